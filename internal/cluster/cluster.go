@@ -17,8 +17,10 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/jetstack/jsctl/internal/client"
+	k8syaml "github.com/jetstack/jsctl/internal/kubernetes/yaml"
 )
 
 type (
@@ -106,6 +108,9 @@ func Delete(ctx context.Context, httpClient HTTPClient, organization, name strin
 //go:embed templates/agent.yaml
 var agentYAML string
 
+// AgentDeployment is the namespaced name of the agent Deployment created by ApplyAgentYAML.
+var AgentDeployment = types.NamespacedName{Namespace: "jetstack-secure", Name: "agent"}
+
 // ApplyAgentYAMLOptions contains options for creating a YAML bundle to install the Jetstack Secure agent
 type ApplyAgentYAMLOptions struct {
 	Organization   string          // The user's organization
@@ -142,6 +147,26 @@ func ApplyAgentYAML(ctx context.Context, applier Applier, options ApplyAgentYAML
 	return applier.Apply(ctx, buf)
 }
 
+// AgentImages returns the images deployed by ApplyAgentYAML when the agent image is pulled from imageRegistry.
+func AgentImages(imageRegistry string) ([]string, error) {
+	tpl, err := template.New("deploy").Parse(agentYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if err = tpl.Execute(buf, map[string]interface{}{"ImageRegistry": imageRegistry}); err != nil {
+		return nil, err
+	}
+
+	resources, err := k8syaml.Load(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return k8syaml.Images(resources), nil
+}
+
 func marshalBase64(in interface{}) ([]byte, error) {
 	jsonData, err := json.Marshal(in)
 	if err != nil {
@@ -89,3 +89,13 @@ func TestDelete(t *testing.T) {
 func timePointer(t time.Time) *time.Time {
 	return &t
 }
+
+func TestAgentImages(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should return the agent image from the registry", func(t *testing.T) {
+		images, err := cluster.AgentImages("registry.example.com/jetstack")
+		assert.NoError(t, err)
+		assert.EqualValues(t, []string{"registry.example.com/jetstack/preflight:v0.1.39"}, images)
+	})
+}
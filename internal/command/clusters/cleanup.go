@@ -1,23 +1,25 @@
 package clusters
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/rest"
 
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/kubernetes"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/ownerrefs"
 	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
+	"github.com/jetstack/jsctl/internal/prompt"
 )
 
 // CleanUp returns a new command that wraps cluster clean up commands
@@ -27,7 +29,10 @@ func CleanUp(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
 		Short: "Contains commands to prepare a cluster for the uninstallation of Jetstack Secure software",
 	}
 
-	cmd.AddCommand(secrets(run, *kubeConfigPath))
+	cmd.AddCommand(
+		secrets(run, *kubeConfigPath),
+		leftovers(run, *kubeConfigPath),
+	)
 
 	return cmd
 }
@@ -38,17 +43,25 @@ func secrets(run types.RunFunc, kubeConfigPath string) *cobra.Command {
 		Short: "Perform operations to ensure secrets with issued X.509 certificates are not deleted when Jetstack Secure software is uninstalled",
 	}
 
-	cmd.AddCommand(removeSecretOwnerReferences(run, kubeConfigPath))
+	cmd.AddCommand(
+		removeSecretOwnerReferences(run, kubeConfigPath),
+		restoreSecretOwnerReferences(run, kubeConfigPath),
+	)
 
 	return cmd
 }
 
 func removeSecretOwnerReferences(run types.RunFunc, kubeConfigPath string) *cobra.Command {
-	return &cobra.Command{
+	var journalPath string
+	var dryRun bool
+
+	cmd := &cobra.Command{
 		Use:   "remove-certificate-owner-refs",
 		Short: "Remove certificate owner references from secret resources",
-		Long:  "Removing Certificate owner references from secrets allows the uninstallation of cert-manager (including CRDs) without deleting the secrets that contain the issued X.509 certificates. This allows the uninstallation of cert-manager without causing application downtime or unneccessary certificate re-issuance. After cert-manager is re-installed and the Certificate resources are be re-applied, the existing secrets will be picked up for the Certificates.",
-		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		Long: `Removing Certificate owner references from secrets allows the uninstallation of cert-manager (including CRDs) without deleting the secrets that contain the issued X.509 certificates. This allows the uninstallation of cert-manager without causing application downtime or unneccessary certificate re-issuance. After cert-manager is re-installed and the Certificate resources are be re-applied, the existing secrets will be picked up for the Certificates.
+
+Every removed owner reference is recorded in a journal file (see --journal), which can be used to re-attach the owner references with the 'restore-certificate-owner-refs' command once cert-manager has been re-installed.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(0)),
 		Run: run(func(ctx context.Context, args []string) error {
 			kubeCfg, err := kubernetes.NewConfig(kubeConfigPath)
 			if err != nil {
@@ -87,9 +100,15 @@ func removeSecretOwnerReferences(run types.RunFunc, kubeConfigPath string) *cobr
 					Kind:       "pods",
 				},
 			)
+			if err != nil {
+				return fmt.Errorf("error creating pod client: %s", err)
+			}
 
 			var pods corev1.PodList
 			err = podClient.List(ctx, &clients.GenericRequestOptions{}, &pods)
+			if err != nil {
+				return fmt.Errorf("error listing pods: %s", err)
+			}
 
 			md := components.MatchData{Pods: pods.Items}
 
@@ -121,70 +140,53 @@ Next Steps:
 			// cert-manager to remove them, even if cert-manager is running.
 			// Older versions of cert-manager do not remove the ownerReferences
 			// when the flag is unset.
-			secretsClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
-				&clients.GenericClientOptions{
-					RestConfig: kubeCfg,
-					APIPath:    "/api/",
-					Group:      corev1.GroupName,
-					Version:    corev1.SchemeGroupVersion.Version,
-					Kind:       "secrets",
-				},
-			)
+			secretsClient, err := newSecretsClient(kubeCfg)
+			if err != nil {
+				return err
+			}
 
 			var secretsList corev1.SecretList
 			err = secretsClient.List(ctx, &clients.GenericRequestOptions{}, &secretsList)
+			if err != nil {
+				return fmt.Errorf("error listing secrets: %s", err)
+			}
 
 			var count int
 			var operations []func() error
+			var journal ownerrefs.Journal
 
 			for i := range secretsList.Items {
 				secret := &secretsList.Items[i]
 
-				hasCertificatOwnerRef := false
-				for _, ownerRef := range secret.OwnerReferences {
-					if ownerRef.Kind == "Certificate" {
-						hasCertificatOwnerRef = true
-						break
-					}
+				newSecret, removed := ownerrefs.WithoutCertificateOwnerRefs(secret)
+				if len(removed) == 0 {
+					continue
 				}
 
-				if hasCertificatOwnerRef {
-					count += 1
-					fmt.Fprintf(os.Stderr, "%s/%s needs update\n", secret.Namespace, secret.Name)
-					newSecret := secret.DeepCopy()
-					newSecret.OwnerReferences = []metav1.OwnerReference{}
-
-					for _, ownerRef := range secret.OwnerReferences {
-						if ownerRef.Kind != "Certificate" {
-							newSecret.OwnerReferences = append(newSecret.OwnerReferences, ownerRef)
-							break
-						}
-					}
-
-					secretData, err := json.Marshal(secret)
-					if err != nil {
-						return fmt.Errorf("error marshalling secret: %s", err)
-					}
-					newSecretData, err := json.Marshal(newSecret)
-					if err != nil {
-						return fmt.Errorf("error marshalling new secret: %s", err)
-					}
+				count += 1
+				fmt.Fprintf(os.Stderr, "%s/%s needs update\n", secret.Namespace, secret.Name)
 
-					operations = append(operations, func() error {
-						patch, err := strategicpatch.CreateTwoWayMergePatch(secretData, newSecretData, corev1.Secret{})
-						if err != nil {
-							return fmt.Errorf("error creating patch for secret %s: %s", secret.Name, err)
-						}
+				journal.Add(secret, removed)
 
-						err = secretsClient.Patch(ctx, &clients.GenericRequestOptions{Name: secret.Name, Namespace: secret.Namespace}, patch)
-						if err != nil {
-							return fmt.Errorf("error patching secret %s: %s", secret.Name, err)
-						}
+				patch, err := ownerrefs.SecretPatch(secret, newSecret)
+				if err != nil {
+					return err
+				}
 
-						fmt.Fprintf(os.Stderr, "%s/%s updated\n", secret.Namespace, secret.Name)
-						return nil
-					})
+				if dryRun {
+					fmt.Fprintf(os.Stdout, "%s/%s: %s\n", secret.Namespace, secret.Name, patch)
+					continue
 				}
+
+				operations = append(operations, func() error {
+					err := secretsClient.Patch(ctx, &clients.GenericRequestOptions{Name: secret.Name, Namespace: secret.Namespace}, patch)
+					if err != nil {
+						return fmt.Errorf("error patching secret %s: %s", secret.Name, err)
+					}
+
+					fmt.Fprintf(os.Stderr, "%s/%s updated\n", secret.Namespace, secret.Name)
+					return nil
+				})
 			}
 
 			if count == 0 {
@@ -193,26 +195,609 @@ Next Steps:
 			}
 
 			fmt.Fprintf(os.Stderr, "Found %d secrets with ownerReferences to Certificate resources\n", count)
-			fmt.Fprintf(os.Stderr, "Would you like to update the owner references of %d secrets? (yes)\n", count)
-			fmt.Fprintf(os.Stderr, "> ")
-			reader := bufio.NewReader(os.Stdin)
-			response, err := reader.ReadString('\n')
+			if dryRun {
+				fmt.Fprintf(os.Stderr, "Dry run, no action taken\n")
+				return nil
+			}
+
+			ok, err := prompt.YesNo(os.Stdin, os.Stderr, "Would you like to update the owner references of %d secrets?", count)
 			if err != nil {
-				return fmt.Errorf("error reading input: %s", err)
+				return err
+			}
+			if !ok {
+				fmt.Fprintf(os.Stderr, "No action taken\n")
+				return nil
 			}
-			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(response)), "yes") {
-				for _, operation := range operations {
-					err = operation()
+
+			// the journal is written before any secrets are patched so
+			// that no removed owner references are lost if patching fails
+			if err := ownerrefs.AppendJournal(journalPath, journal); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Removed owner references recorded in %s\n", journalPath)
+
+			for _, operation := range operations {
+				if err := operation(); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&journalPath, "journal", ownerrefs.DefaultJournalPath, "path of the journal file to record removed owner references in, entries are appended if the file exists")
+	flags.BoolVar(&dryRun, "dry-run", false, "if set, the patches are printed to stdout rather than applied")
+
+	return cmd
+}
+
+func restoreSecretOwnerReferences(run types.RunFunc, kubeConfigPath string) *cobra.Command {
+	var journalPath string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "restore-certificate-owner-refs",
+		Short: "Restore certificate owner references to secret resources",
+		Long:  "Re-attaches the Certificate owner references recorded by 'remove-certificate-owner-refs' to secrets. Run this after cert-manager has been re-installed and the Certificate resources have been re-applied. Owner references are matched to Certificates by namespace and name, since re-applied Certificates have new UIDs.",
+		Args:  cobra.MatchAll(cobra.ExactArgs(0)),
+		Run: run(func(ctx context.Context, args []string) error {
+			journal, err := ownerrefs.ReadJournal(journalPath)
+			if err != nil {
+				return err
+			}
+			if len(journal.Entries) == 0 {
+				fmt.Fprintf(os.Stderr, "Journal %s contains no owner references, no action needed\n", journalPath)
+				return nil
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(kubeConfigPath)
+			if err != nil {
+				return err
+			}
+
+			secretsClient, err := newSecretsClient(kubeCfg)
+			if err != nil {
+				return err
+			}
+			certificatesClient, err := clients.NewCertificateClient(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("error creating certificate client: %s", err)
+			}
+
+			var count int
+			var operations []func() error
+
+			for _, secretEntries := range journal.BySecret() {
+				namespace, name := secretEntries[0].Namespace, secretEntries[0].SecretName
+
+				var secret corev1.Secret
+				err := secretsClient.Get(ctx, &clients.GenericRequestOptions{Name: name, Namespace: namespace}, &secret)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s/%s could not be fetched, skipping: %s\n", namespace, name, err)
+					continue
+				}
+
+				newSecret := secret.DeepCopy()
+				for _, entry := range secretEntries {
+					var certificate cmapi.Certificate
+					err := certificatesClient.Get(ctx, &clients.GenericRequestOptions{Name: entry.CertificateName, Namespace: namespace}, &certificate)
 					if err != nil {
-						return err
+						fmt.Fprintf(os.Stderr, "%s/%s certificate could not be fetched, skipping owner reference for secret %s: %s\n", namespace, entry.CertificateName, name, err)
+						continue
 					}
+
+					newSecret.OwnerReferences = withRestoredOwnerRef(newSecret.OwnerReferences, entry, &certificate)
 				}
-			} else {
+
+				if len(newSecret.OwnerReferences) == len(secret.OwnerReferences) {
+					continue
+				}
+
+				count += 1
+				fmt.Fprintf(os.Stderr, "%s/%s needs update\n", namespace, name)
+
+				patch, err := ownerrefs.SecretPatch(&secret, newSecret)
+				if err != nil {
+					return err
+				}
+
+				if dryRun {
+					fmt.Fprintf(os.Stdout, "%s/%s: %s\n", namespace, name, patch)
+					continue
+				}
+
+				operations = append(operations, func() error {
+					err := secretsClient.Patch(ctx, &clients.GenericRequestOptions{Name: name, Namespace: namespace}, patch)
+					if err != nil {
+						return fmt.Errorf("error patching secret %s: %s", name, err)
+					}
+
+					fmt.Fprintf(os.Stderr, "%s/%s updated\n", namespace, name)
+					return nil
+				})
+			}
+
+			if count == 0 {
+				fmt.Fprintf(os.Stderr, "No secrets found that need Certificate owner references restored, no action needed\n")
+				return nil
+			}
+
+			if dryRun {
+				fmt.Fprintf(os.Stderr, "Dry run, no action taken\n")
+				return nil
+			}
+
+			ok, err := prompt.YesNo(os.Stdin, os.Stderr, "Would you like to restore the owner references of %d secrets?", count)
+			if err != nil {
+				return err
+			}
+			if !ok {
 				fmt.Fprintf(os.Stderr, "No action taken\n")
 				return nil
 			}
 
+			for _, operation := range operations {
+				if err := operation(); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		}),
 	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&journalPath, "journal", ownerrefs.DefaultJournalPath, "path of the journal file written by remove-certificate-owner-refs")
+	flags.BoolVar(&dryRun, "dry-run", false, "if set, the patches are printed to stdout rather than applied")
+
+	return cmd
+}
+
+// withRestoredOwnerRef returns ownerRefs with the owner reference recorded in
+// entry added, pointing at the re-applied certificate. If ownerRefs already
+// reference the certificate, they are returned unchanged.
+func withRestoredOwnerRef(ownerRefs []metav1.OwnerReference, entry ownerrefs.JournalEntry, certificate *cmapi.Certificate) []metav1.OwnerReference {
+	for _, ownerRef := range ownerRefs {
+		if ownerRef.UID == certificate.UID {
+			return ownerRefs
+		}
+	}
+
+	ownerRef := entry.OwnerReference
+	ownerRef.UID = certificate.UID
+	ownerRef.Name = certificate.Name
+
+	return append(ownerRefs, ownerRef)
+}
+
+// leftoverKind describes a kind of cluster resource which can be left behind
+// after cert-manager has been uninstalled
+type leftoverKind struct {
+	// kind is the kind of the resource, used when presenting leftovers
+	kind string
+	// apiPath, group, version and resource are used to build a client for the
+	// resource
+	apiPath  string
+	group    string
+	version  string
+	resource string
+	// namespace is set for namespaced resources and limits the search to
+	// that namespace
+	namespace string
+	// names are the names of the resources of this kind created by the
+	// cert-manager Helm chart and static manifests
+	names []string
+	// related returns true if the named resource may belong to another
+	// component of the cert-manager ecosystem, such as approver-policy or
+	// trust-manager, which share cert-manager's naming
+	related func(name string) bool
+}
+
+// match returns true if the named resource belongs to cert-manager itself
+func (k leftoverKind) match(name string) bool {
+	for _, n := range k.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// certManagerRBACNames are the names of the ClusterRoles and
+// ClusterRoleBindings created for cert-manager
+var certManagerRBACNames = []string{
+	"cert-manager-cainjector",
+	"cert-manager-controller-issuers",
+	"cert-manager-controller-clusterissuers",
+	"cert-manager-controller-certificates",
+	"cert-manager-controller-orders",
+	"cert-manager-controller-challenges",
+	"cert-manager-controller-ingress-shim",
+	"cert-manager-controller-approve:cert-manager-io",
+	"cert-manager-controller-certificatesigningrequests",
+	"cert-manager-webhook:subjectaccessreviews",
+}
+
+// certManagerLeaderElectionNames are the names of the Leases, and the
+// ConfigMaps used by older versions, that cert-manager uses for leader election
+var certManagerLeaderElectionNames = []string{
+	"cert-manager-controller",
+	"cert-manager-cainjector-leader-election",
+	"cert-manager-cainjector-leader-election-core",
+}
+
+// certManagerCRDs are the names of the CRDs installed by cert-manager, other
+// CRDs in cert-manager.io groups belong to other components
+var certManagerCRDs = []string{
+	"certificaterequests.cert-manager.io",
+	"certificates.cert-manager.io",
+	"clusterissuers.cert-manager.io",
+	"issuers.cert-manager.io",
+	"challenges.acme.cert-manager.io",
+	"orders.acme.cert-manager.io",
+}
+
+// leftoverKinds are the kinds of resources checked by the leftovers command.
+// CRDs are listed last as deleting them also deletes any remaining cert-manager
+// custom resources.
+var leftoverKinds = []leftoverKind{
+	{
+		kind:     "ValidatingWebhookConfiguration",
+		group:    "admissionregistration.k8s.io",
+		version:  "v1",
+		resource: "validatingwebhookconfigurations",
+		names:    []string{"cert-manager-webhook"},
+		related:  hasCertManagerPrefix,
+	},
+	{
+		kind:     "MutatingWebhookConfiguration",
+		group:    "admissionregistration.k8s.io",
+		version:  "v1",
+		resource: "mutatingwebhookconfigurations",
+		names:    []string{"cert-manager-webhook"},
+		related:  hasCertManagerPrefix,
+	},
+	{
+		kind:     "APIService",
+		group:    "apiregistration.k8s.io",
+		version:  "v1",
+		resource: "apiservices",
+		// the webhook was served as an APIService before cert-manager v0.14
+		names:   []string{"v1beta1.webhook.cert-manager.io"},
+		related: isEcosystemAPIService,
+	},
+	{
+		kind:     "ClusterRoleBinding",
+		group:    "rbac.authorization.k8s.io",
+		version:  "v1",
+		resource: "clusterrolebindings",
+		names:    certManagerRBACNames,
+		related:  hasCertManagerPrefix,
+	},
+	{
+		kind:     "ClusterRole",
+		group:    "rbac.authorization.k8s.io",
+		version:  "v1",
+		resource: "clusterroles",
+		names:    append([]string{"cert-manager-view", "cert-manager-edit", "cert-manager-cluster-view"}, certManagerRBACNames...),
+		related:  hasCertManagerPrefix,
+	},
+	{
+		kind:      "Lease",
+		group:     "coordination.k8s.io",
+		version:   "v1",
+		resource:  "leases",
+		namespace: "kube-system",
+		names:     certManagerLeaderElectionNames,
+		related:   hasCertManagerPrefix,
+	},
+	{
+		kind:      "ConfigMap",
+		apiPath:   "/api/",
+		group:     corev1.GroupName,
+		version:   corev1.SchemeGroupVersion.Version,
+		resource:  "configmaps",
+		namespace: "kube-system",
+		names:     certManagerLeaderElectionNames,
+		related:   hasCertManagerPrefix,
+	},
+	{
+		kind:     "CustomResourceDefinition",
+		group:    apiextensionsv1.GroupName,
+		version:  apiextensionsv1.SchemeGroupVersion.Version,
+		resource: "customresourcedefinitions",
+		names:    certManagerCRDs,
+		related:  isCertManagerGroupResource,
+	},
+}
+
+// hasCertManagerPrefix matches resources named after cert-manager ecosystem
+// components, e.g. cert-manager-webhook or cert-manager-approver-policy
+func hasCertManagerPrefix(name string) bool {
+	return strings.HasPrefix(name, "cert-manager-")
+}
+
+// isCertManagerGroupResource matches resources named after cert-manager
+// ecosystem API groups, e.g. the certificates.cert-manager.io or
+// bundles.trust.cert-manager.io CRDs
+func isCertManagerGroupResource(name string) bool {
+	return strings.HasSuffix(name, ".cert-manager.io")
+}
+
+// isEcosystemAPIService matches APIServices for cert-manager ecosystem API
+// groups other than those served by cert-manager's own CRDs, e.g.
+// v1alpha1.policy.cert-manager.io
+func isEcosystemAPIService(name string) bool {
+	_, group, _ := strings.Cut(name, ".")
+	return isCertManagerGroupResource(name) && !isCertManagerAPIGroup(group)
+}
+
+// isCertManagerAPIGroup returns true if group is served by one of
+// cert-manager's own CRDs
+func isCertManagerAPIGroup(group string) bool {
+	for _, crd := range certManagerCRDs {
+		if _, crdGroup, _ := strings.Cut(crd, "."); crdGroup == group {
+			return true
+		}
+	}
+	return false
+}
+
+// isCertManagerCRD returns true if name is one of the CRDs installed by
+// cert-manager
+func isCertManagerCRD(name string) bool {
+	for _, crd := range certManagerCRDs {
+		if crd == name {
+			return true
+		}
+	}
+	return false
+}
+
+// automanagedLabel is set by the kube-aggregator on the APIServices it
+// creates for each group version served by a CRD. These are removed along with
+// the CRDs and must not be deleted directly.
+const automanagedLabel = "kube-aggregator.kubernetes.io/automanaged"
+
+// ecosystemCRDs returns the names of the CRDs served in cert-manager.io API
+// groups that were not installed by cert-manager, such as those of
+// approver-policy or trust-manager
+func ecosystemCRDs(crds []apiextensionsv1.CustomResourceDefinition) []string {
+	var found []string
+	for _, crd := range crds {
+		if crd.Spec.Group != "cert-manager.io" && !strings.HasSuffix(crd.Spec.Group, ".cert-manager.io") {
+			continue
+		}
+		if isCertManagerCRD(crd.Name) {
+			continue
+		}
+		found = append(found, crd.Name)
+	}
+	return found
+}
+
+// leftoverClient is a client for a kind of leftover resource. Only resource
+// metadata is needed to identify leftovers.
+type leftoverClient struct {
+	leftoverKind
+	client clients.Generic[*metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList]
+}
+
+// leftover is a single resource left behind after cert-manager was uninstalled
+type leftover struct {
+	client    leftoverClient
+	Namespace string
+	Name      string
+}
+
+func (l leftover) String() string {
+	if l.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", l.client.kind, l.Namespace, l.Name)
+	}
+	return fmt.Sprintf("%s %s", l.client.kind, l.Name)
+}
+
+func leftovers(run types.RunFunc, kubeConfigPath string) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "leftovers",
+		Short: "Remove cluster resources left behind after cert-manager has been uninstalled",
+		Long: `Finds and deletes cert-manager resources which are commonly left in the cluster after cert-manager has been uninstalled: webhook configurations, APIServices, ClusterRoles and ClusterRoleBindings, leader election Leases and ConfigMaps in kube-system, and cert-manager CRDs. Resources are matched by the exact names used by the cert-manager Helm chart and static manifests.
+
+Other components of the cert-manager ecosystem, such as approver-policy, trust-manager, csi-driver and istio-csr, use similar names and cert-manager.io API groups. Nothing is removed while any of their CRDs are found in the cluster. Other resources which may belong to them, such as cert-manager-* ClusterRoles or Leases not created by cert-manager, are listed as skipped and left in place.
+
+Deleting the cert-manager CRDs also deletes any remaining cert-manager custom resources. Run 'jsctl experimental clusters cleanup secrets remove-certificate-owner-refs' first so that secrets containing issued certificates are not garbage collected.`,
+		Args: cobra.MatchAll(cobra.ExactArgs(0)),
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(kubeConfigPath)
+			if err != nil {
+				return err
+			}
+
+			// leftovers are only safe to remove once cert-manager is no
+			// longer running, otherwise it would recreate them
+			podClient, err := clients.NewGenericClient[*corev1.Pod, *corev1.PodList](
+				&clients.GenericClientOptions{
+					RestConfig: kubeCfg,
+					APIPath:    "/api/",
+					Group:      corev1.GroupName,
+					Version:    corev1.SchemeGroupVersion.Version,
+					Kind:       "pods",
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("error creating pod client: %s", err)
+			}
+
+			var pods corev1.PodList
+			err = podClient.List(ctx, &clients.GenericRequestOptions{}, &pods)
+			if err != nil {
+				return fmt.Errorf("error listing pods: %s", err)
+			}
+
+			var certManagerStatus components.CertManagerStatus
+			found, err := certManagerStatus.Match(&components.MatchData{Pods: pods.Items})
+			if err != nil {
+				return fmt.Errorf("error matching cert-manager status: %s", err)
+			}
+			if found {
+				fmt.Fprintf(os.Stderr, "cert-manager is still running in this cluster, uninstall cert-manager before removing leftover resources.\n")
+				fmt.Fprintf(os.Stderr, "No cleanup action has been taken at this time\n")
+				return nil
+			}
+
+			var leftoverClients []leftoverClient
+			for _, kind := range leftoverKinds {
+				apiPath := kind.apiPath
+				if apiPath == "" {
+					apiPath = "/apis/"
+				}
+				client, err := clients.NewGenericClient[*metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList](
+					&clients.GenericClientOptions{
+						RestConfig: kubeCfg,
+						APIPath:    apiPath,
+						Group:      kind.group,
+						Version:    kind.version,
+						Kind:       kind.resource,
+					},
+				)
+				if err != nil {
+					return fmt.Errorf("error creating %s client: %s", kind.kind, err)
+				}
+				leftoverClients = append(leftoverClients, leftoverClient{leftoverKind: kind, client: client})
+			}
+
+			fmt.Fprintf(os.Stderr, "Checking for resources left behind by cert-manager...\n")
+
+			leftoverResources, related, err := findLeftovers(ctx, leftoverClients)
+			if err != nil {
+				return err
+			}
+
+			// cert-manager's CRDs are relied on by other components such as
+			// approver-policy and trust-manager, so nothing is removed while
+			// any of them are installed
+			crdClient, err := clients.NewCRDClient(kubeCfg)
+			if err != nil {
+				return fmt.Errorf("error creating CRD client: %s", err)
+			}
+			var crds apiextensionsv1.CustomResourceDefinitionList
+			if err := crdClient.List(ctx, &clients.GenericRequestOptions{}, &crds); err != nil {
+				return fmt.Errorf("error listing CRDs: %s", err)
+			}
+			if ecosystem := ecosystemCRDs(crds.Items); len(ecosystem) > 0 {
+				fmt.Fprintf(os.Stderr, "Other cert-manager ecosystem components are still installed in this cluster, uninstall them before removing leftover cert-manager resources:\n")
+				for _, crd := range ecosystem {
+					fmt.Fprintf(os.Stderr, "  CustomResourceDefinition %s\n", crd)
+				}
+				fmt.Fprintf(os.Stderr, "No cleanup action has been taken at this time\n")
+				return nil
+			}
+
+			// resources which only share cert-manager's naming are left in
+			// place, they may belong to other components
+			for _, l := range related {
+				fmt.Fprintf(os.Stderr, "Skipping %s, it may belong to another cert-manager ecosystem component\n", l)
+			}
+
+			if len(leftoverResources) == 0 {
+				fmt.Fprintf(os.Stderr, "No leftover cert-manager resources found, no action needed\n")
+				return nil
+			}
+
+			for _, l := range leftoverResources {
+				fmt.Fprintf(os.Stdout, "%s\n", l)
+			}
+
+			if dryRun {
+				fmt.Fprintf(os.Stderr, "Dry run, no action taken\n")
+				return nil
+			}
+
+			ok, err := prompt.YesNo(os.Stdin, os.Stderr, "Would you like to delete these %d resources?", len(leftoverResources))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintf(os.Stderr, "No action taken\n")
+				return nil
+			}
+
+			return deleteLeftovers(ctx, leftoverResources)
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.BoolVar(&dryRun, "dry-run", false, "if set, leftover resources are listed but not deleted")
+
+	return cmd
+}
+
+// findLeftovers lists the resources of each kind and returns those which
+// belong to cert-manager, along with those which may belong to other cert-manager
+// ecosystem components. Kinds which are not served by the cluster, such as
+// APIServices on some managed clusters, are skipped.
+func findLeftovers(ctx context.Context, leftoverClients []leftoverClient) ([]leftover, []leftover, error) {
+	var found, related []leftover
+
+	for _, c := range leftoverClients {
+		var list metav1.PartialObjectMetadataList
+		err := c.client.List(ctx, &clients.GenericRequestOptions{Namespace: c.namespace}, &list)
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return nil, nil, fmt.Errorf("error listing %s resources: %w", c.kind, err)
+		}
+
+		for _, item := range list.Items {
+			if _, ok := item.Labels[automanagedLabel]; ok {
+				continue
+			}
+			l := leftover{client: c, Namespace: c.namespace, Name: item.Name}
+			switch {
+			case c.match(item.Name):
+				found = append(found, l)
+			case c.related != nil && c.related(item.Name):
+				related = append(related, l)
+			}
+		}
+	}
+
+	return found, related, nil
+}
+
+// deleteLeftovers deletes each of the leftover resources in order. Resources
+// which have already been deleted are ignored.
+func deleteLeftovers(ctx context.Context, found []leftover) error {
+	for _, l := range found {
+		err := l.client.client.Delete(ctx, &clients.GenericRequestOptions{Name: l.Name, Namespace: l.Namespace})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error deleting %s: %w", l, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s deleted\n", l)
+	}
+
+	return nil
+}
+
+func newSecretsClient(kubeCfg *rest.Config) (clients.Generic[*corev1.Secret, *corev1.SecretList], error) {
+	secretsClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&clients.GenericClientOptions{
+			RestConfig: kubeCfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating secrets client: %s", err)
+	}
+
+	return secretsClient, nil
 }
@@ -0,0 +1,246 @@
+package clusters
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/ownerrefs"
+)
+
+func Test_withRestoredOwnerRef(t *testing.T) {
+	entry := ownerrefs.JournalEntry{
+		Namespace:       "foo",
+		SecretName:      "foo",
+		CertificateName: "foo",
+		CertificateUID:  "old",
+		OwnerReference: metav1.OwnerReference{
+			APIVersion: "cert-manager.io/v1",
+			Kind:       cmapi.CertificateKind,
+			Name:       "foo",
+			UID:        "old",
+		},
+	}
+	certificate := &cmapi.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "foo", UID: "new"},
+	}
+	restoredRef := metav1.OwnerReference{
+		APIVersion: "cert-manager.io/v1",
+		Kind:       cmapi.CertificateKind,
+		Name:       "foo",
+		UID:        "new",
+	}
+	deploymentRef := metav1.OwnerReference{Kind: "Deployment", Name: "bar", UID: "2"}
+
+	tests := map[string]struct {
+		ownerRefs []metav1.OwnerReference
+		want      []metav1.OwnerReference
+	}{
+		"owner reference should be restored with the new certificate UID": {
+			ownerRefs: nil,
+			want:      []metav1.OwnerReference{restoredRef},
+		},
+		"existing owner references should be kept": {
+			ownerRefs: []metav1.OwnerReference{deploymentRef},
+			want:      []metav1.OwnerReference{deploymentRef, restoredRef},
+		},
+		"owner reference already present should not be duplicated": {
+			ownerRefs: []metav1.OwnerReference{restoredRef},
+			want:      []metav1.OwnerReference{restoredRef},
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := withRestoredOwnerRef(scenario.ownerRefs, entry, certificate)
+			if !reflect.DeepEqual(got, scenario.want) {
+				t.Errorf("withRestoredOwnerRef() = %v, want %v", got, scenario.want)
+			}
+		})
+	}
+}
+
+// fakeLeftoverClient returns a leftoverClient for kind which lists the named
+// resources and records deletions in deleted
+func fakeLeftoverClient(kind leftoverKind, names []string, listErr error, deleted *[]string) leftoverClient {
+	return leftoverClient{
+		leftoverKind: kind,
+		client: &clients.FakeGeneric[*metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList]{
+			FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, list *metav1.PartialObjectMetadataList) error {
+				if listErr != nil {
+					return listErr
+				}
+				for _, name := range names {
+					list.Items = append(list.Items, metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: name}})
+				}
+				return nil
+			},
+			FakeDelete: func(_ context.Context, options *clients.GenericRequestOptions) error {
+				*deleted = append(*deleted, options.Namespace+"/"+options.Name)
+				return nil
+			},
+		},
+	}
+}
+
+// automanaged labels every resource listed by c as created by the
+// kube-aggregator
+func automanaged(c leftoverClient) leftoverClient {
+	fake := c.client.(*clients.FakeGeneric[*metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList])
+	list := fake.FakeList
+	fake.FakeList = func(ctx context.Context, options *clients.GenericRequestOptions, l *metav1.PartialObjectMetadataList) error {
+		if err := list(ctx, options, l); err != nil {
+			return err
+		}
+		for i := range l.Items {
+			l.Items[i].Labels = map[string]string{automanagedLabel: "onstart"}
+		}
+		return nil
+	}
+	return c
+}
+
+func Test_findLeftovers(t *testing.T) {
+	webhookKind := leftoverKinds[0]
+	apiServiceKind := leftoverKinds[2]
+	crdKind := leftoverKinds[len(leftoverKinds)-1]
+	leaseKind := leftoverKind{kind: "Lease", namespace: "kube-system", names: certManagerLeaderElectionNames, related: hasCertManagerPrefix}
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "apiservices"}, "")
+
+	tests := map[string]struct {
+		clients     []leftoverClient
+		want        []string
+		wantRelated []string
+		wantErr     bool
+	}{
+		"no leftovers should be found in a clean cluster": {
+			clients: []leftoverClient{
+				fakeLeftoverClient(webhookKind, []string{"other-webhook"}, nil, nil),
+				fakeLeftoverClient(crdKind, []string{"foos.example.com"}, nil, nil),
+			},
+		},
+		"cert-manager resources should be found": {
+			clients: []leftoverClient{
+				fakeLeftoverClient(webhookKind, []string{"cert-manager-webhook", "other-webhook"}, nil, nil),
+				fakeLeftoverClient(leaseKind, []string{"cert-manager-controller", "kube-scheduler"}, nil, nil),
+				fakeLeftoverClient(crdKind, []string{"certificates.cert-manager.io", "orders.acme.cert-manager.io", "foos.example.com"}, nil, nil),
+			},
+			want: []string{
+				"ValidatingWebhookConfiguration cert-manager-webhook",
+				"Lease kube-system/cert-manager-controller",
+				"CustomResourceDefinition certificates.cert-manager.io",
+				"CustomResourceDefinition orders.acme.cert-manager.io",
+			},
+		},
+		"resources of other cert-manager ecosystem components should not be matched": {
+			clients: []leftoverClient{
+				fakeLeftoverClient(webhookKind, []string{"cert-manager-webhook", "cert-manager-approver-policy"}, nil, nil),
+				fakeLeftoverClient(crdKind, []string{"certificates.cert-manager.io", "bundles.trust.cert-manager.io", "certificaterequestpolicies.policy.cert-manager.io"}, nil, nil),
+			},
+			want: []string{
+				"ValidatingWebhookConfiguration cert-manager-webhook",
+				"CustomResourceDefinition certificates.cert-manager.io",
+			},
+			wantRelated: []string{
+				"ValidatingWebhookConfiguration cert-manager-approver-policy",
+				"CustomResourceDefinition bundles.trust.cert-manager.io",
+				"CustomResourceDefinition certificaterequestpolicies.policy.cert-manager.io",
+			},
+		},
+		"APIServices for cert-manager's own API groups should not be matched": {
+			clients: []leftoverClient{
+				fakeLeftoverClient(apiServiceKind, []string{"v1beta1.webhook.cert-manager.io", "v1.cert-manager.io", "v1.acme.cert-manager.io", "v1alpha1.policy.cert-manager.io"}, nil, nil),
+			},
+			want:        []string{"APIService v1beta1.webhook.cert-manager.io"},
+			wantRelated: []string{"APIService v1alpha1.policy.cert-manager.io"},
+		},
+		"APIServices managed by the kube-aggregator should be ignored": {
+			clients: []leftoverClient{
+				automanaged(fakeLeftoverClient(apiServiceKind, []string{"v1.cert-manager.io", "v1alpha1.trust.cert-manager.io"}, nil, nil)),
+			},
+		},
+		"kinds not served by the cluster should be skipped": {
+			clients: []leftoverClient{
+				fakeLeftoverClient(apiServiceKind, nil, notFound, nil),
+				fakeLeftoverClient(crdKind, []string{"certificates.cert-manager.io"}, nil, nil),
+			},
+			want: []string{"CustomResourceDefinition certificates.cert-manager.io"},
+		},
+		"other list errors should be returned": {
+			clients: []leftoverClient{
+				fakeLeftoverClient(crdKind, nil, apierrors.NewForbidden(schema.GroupResource{}, "", nil), nil),
+			},
+			wantErr: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			found, related, err := findLeftovers(context.Background(), scenario.clients)
+			if (err != nil) != scenario.wantErr {
+				t.Fatalf("findLeftovers() error = %v, wantErr %v", err, scenario.wantErr)
+			}
+
+			var got, gotRelated []string
+			for _, l := range found {
+				got = append(got, l.String())
+			}
+			for _, l := range related {
+				gotRelated = append(gotRelated, l.String())
+			}
+			if !reflect.DeepEqual(got, scenario.want) {
+				t.Errorf("findLeftovers() = %v, want %v", got, scenario.want)
+			}
+			if !reflect.DeepEqual(gotRelated, scenario.wantRelated) {
+				t.Errorf("findLeftovers() related = %v, want %v", gotRelated, scenario.wantRelated)
+			}
+		})
+	}
+}
+
+func Test_deleteLeftovers(t *testing.T) {
+	var deleted []string
+	leaseClient := fakeLeftoverClient(leftoverKind{kind: "Lease", namespace: "kube-system", names: certManagerLeaderElectionNames}, []string{"cert-manager-controller"}, nil, &deleted)
+	crdClient := fakeLeftoverClient(leftoverKinds[len(leftoverKinds)-1], []string{"certificates.cert-manager.io", "bundles.trust.cert-manager.io"}, nil, &deleted)
+
+	found, _, err := findLeftovers(context.Background(), []leftoverClient{leaseClient, crdClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deleteLeftovers(context.Background(), found); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"kube-system/cert-manager-controller", "/certificates.cert-manager.io"}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleteLeftovers() deleted %v, want %v", deleted, want)
+	}
+}
+
+func Test_ecosystemCRDs(t *testing.T) {
+	crd := func(name, group string) apiextensionsv1.CustomResourceDefinition {
+		return apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: group},
+		}
+	}
+
+	crds := []apiextensionsv1.CustomResourceDefinition{
+		crd("certificates.cert-manager.io", "cert-manager.io"),
+		crd("orders.acme.cert-manager.io", "acme.cert-manager.io"),
+		crd("bundles.trust.cert-manager.io", "trust.cert-manager.io"),
+		crd("certificaterequestpolicies.policy.cert-manager.io", "policy.cert-manager.io"),
+		crd("foos.example.com", "example.com"),
+	}
+
+	want := []string{"bundles.trust.cert-manager.io", "certificaterequestpolicies.policy.cert-manager.io"}
+	if got := ecosystemCRDs(crds); !reflect.DeepEqual(got, want) {
+		t.Errorf("ecosystemCRDs() = %v, want %v", got, want)
+	}
+}
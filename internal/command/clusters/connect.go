@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 
 	"github.com/jetstack/jsctl/internal/client"
 	"github.com/jetstack/jsctl/internal/cluster"
@@ -14,12 +16,16 @@ import (
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/rollout"
 )
 
 // Connect returns a new cobra.Command that connects a cluster to the control plane.
 func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool) *cobra.Command {
 	const defaultRegistry = "quay.io/jetstack"
 	var registry string
+	var waitForReady bool
+	var timeout time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "connect name",
@@ -30,6 +36,9 @@ func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool)
 			if name == "" {
 				return errors.New("you must specify a cluster name")
 			}
+			if waitForReady && *useStdout {
+				return errors.New("cannot specify both --wait and --stdout")
+			}
 
 			cnf, ok := config.FromContext(ctx)
 			if !ok || cnf.Organization == "" {
@@ -64,6 +73,23 @@ func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool)
 				return fmt.Errorf("failed to generate agent YAML: %w", err)
 			}
 
+			if waitForReady {
+				kubeCfg, err := kubernetes.NewConfig(*kubeConfigPath)
+				if err != nil {
+					return err
+				}
+
+				deploymentClient, err := clients.NewDeploymentClient(kubeCfg)
+				if err != nil {
+					return fmt.Errorf("error creating deployment client: %s", err)
+				}
+
+				err = rollout.WaitForDeployments(ctx, deploymentClient, []k8stypes.NamespacedName{cluster.AgentDeployment}, rollout.DefaultInterval, timeout, os.Stderr)
+				if err != nil {
+					return err
+				}
+			}
+
 			fmt.Fprintf(os.Stderr, "Once connected, you can view the cluster in the dashboard:\n"+
 				"https://platform.jetstack.io/org/%s/certinventory/cluster/%s\n", cnf.Organization, name)
 
@@ -73,6 +99,8 @@ func Connect(run types.RunFunc, kubeConfigPath, apiURL *string, useStdout *bool)
 
 	flags := cmd.PersistentFlags()
 	flags.StringVar(&registry, "registry", defaultRegistry, "Specifies an alternative image registry to use for the agent image")
+	flags.BoolVar(&waitForReady, "wait", false, "If set, waits for the agent Deployment to be rolled out before returning")
+	flags.DurationVar(&timeout, "timeout", 5*time.Minute, "How long to wait for the agent to become ready when --wait is set")
 
 	return cmd
 }
@@ -2,17 +2,25 @@ package clusters
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/utils/clock"
 
+	internalerrors "github.com/jetstack/jsctl/internal/command/errors"
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/kubernetes"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
@@ -34,8 +42,231 @@ const (
 	failedInfoHeader             = "Some certificates are currently failing issuance attempts. You might want to fix any issues before uninstalling."
 	integrationHeader            = "A cert-manager integration that creates certificate requests was found in cluster. You might want to ensure that uninstalling Jetstack Secure software will not cause downtime."
 	integrationInfoTemplate      = "%s found in cluster"
+	csiWorkloadInfoTemplate      = "%s/%s %s has %d pod(s) mounting volumes from %s"
+	csiWorkloadHeader            = "Some workloads mount certificates using cert-manager CSI drivers. These workloads will not be able to get certificates while the CSI drivers are uninstalled."
+	unapprovedRequestTemplate    = "%s/%s certificate request has not been approved or denied"
+	pendingRequestTemplate       = "%s/%s certificate request has been approved but not yet issued"
+	pendingRequestsHeader        = "Some certificate requests are in flight. You might want to ensure that they are approved and issued, or deleted, before uninstalling to avoid orphaned requests to issuers."
+	pendingOrderTemplate         = "%s/%s ACME order is in state %q"
+	pendingOrdersHeader          = "Some ACME orders are in flight. You might want to ensure that they complete before uninstalling to avoid unnecessary requests to the ACME server."
+	pendingChallengeTemplate     = "%s/%s %s challenge for %s is in state %q (presented: %t)"
+	pendingChallengesHeader      = "Some ACME challenges are outstanding. Uninstalling now would prevent cert-manager from cleaning up DNS01 records and HTTP01 solver resources it has created for them."
+	acmeSolverPodTemplate        = "%s/%s pod is an ACME HTTP01 solver"
+	acmeSolverIngressTemplate    = "%s/%s ingress routes traffic to an ACME HTTP01 solver"
+	acmeSolversHeader            = "ACME HTTP01 solver resources were found. These are normally cleaned up by cert-manager when challenges complete and might need to be removed manually after uninstalling."
+
+	// csiDriverName is the name of the cert-manager csi-driver CSI driver
+	csiDriverName = "csi.cert-manager.io"
+	// csiDriverSPIFFEName is the name of the cert-manager csi-driver-spiffe
+	// CSI driver
+	csiDriverSPIFFEName = "spiffe.csi.cert-manager.io"
+
+	// exitCodeBlockingIssues is used when at least one check with blocking
+	// severity produced results
+	exitCodeBlockingIssues = 2
+	// exitCodeAdvisoryIssues is used when only checks with advisory severity
+	// produced results
+	exitCodeAdvisoryIssues = 3
+)
+
+// severity describes how important it is to act on the results of a check
+// before uninstalling
+type severity string
+
+const (
+	// severityBlocking results must be addressed before uninstalling, e.g.
+	// because data will be lost or workloads will be affected
+	severityBlocking severity = "blocking"
+	// severityAdvisory results are informational and might be worth
+	// addressing before uninstalling
+	severityAdvisory severity = "advisory"
+)
+
+// check describes a single verification that is run against the cluster
+type check struct {
+	// id is a stable identifier for the check that can be used in automation
+	id string
+	// severity is the severity of any results produced by the check
+	severity severity
+	// header holds generic info about the issue and the suggested fix
+	header string
+}
+
+var (
+	ownerRefCheck = check{
+		id:       "certificate-owner-refs",
+		severity: severityBlocking,
+		header:   hasOwnerRefHeader,
+	}
+	unreadyCheck = check{
+		id:       "unready-certificates",
+		severity: severityAdvisory,
+		header:   unreadyHeader,
+	}
+	upcomingRenewalCheck = check{
+		id:       "upcoming-renewals",
+		severity: severityAdvisory,
+		header:   upcomingRenewalInfoHeader,
+	}
+	upcomingExpiryCheck = check{
+		id:       "upcoming-expiries",
+		severity: severityBlocking,
+		header:   upcomingExpiriesHeader,
+	}
+	currentIssuanceCheck = check{
+		id:       "current-issuances",
+		severity: severityAdvisory,
+		header:   currentIssuancesHeader,
+	}
+	failedIssuanceCheck = check{
+		id:       "failed-issuances",
+		severity: severityAdvisory,
+		header:   failedInfoHeader,
+	}
+	integrationCheck = check{
+		id:       "integrations",
+		severity: severityAdvisory,
+		header:   integrationHeader,
+	}
+	csiWorkloadCheck = check{
+		id:       "csi-driver-workloads",
+		severity: severityBlocking,
+		header:   csiWorkloadHeader,
+	}
+	pendingRequestCheck = check{
+		id:       "pending-certificate-requests",
+		severity: severityAdvisory,
+		header:   pendingRequestsHeader,
+	}
+	pendingOrderCheck = check{
+		id:       "pending-acme-orders",
+		severity: severityAdvisory,
+		header:   pendingOrdersHeader,
+	}
+	pendingChallengeCheck = check{
+		id:       "pending-acme-challenges",
+		severity: severityBlocking,
+		header:   pendingChallengesHeader,
+	}
+	acmeSolverCheck = check{
+		id:       "acme-http01-solvers",
+		severity: severityAdvisory,
+		header:   acmeSolversHeader,
+	}
+
+	// allChecks lists every check that can be run by verify
+	allChecks = []check{
+		ownerRefCheck,
+		unreadyCheck,
+		upcomingRenewalCheck,
+		upcomingExpiryCheck,
+		currentIssuanceCheck,
+		failedIssuanceCheck,
+		integrationCheck,
+		csiWorkloadCheck,
+		pendingRequestCheck,
+		pendingOrderCheck,
+		pendingChallengeCheck,
+		acmeSolverCheck,
+	}
 )
 
+// verifyOptions configure which checks are run by verify and how. They can be
+// loaded from a policy file and overridden by flags.
+type verifyOptions struct {
+	// RenewalWarnBuffer is how far in the future a certificate renewal needs
+	// to be scheduled to not be reported as upcoming
+	RenewalWarnBuffer time.Duration `yaml:"renewalWarnBuffer"`
+	// ExpiryWarnBuffer is how far in the future a certificate expiry needs to
+	// be to not be reported as upcoming
+	ExpiryWarnBuffer time.Duration `yaml:"expiryWarnBuffer"`
+	// EnabledChecks, if set, limits the checks run to those with the given IDs
+	EnabledChecks []string `yaml:"enabledChecks"`
+	// DisabledChecks lists the IDs of checks that should not be run
+	DisabledChecks []string `yaml:"disabledChecks"`
+	// Namespaces, if set, limits namespaced resources checked to those in the
+	// given namespaces
+	Namespaces []string `yaml:"namespaces"`
+}
+
+// defaultVerifyOptions returns the options used when no policy file or flags
+// are provided
+func defaultVerifyOptions() verifyOptions {
+	return verifyOptions{
+		RenewalWarnBuffer: time.Hour,
+		ExpiryWarnBuffer:  12 * time.Hour,
+	}
+}
+
+// loadVerifyPolicyFile reads verifyOptions from a YAML policy file, any fields
+// not set in the file keep the values in defaults
+func loadVerifyPolicyFile(path string, defaults verifyOptions) (verifyOptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return verifyOptions{}, fmt.Errorf("error reading policy file: %w", err)
+	}
+
+	opts := defaults
+	if err := yaml.UnmarshalStrict(data, &opts); err != nil {
+		return verifyOptions{}, fmt.Errorf("error parsing policy file: %w", err)
+	}
+
+	return opts, nil
+}
+
+// validate checks that all check IDs and buffers are valid
+func (o verifyOptions) validate() error {
+	knownIDs := make([]string, len(allChecks))
+	for i, c := range allChecks {
+		knownIDs[i] = c.id
+	}
+
+	for _, id := range append(append([]string{}, o.EnabledChecks...), o.DisabledChecks...) {
+		if !containsString(knownIDs, id) {
+			return fmt.Errorf("unknown check %q, valid checks are: %s", id, strings.Join(knownIDs, ", "))
+		}
+	}
+
+	if o.RenewalWarnBuffer < 0 || o.ExpiryWarnBuffer < 0 {
+		return errors.New("renewal and expiry buffers must not be negative")
+	}
+
+	return nil
+}
+
+// checkEnabled returns true if the check should be run
+func (o verifyOptions) checkEnabled(c check) bool {
+	if len(o.EnabledChecks) > 0 && !containsString(o.EnabledChecks, c.id) {
+		return false
+	}
+	return !containsString(o.DisabledChecks, c.id)
+}
+
+// namespaceInScope returns true if resources in the namespace should be checked
+func (o verifyOptions) namespaceInScope(namespace string) bool {
+	return len(o.Namespaces) == 0 || containsString(o.Namespaces, namespace)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// result returns a checkResult for a resource affected by the check
+func (c check) result(namespace, name, detail string) checkResult {
+	return checkResult{
+		CheckID:   c.id,
+		Severity:  c.severity,
+		Namespace: namespace,
+		Name:      name,
+		Detail:    detail,
+	}
+}
+
 func Uninstall(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "uninstall",
@@ -48,7 +279,18 @@ func Uninstall(run types.RunFunc, kubeConfigPath *string) *cobra.Command {
 }
 
 func verify(run types.RunFunc, kubeConfigPath string) *cobra.Command {
-	cmd := &cobra.Command{
+	var outputFormat string
+	var policyFilePath string
+	var renewalWarnBuffer time.Duration
+	var expiryWarnBuffer time.Duration
+	var enabledChecks []string
+	var disabledChecks []string
+	var namespaces []string
+
+	// cmd is declared before it is assigned so that the Run function can check
+	// which flags were set
+	var cmd *cobra.Command
+	cmd = &cobra.Command{
 		Use:   "verify",
 		Short: "Check that a cluster is ready to have Jetstack Software uninstalled",
 		Long: `Runs the following checks:
@@ -58,9 +300,61 @@ func verify(run types.RunFunc, kubeConfigPath string) *cobra.Command {
 * Checks for certificates that will expire soon
 * Checks for unready certificates
 * Checks for failing issuances
+* Checks for cert-manager integrations
+* Checks for workloads mounting cert-manager CSI driver volumes
+* Checks for unapproved or pending certificate requests
+* Checks for ACME orders and challenges in flight
+* Checks for leftover ACME HTTP01 solver pods and ingresses
+
+The command exits with code 2 if any blocking issues were found and with code 3
+if only advisory issues were found.
+
+Checks can be configured with flags or a YAML policy file passed with
+--policy-file, flags take precedence over values in the file. For example:
+
+  renewalWarnBuffer: 2h
+  expiryWarnBuffer: 24h
+  disabledChecks:
+  - upcoming-renewals
+  namespaces:
+  - team-a
 `,
 		Args: cobra.MatchAll(cobra.ExactArgs(0)),
 		Run: run(func(ctx context.Context, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return fmt.Errorf("unknown output format: %s", outputFormat)
+			}
+
+			opts := defaultVerifyOptions()
+			if policyFilePath != "" {
+				var err error
+				opts, err = loadVerifyPolicyFile(policyFilePath, opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			flags := cmd.Flags()
+			if flags.Changed("renewal-buffer") {
+				opts.RenewalWarnBuffer = renewalWarnBuffer
+			}
+			if flags.Changed("expiry-buffer") {
+				opts.ExpiryWarnBuffer = expiryWarnBuffer
+			}
+			if flags.Changed("checks") {
+				opts.EnabledChecks = enabledChecks
+			}
+			if flags.Changed("disable-checks") {
+				opts.DisabledChecks = disabledChecks
+			}
+			if flags.Changed("namespaces") {
+				opts.Namespaces = namespaces
+			}
+
+			if err := opts.validate(); err != nil {
+				return fmt.Errorf("error validating checks configuration: %w", err)
+			}
+
 			kubeCfg, err := kubernetes.NewConfig(kubeConfigPath)
 			if err != nil {
 				return err
@@ -72,46 +366,79 @@ func verify(run types.RunFunc, kubeConfigPath string) *cobra.Command {
 			}
 
 			realClock := clock.RealClock{}
-			notifications, err := findIssues(ctx, clientset, realClock)
+			notifications, err := findIssues(ctx, clientset, realClock, opts)
 			if err != nil {
 				return fmt.Errorf("error investigating cluster state: %w", err)
 			}
 
-			// print out any suggested next steps
-			if len(notifications) > 0 {
-				fmt.Fprintf(os.Stdout, "\nResults:\n")
+			switch outputFormat {
+			case "json":
+				results := []checkResult{}
 				for _, n := range notifications {
-					fmt.Fprintf(os.Stdout, "%s\n", n.header)
-					for _, ri := range n.resourceInfos {
-						fmt.Fprintf(os.Stdout, "	* %s\n", ri)
+					results = append(results, n.results...)
+				}
+
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent(" ", " ")
+				if err := encoder.Encode(results); err != nil {
+					return fmt.Errorf("error encoding results: %w", err)
+				}
+			default:
+				// print out any suggested next steps
+				if len(notifications) > 0 {
+					fmt.Fprintf(os.Stdout, "\nResults:\n")
+					for _, n := range notifications {
+						fmt.Fprintf(os.Stdout, "%s\n", n.check.header)
+						for _, r := range n.results {
+							fmt.Fprintf(os.Stdout, "	* %s\n", r.Detail)
+						}
 					}
+				} else {
+					fmt.Fprintf(os.Stdout, "\nNothing to do before uninstalling\n")
 				}
-			} else {
-				fmt.Fprintf(os.Stdout, "\nNothing to do before uninstalling\n")
 			}
-			return nil
+
+			return verifyResult(notifications)
 		}),
 	}
 
+	defaults := defaultVerifyOptions()
+	checkIDs := make([]string, len(allChecks))
+	for i, c := range allChecks {
+		checkIDs[i] = c.id
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&outputFormat, "output", "text", "output format, one of: text, json")
+	flags.StringVar(&policyFilePath, "policy-file", "", "path to a YAML file configuring the checks, values set by other flags take precedence")
+	flags.DurationVar(&renewalWarnBuffer, "renewal-buffer", defaults.RenewalWarnBuffer, "report certificates that will be renewed within this duration")
+	flags.DurationVar(&expiryWarnBuffer, "expiry-buffer", defaults.ExpiryWarnBuffer, "report certificates that will expire within this duration")
+	flags.StringSliceVar(&enabledChecks, "checks", []string{}, fmt.Sprintf("if set, only the checks with these IDs are run (checks: %s)", strings.Join(checkIDs, ", ")))
+	flags.StringSliceVar(&disabledChecks, "disable-checks", []string{}, "IDs of checks that should not be run")
+	flags.StringSliceVar(&namespaces, "namespaces", []string{}, "if set, only namespaced resources such as Secrets and Certificates in these namespaces are checked")
+
 	return cmd
 }
 
-func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([]notification, error) {
+func findIssues(ctx context.Context, clientset allClients, clock clock.Clock, opts verifyOptions) ([]notification, error) {
 	notifications := []notification{}
 	nowTime := clock.Now()
 
-	{
+	if opts.checkEnabled(ownerRefCheck) {
 		// Check all cluster Secrets for potential issues
 		var secretsList corev1.SecretList
 		if err := clientset.secrets.List(ctx, &clients.GenericRequestOptions{}, &secretsList); err != nil {
 			return nil, fmt.Errorf("error listing cluster secrets: %w", err)
 		}
 
-		fmt.Fprintf(os.Stdout, "Running checks against cluster Secrets:\n")
-		fmt.Fprintf(os.Stdout, "	* Checking that issued certificates are safe from garbage collection...\n")
-		ownerRefsResourceInfos := []string{}
+		fmt.Fprintf(os.Stderr, "Running checks against cluster Secrets:\n")
+		fmt.Fprintf(os.Stderr, "	* Checking that issued certificates are safe from garbage collection...\n")
+		ownerRefsResults := []checkResult{}
 		for i := range secretsList.Items {
 			secret := &secretsList.Items[i]
+			if !opts.namespaceInScope(secret.Namespace) {
+				continue
+			}
 
 			hasCertificateOwnerRef := false
 			for _, ownerRef := range secret.OwnerReferences {
@@ -122,152 +449,356 @@ func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([
 			}
 
 			if hasCertificateOwnerRef {
-				ownerRefsResourceInfos = append(ownerRefsResourceInfos, fmt.Sprintf(hasOwnerRefInfoTemplate, secret.Namespace, secret.Name))
+				ownerRefsResults = append(ownerRefsResults, ownerRefCheck.result(
+					secret.Namespace,
+					secret.Name,
+					fmt.Sprintf(hasOwnerRefInfoTemplate, secret.Namespace, secret.Name),
+				))
 			}
 		}
 
-		if len(ownerRefsResourceInfos) > 0 {
+		if len(ownerRefsResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        hasOwnerRefHeader,
-				resourceInfos: ownerRefsResourceInfos,
+				check:   ownerRefCheck,
+				results: ownerRefsResults,
 			})
 		}
 	}
 
-	{
+	certificateChecksEnabled := opts.checkEnabled(unreadyCheck) ||
+		opts.checkEnabled(upcomingRenewalCheck) ||
+		opts.checkEnabled(upcomingExpiryCheck) ||
+		opts.checkEnabled(currentIssuanceCheck) ||
+		opts.checkEnabled(failedIssuanceCheck)
+	if certificateChecksEnabled {
 		var certificates cmapi.CertificateList
 		if err := clientset.certificates.List(ctx, &clients.GenericRequestOptions{}, &certificates); err != nil {
 			return nil, fmt.Errorf("error listing certificates: %s", err)
 		}
 
 		// Check all cluster Certificates for potential issues
-		unreadyResourceInfos := []string{}
-		upcomingRenewalsResourceInfos := []string{}
-		upcomingExpiriesResourceInfos := []string{}
-		currentIssuancesResourceInfos := []string{}
-		failedResourceInfos := []string{}
-
-		renewalWarnBuffer, err := time.ParseDuration("1h")
-		if err != nil {
-			return nil, fmt.Errorf("error parsing duration, this is a bug: %s", err)
-		}
-		expiryWarnBuffer, err := time.ParseDuration("12h")
-		if err != nil {
-			return nil, fmt.Errorf("error parsing duration, this is a bug: %s", err)
-		}
-
-		fmt.Fprintf(os.Stdout, "Running checks against cluster Certificates:\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for upcoming renewals\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for upcoming expiries\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for currently failing issuances\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for unready Certificates\n")
+		unreadyResults := []checkResult{}
+		upcomingRenewalsResults := []checkResult{}
+		upcomingExpiriesResults := []checkResult{}
+		currentIssuancesResults := []checkResult{}
+		failedResults := []checkResult{}
+
+		fmt.Fprintf(os.Stderr, "Running checks against cluster Certificates:\n")
+		if opts.checkEnabled(upcomingRenewalCheck) {
+			fmt.Fprintf(os.Stderr, "	* Checking for upcoming renewals\n")
+		}
+		if opts.checkEnabled(upcomingExpiryCheck) {
+			fmt.Fprintf(os.Stderr, "	* Checking for upcoming expiries\n")
+		}
+		if opts.checkEnabled(currentIssuanceCheck) {
+			fmt.Fprintf(os.Stderr, "	* Checking for current issuances\n")
+		}
+		if opts.checkEnabled(failedIssuanceCheck) {
+			fmt.Fprintf(os.Stderr, "	* Checking for currently failing issuances\n")
+		}
+		if opts.checkEnabled(unreadyCheck) {
+			fmt.Fprintf(os.Stderr, "	* Checking for unready Certificates\n")
+		}
 		for _, cert := range certificates.Items {
-			if isUnready(cert) {
-				unreadyResourceInfos = append(unreadyResourceInfos, fmt.Sprintf(unreadyInfoTemplate, cert.Namespace, cert.Name))
+			if !opts.namespaceInScope(cert.Namespace) {
+				continue
+			}
+			if opts.checkEnabled(unreadyCheck) && isUnready(cert) {
+				unreadyResults = append(unreadyResults, unreadyCheck.result(
+					cert.Namespace,
+					cert.Name,
+					fmt.Sprintf(unreadyInfoTemplate, cert.Namespace, cert.Name),
+				))
 			}
-			if willBeRenewedSoon(cert, renewalWarnBuffer, nowTime) {
-				upcomingRenewalsResourceInfos = append(
-					upcomingRenewalsResourceInfos,
+			if opts.checkEnabled(upcomingRenewalCheck) && willBeRenewedSoon(cert, opts.RenewalWarnBuffer, nowTime) {
+				upcomingRenewalsResults = append(upcomingRenewalsResults, upcomingRenewalCheck.result(
+					cert.Namespace,
+					cert.Name,
 					fmt.Sprintf(
 						upcomingRenewalInfoTemplate,
 						cert.Namespace,
 						cert.Name,
 						cert.Status.RenewalTime.Time,
 					),
-				)
+				))
 			}
-			if willExpireSoon(cert, expiryWarnBuffer, nowTime) {
-				upcomingExpiriesResourceInfos = append(
-					upcomingExpiriesResourceInfos,
+			if opts.checkEnabled(upcomingExpiryCheck) && willExpireSoon(cert, opts.ExpiryWarnBuffer, nowTime) {
+				upcomingExpiriesResults = append(upcomingExpiriesResults, upcomingExpiryCheck.result(
+					cert.Namespace,
+					cert.Name,
 					fmt.Sprintf(
 						upcomingExpiriesInfoTemplate,
 						cert.Namespace,
 						cert.Name,
 						cert.Status.NotAfter.Time,
 					),
-				)
+				))
 			}
-			if isCurrentlyBeingIssued(cert) {
-				currentIssuancesResourceInfos = append(currentIssuancesResourceInfos, fmt.Sprintf(currentIssuancesInfoTemplate, cert.Namespace, cert.Name))
-
+			if opts.checkEnabled(currentIssuanceCheck) && isCurrentlyBeingIssued(cert) {
+				currentIssuancesResults = append(currentIssuancesResults, currentIssuanceCheck.result(
+					cert.Namespace,
+					cert.Name,
+					fmt.Sprintf(currentIssuancesInfoTemplate, cert.Namespace, cert.Name),
+				))
 			}
-			if isCurrentlyFailingIssuance(cert) {
+			if opts.checkEnabled(failedIssuanceCheck) && isCurrentlyFailingIssuance(cert) {
 				failedAttempts := cert.Status.FailedIssuanceAttempts
-				failedResourceInfos = append(failedResourceInfos, fmt.Sprintf(failedInfoTemplate, cert.Namespace, cert.Name, *failedAttempts))
+				failedResults = append(failedResults, failedIssuanceCheck.result(
+					cert.Namespace,
+					cert.Name,
+					fmt.Sprintf(failedInfoTemplate, cert.Namespace, cert.Name, *failedAttempts),
+				))
 			}
 		}
 
-		if len(unreadyResourceInfos) > 0 {
+		if len(unreadyResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        unreadyHeader,
-				resourceInfos: unreadyResourceInfos,
+				check:   unreadyCheck,
+				results: unreadyResults,
 			})
 		}
-		if len(upcomingRenewalsResourceInfos) > 0 {
+		if len(upcomingRenewalsResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        upcomingRenewalInfoHeader,
-				resourceInfos: upcomingRenewalsResourceInfos,
+				check:   upcomingRenewalCheck,
+				results: upcomingRenewalsResults,
 			})
 		}
-		if len(upcomingExpiriesResourceInfos) > 0 {
+		if len(upcomingExpiriesResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        upcomingExpiriesHeader,
-				resourceInfos: upcomingExpiriesResourceInfos,
+				check:   upcomingExpiryCheck,
+				results: upcomingExpiriesResults,
 			})
 		}
-		if len(currentIssuancesResourceInfos) > 0 {
+		if len(currentIssuancesResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        currentIssuancesHeader,
-				resourceInfos: currentIssuancesResourceInfos,
+				check:   currentIssuanceCheck,
+				results: currentIssuancesResults,
 			})
 		}
-		if len(failedResourceInfos) > 0 {
+		if len(failedResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        failedInfoHeader,
-				resourceInfos: failedResourceInfos,
+				check:   failedIssuanceCheck,
+				results: failedResults,
 			})
 		}
 	}
 
-	{
+	// Pods are used by several checks, so are only listed once
+	pods := &corev1.PodList{}
+	if opts.checkEnabled(integrationCheck) || opts.checkEnabled(csiWorkloadCheck) || opts.checkEnabled(acmeSolverCheck) {
+		if err := clientset.pods.List(ctx, &clients.GenericRequestOptions{}, pods); err != nil {
+			return nil, fmt.Errorf("failed to list pods: %s", err)
+		}
+	}
+
+	if opts.checkEnabled(integrationCheck) {
 		// Check whether cert-manager-csi-driver, cert-manager-csi-driver-spiffe and/or istio-csr are installed in cluster
 		// There aren't really any non-parameterizable values in csi-driver or
 		// istio-csr Helm charts so we use image names.
-		fmt.Fprintf(os.Stdout, "Running checks against cert-manager integrations installed in cluster:\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for cert-manager-istio-csr\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for cert-manager-csi-driver\n")
-		fmt.Fprintf(os.Stdout, "	* Checking for cert-manager-csi-driver-spiffe\n")
+		fmt.Fprintf(os.Stderr, "Running checks against cert-manager integrations installed in cluster:\n")
+		fmt.Fprintf(os.Stderr, "	* Checking for cert-manager-istio-csr\n")
+		fmt.Fprintf(os.Stderr, "	* Checking for cert-manager-csi-driver\n")
+		fmt.Fprintf(os.Stderr, "	* Checking for cert-manager-csi-driver-spiffe\n")
 
-		pods := &corev1.PodList{}
-		if err := clientset.pods.List(ctx, &clients.GenericRequestOptions{}, pods); err != nil {
-			return nil, fmt.Errorf("failed to list pods: %s", err)
-		}
 		md := &components.MatchData{Pods: pods.Items}
 
-		certManagerIntegrationsResourceInfos := []string{}
+		certManagerIntegrationsResults := []checkResult{}
 
 		if found, err := (&components.CertManagerCSIDriverSPIFFEStatus{}).Match(md); err != nil {
 			return nil, fmt.Errorf("failed to detemine if cert-manager-csi-driver-spiffe exists: %w", err)
 		} else if found {
-			certManagerIntegrationsResourceInfos = append(certManagerIntegrationsResourceInfos, fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver-spiffe"))
+			certManagerIntegrationsResults = append(certManagerIntegrationsResults, integrationCheck.result(
+				"",
+				"cert-manager-csi-driver-spiffe",
+				fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver-spiffe"),
+			))
 		}
 
 		if found, err := (&components.CertManagerCSIDriverStatus{}).Match(md); err != nil {
 			return nil, fmt.Errorf("failed to detemine if cert-manager-csi-driver exists: %w", err)
 		} else if found {
-			certManagerIntegrationsResourceInfos = append(certManagerIntegrationsResourceInfos, fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver"))
+			certManagerIntegrationsResults = append(certManagerIntegrationsResults, integrationCheck.result(
+				"",
+				"cert-manager-csi-driver",
+				fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver"),
+			))
 		}
 
 		if found, err := (&components.CertManagerIstioCSRStatus{}).Match(md); err != nil {
 			return nil, fmt.Errorf("failed to detemine if istio-csr exists: %w", err)
 		} else if found {
-			certManagerIntegrationsResourceInfos = append(certManagerIntegrationsResourceInfos, fmt.Sprintf(integrationInfoTemplate, "cert-manager-istio-csr"))
+			certManagerIntegrationsResults = append(certManagerIntegrationsResults, integrationCheck.result(
+				"",
+				"cert-manager-istio-csr",
+				fmt.Sprintf(integrationInfoTemplate, "cert-manager-istio-csr"),
+			))
 		}
 
-		if len(certManagerIntegrationsResourceInfos) > 0 {
+		if len(certManagerIntegrationsResults) > 0 {
 			notifications = append(notifications, notification{
-				header:        integrationHeader,
-				resourceInfos: certManagerIntegrationsResourceInfos,
+				check:   integrationCheck,
+				results: certManagerIntegrationsResults,
+			})
+		}
+	}
+
+	if opts.checkEnabled(csiWorkloadCheck) {
+		fmt.Fprintf(os.Stderr, "Running checks against workloads using cert-manager CSI drivers:\n")
+		fmt.Fprintf(os.Stderr, "	* Checking for pods mounting %s or %s volumes\n", csiDriverName, csiDriverSPIFFEName)
+
+		csiWorkloadResults := []checkResult{}
+		for _, w := range findCSIWorkloads(pods.Items, opts) {
+			csiWorkloadResults = append(csiWorkloadResults, csiWorkloadCheck.result(
+				w.namespace,
+				w.name,
+				fmt.Sprintf(csiWorkloadInfoTemplate, w.namespace, w.name, w.kind, w.pods, strings.Join(w.drivers, ", ")),
+			))
+		}
+
+		if len(csiWorkloadResults) > 0 {
+			notifications = append(notifications, notification{
+				check:   csiWorkloadCheck,
+				results: csiWorkloadResults,
+			})
+		}
+	}
+
+	if opts.checkEnabled(pendingRequestCheck) {
+		var certificateRequests cmapi.CertificateRequestList
+		if err := clientset.certificateRequests.List(ctx, &clients.GenericRequestOptions{}, &certificateRequests); err != nil {
+			return nil, fmt.Errorf("error listing certificate requests: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Running checks against cluster CertificateRequests:\n")
+		fmt.Fprintf(os.Stderr, "	* Checking for unapproved or pending certificate requests\n")
+		pendingRequestResults := []checkResult{}
+		for _, cr := range certificateRequests.Items {
+			if !opts.namespaceInScope(cr.Namespace) {
+				continue
+			}
+			switch {
+			case isUnapproved(cr):
+				pendingRequestResults = append(pendingRequestResults, pendingRequestCheck.result(
+					cr.Namespace,
+					cr.Name,
+					fmt.Sprintf(unapprovedRequestTemplate, cr.Namespace, cr.Name),
+				))
+			case isPendingIssuance(cr):
+				pendingRequestResults = append(pendingRequestResults, pendingRequestCheck.result(
+					cr.Namespace,
+					cr.Name,
+					fmt.Sprintf(pendingRequestTemplate, cr.Namespace, cr.Name),
+				))
+			}
+		}
+
+		if len(pendingRequestResults) > 0 {
+			notifications = append(notifications, notification{
+				check:   pendingRequestCheck,
+				results: pendingRequestResults,
+			})
+		}
+	}
+
+	if opts.checkEnabled(pendingOrderCheck) || opts.checkEnabled(pendingChallengeCheck) {
+		fmt.Fprintf(os.Stderr, "Running checks against cluster ACME resources:\n")
+	}
+
+	if opts.checkEnabled(pendingOrderCheck) {
+		var orders cmacme.OrderList
+		if err := clientset.orders.List(ctx, &clients.GenericRequestOptions{}, &orders); err != nil {
+			return nil, fmt.Errorf("error listing ACME orders: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "	* Checking for ACME orders in flight\n")
+		pendingOrderResults := []checkResult{}
+		for _, order := range orders.Items {
+			if !opts.namespaceInScope(order.Namespace) || isFinalACMEState(order.Status.State) {
+				continue
+			}
+			pendingOrderResults = append(pendingOrderResults, pendingOrderCheck.result(
+				order.Namespace,
+				order.Name,
+				fmt.Sprintf(pendingOrderTemplate, order.Namespace, order.Name, order.Status.State),
+			))
+		}
+
+		if len(pendingOrderResults) > 0 {
+			notifications = append(notifications, notification{
+				check:   pendingOrderCheck,
+				results: pendingOrderResults,
+			})
+		}
+	}
+
+	if opts.checkEnabled(pendingChallengeCheck) {
+		var challenges cmacme.ChallengeList
+		if err := clientset.challenges.List(ctx, &clients.GenericRequestOptions{}, &challenges); err != nil {
+			return nil, fmt.Errorf("error listing ACME challenges: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "	* Checking for outstanding ACME challenges\n")
+		pendingChallengeResults := []checkResult{}
+		for _, challenge := range challenges.Items {
+			if !opts.namespaceInScope(challenge.Namespace) || isFinalACMEState(challenge.Status.State) {
+				continue
+			}
+			pendingChallengeResults = append(pendingChallengeResults, pendingChallengeCheck.result(
+				challenge.Namespace,
+				challenge.Name,
+				fmt.Sprintf(
+					pendingChallengeTemplate,
+					challenge.Namespace,
+					challenge.Name,
+					challenge.Spec.Type,
+					challenge.Spec.DNSName,
+					challenge.Status.State,
+					challenge.Status.Presented,
+				),
+			))
+		}
+
+		if len(pendingChallengeResults) > 0 {
+			notifications = append(notifications, notification{
+				check:   pendingChallengeCheck,
+				results: pendingChallengeResults,
+			})
+		}
+	}
+
+	if opts.checkEnabled(acmeSolverCheck) {
+		fmt.Fprintf(os.Stderr, "Running checks against ACME HTTP01 solver resources:\n")
+		fmt.Fprintf(os.Stderr, "	* Checking for ACME HTTP01 solver pods and ingresses\n")
+
+		ingresses := &networkingv1.IngressList{}
+		if err := clientset.ingresses.List(ctx, &clients.GenericRequestOptions{}, ingresses); err != nil {
+			return nil, fmt.Errorf("failed to list ingresses: %s", err)
+		}
+
+		acmeSolverResults := []checkResult{}
+		for _, pod := range pods.Items {
+			if opts.namespaceInScope(pod.Namespace) && isACMESolver(pod.Labels) {
+				acmeSolverResults = append(acmeSolverResults, acmeSolverCheck.result(
+					pod.Namespace,
+					pod.Name,
+					fmt.Sprintf(acmeSolverPodTemplate, pod.Namespace, pod.Name),
+				))
+			}
+		}
+		for _, ingress := range ingresses.Items {
+			if opts.namespaceInScope(ingress.Namespace) && isACMESolver(ingress.Labels) {
+				acmeSolverResults = append(acmeSolverResults, acmeSolverCheck.result(
+					ingress.Namespace,
+					ingress.Name,
+					fmt.Sprintf(acmeSolverIngressTemplate, ingress.Namespace, ingress.Name),
+				))
+			}
+		}
+
+		if len(acmeSolverResults) > 0 {
+			notifications = append(notifications, notification{
+				check:   acmeSolverCheck,
+				results: acmeSolverResults,
 			})
 		}
 	}
@@ -278,10 +809,42 @@ func findIssues(ctx context.Context, clientset allClients, clock clock.Clock) ([
 // Notification holds information about a particular type of issue related to
 // uninstallation safety affecting a subset of resources in cluster
 type notification struct {
-	// header holds generic info about the issue and the suggested fix
-	header string
+	// check is the check that found the issue
+	check check
 	// listing of the affected resources with any additional related info
-	resourceInfos []string
+	results []checkResult
+}
+
+// checkResult is a machine-readable record of a single resource affected by
+// a check
+type checkResult struct {
+	CheckID   string   `json:"checkID"`
+	Severity  severity `json:"severity"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Detail    string   `json:"detail"`
+}
+
+// verifyResult returns an error with an exit code matching the most severe
+// issue found, or nil if there is nothing to do before uninstalling
+func verifyResult(notifications []notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	for _, n := range notifications {
+		if n.check.severity == severityBlocking {
+			return &internalerrors.ExitCodeError{
+				Code: exitCodeBlockingIssues,
+				Err:  errors.New("blocking issues found, resolve them before uninstalling"),
+			}
+		}
+	}
+
+	return &internalerrors.ExitCodeError{
+		Code: exitCodeAdvisoryIssues,
+		Err:  errors.New("advisory issues found, review them before uninstalling"),
+	}
 }
 
 func buildClients(kubeconfig *rest.Config) (allClients, error) {
@@ -314,17 +877,49 @@ func buildClients(kubeconfig *rest.Config) (allClients, error) {
 	if err != nil {
 		return allClients{}, fmt.Errorf("error creating new pods client: %w", err)
 	}
+	ingressClient, err := clients.NewGenericClient[*networkingv1.Ingress, *networkingv1.IngressList](
+		&clients.GenericClientOptions{
+			RestConfig: kubeconfig,
+			APIPath:    "/apis/",
+			Group:      networkingv1.GroupName,
+			Version:    networkingv1.SchemeGroupVersion.Version,
+			Kind:       "ingresses",
+		},
+	)
+	if err != nil {
+		return allClients{}, fmt.Errorf("error creating new ingresses client: %w", err)
+	}
+	certificateRequestsClient, err := clients.NewCertificateRequestClient(kubeconfig)
+	if err != nil {
+		return allClients{}, fmt.Errorf("error creating new certificate requests client: %w", err)
+	}
+	ordersClient, err := clients.NewOrderClient(kubeconfig)
+	if err != nil {
+		return allClients{}, fmt.Errorf("error creating new ACME orders client: %w", err)
+	}
+	challengesClient, err := clients.NewChallengeClient(kubeconfig)
+	if err != nil {
+		return allClients{}, fmt.Errorf("error creating new ACME challenges client: %w", err)
+	}
 	return allClients{
-		certificates: certsClient,
-		secrets:      secretsClient,
-		pods:         podClient,
+		certificates:        certsClient,
+		certificateRequests: certificateRequestsClient,
+		orders:              ordersClient,
+		challenges:          challengesClient,
+		secrets:             secretsClient,
+		pods:                podClient,
+		ingresses:           ingressClient,
 	}, nil
 }
 
 type allClients struct {
-	secrets      clients.Generic[*corev1.Secret, *corev1.SecretList]
-	certificates clients.Generic[*cmapi.Certificate, *cmapi.CertificateList]
-	pods         clients.Generic[*corev1.Pod, *corev1.PodList]
+	secrets             clients.Generic[*corev1.Secret, *corev1.SecretList]
+	certificates        clients.Generic[*cmapi.Certificate, *cmapi.CertificateList]
+	certificateRequests clients.Generic[*cmapi.CertificateRequest, *cmapi.CertificateRequestList]
+	orders              clients.Generic[*cmacme.Order, *cmacme.OrderList]
+	challenges          clients.Generic[*cmacme.Challenge, *cmacme.ChallengeList]
+	pods                clients.Generic[*corev1.Pod, *corev1.PodList]
+	ingresses           clients.Generic[*networkingv1.Ingress, *networkingv1.IngressList]
 }
 
 func isUnready(cert cmapi.Certificate) bool {
@@ -361,3 +956,172 @@ func isCurrentlyFailingIssuance(cert cmapi.Certificate) bool {
 	failedAttempts := cert.Status.FailedIssuanceAttempts
 	return failedAttempts != nil && *failedAttempts > 0
 }
+
+// csiWorkload describes a workload with pods that mount volumes from
+// cert-manager CSI drivers
+type csiWorkload struct {
+	kind      string
+	namespace string
+	name      string
+	// pods is the number of pods of the workload mounting CSI volumes
+	pods int
+	// drivers are the names of the CSI drivers used by the pods
+	drivers []string
+}
+
+// findCSIWorkloads returns the workloads owning pods that mount volumes from
+// cert-manager CSI drivers, sorted by namespace, kind and name
+func findCSIWorkloads(pods []corev1.Pod, opts verifyOptions) []*csiWorkload {
+	workloads := map[string]*csiWorkload{}
+	for _, pod := range pods {
+		if !opts.namespaceInScope(pod.Namespace) {
+			continue
+		}
+
+		var drivers []string
+		for _, volume := range pod.Spec.Volumes {
+			if volume.CSI == nil {
+				continue
+			}
+			driver := volume.CSI.Driver
+			if (driver == csiDriverName || driver == csiDriverSPIFFEName) && !containsString(drivers, driver) {
+				drivers = append(drivers, driver)
+			}
+		}
+		if len(drivers) == 0 {
+			continue
+		}
+
+		kind, name := podWorkload(pod)
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, kind, name)
+		w, ok := workloads[key]
+		if !ok {
+			w = &csiWorkload{kind: kind, namespace: pod.Namespace, name: name}
+			workloads[key] = w
+		}
+		w.pods++
+		for _, driver := range drivers {
+			if !containsString(w.drivers, driver) {
+				w.drivers = append(w.drivers, driver)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(workloads))
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*csiWorkload, len(keys))
+	for i, key := range keys {
+		sort.Strings(workloads[key].drivers)
+		result[i] = workloads[key]
+	}
+	return result
+}
+
+// podWorkload returns the kind and name of the workload that manages the pod.
+// Pods owned by a ReplicaSet created by a Deployment are attributed to the
+// Deployment, and pods owned by a Job created by a CronJob are attributed to
+// the CronJob. Pods without a controller are their own workload.
+func podWorkload(pod corev1.Pod) (string, string) {
+	for _, ownerRef := range pod.OwnerReferences {
+		if ownerRef.Controller == nil || !*ownerRef.Controller {
+			continue
+		}
+
+		// ReplicaSets created by a Deployment are named after the Deployment
+		// with the pod template hash as a suffix
+		if hash, ok := pod.Labels["pod-template-hash"]; ok && ownerRef.Kind == "ReplicaSet" && strings.HasSuffix(ownerRef.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(ownerRef.Name, "-"+hash)
+		}
+
+		if ownerRef.Kind == "Job" {
+			if cronJob, ok := cronJobName(ownerRef.Name); ok {
+				return "CronJob", cronJob
+			}
+		}
+
+		return ownerRef.Kind, ownerRef.Name
+	}
+
+	return "Pod", pod.Name
+}
+
+// cronJobName returns the name of the CronJob that created a Job. Jobs created
+// by a CronJob are named after the CronJob with the scheduled time, in minutes
+// since the Unix epoch, as a suffix. Shorter numeric suffixes are not treated
+// as scheduled times so that Jobs such as migrate-2 keep their own name.
+func cronJobName(job string) (string, bool) {
+	i := strings.LastIndex(job, "-")
+	if i <= 0 {
+		return "", false
+	}
+
+	suffix := job[i+1:]
+	if len(suffix) < 8 {
+		return "", false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return job[:i], true
+}
+
+// isUnapproved returns true if the request has neither been approved nor
+// denied and has not completed
+func isUnapproved(cr cmapi.CertificateRequest) bool {
+	for _, cond := range cr.Status.Conditions {
+		switch cond.Type {
+		case cmapi.CertificateRequestConditionApproved, cmapi.CertificateRequestConditionDenied, cmapi.CertificateRequestConditionInvalidRequest:
+			if cond.Status == cmmeta.ConditionTrue {
+				return false
+			}
+		case cmapi.CertificateRequestConditionReady:
+			if cond.Status == cmmeta.ConditionTrue {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isPendingIssuance returns true if the request has been approved but has
+// neither been issued nor failed
+func isPendingIssuance(cr cmapi.CertificateRequest) bool {
+	approved := false
+	for _, cond := range cr.Status.Conditions {
+		switch cond.Type {
+		case cmapi.CertificateRequestConditionApproved:
+			approved = cond.Status == cmmeta.ConditionTrue
+		case cmapi.CertificateRequestConditionInvalidRequest:
+			if cond.Status == cmmeta.ConditionTrue {
+				return false
+			}
+		case cmapi.CertificateRequestConditionReady:
+			if cond.Status == cmmeta.ConditionTrue || cond.Reason == cmapi.CertificateRequestReasonFailed || cond.Reason == cmapi.CertificateRequestReasonDenied {
+				return false
+			}
+		}
+	}
+	return approved
+}
+
+// isFinalACMEState returns true if an ACME Order or Challenge in the given
+// state will not be processed any further
+func isFinalACMEState(state cmacme.State) bool {
+	switch state {
+	case cmacme.Valid, cmacme.Invalid, cmacme.Expired, cmacme.Errored:
+		return true
+	}
+	return false
+}
+
+// isACMESolver returns true if the labels are those set by cert-manager on
+// HTTP01 solver pods and ingresses
+func isACMESolver(labels map[string]string) bool {
+	return labels[cmacme.SolverIdentificationLabelKey] == "true"
+}
@@ -2,18 +2,24 @@ package clusters
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	fakeclock "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
 
+	internalerrors "github.com/jetstack/jsctl/internal/command/errors"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 )
 
@@ -67,6 +73,12 @@ func Test_findIssues(t *testing.T) {
 		podList         *corev1.PodList
 		secretList      *corev1.SecretList
 		certificateList *cmapi.CertificateList
+		// the following lists are optional and are empty if not set
+		certificateRequestList *cmapi.CertificateRequestList
+		orderList              *cmacme.OrderList
+		challengeList          *cmacme.ChallengeList
+		ingressList            *networkingv1.IngressList
+		opts                   *verifyOptions
 	}{
 		"cluster that has no cert-manager related resources does not produce any notifications": {
 			podList:         &corev1.PodList{Items: []corev1.Pod{fooPod}},
@@ -101,8 +113,8 @@ func Test_findIssues(t *testing.T) {
 				},
 			}}},
 			want: []notification{{
-				header:        unreadyHeader,
-				resourceInfos: []string{fmt.Sprintf(unreadyInfoTemplate, "foo", "foo")},
+				check:   unreadyCheck,
+				results: []checkResult{unreadyCheck.result("foo", "foo", fmt.Sprintf(unreadyInfoTemplate, "foo", "foo"))},
 			}},
 		},
 		"cluster that has a cert without a ready condition should produce a notification": {
@@ -120,8 +132,95 @@ func Test_findIssues(t *testing.T) {
 				Status: cmapi.CertificateStatus{},
 			}}},
 			want: []notification{{
-				header:        unreadyHeader,
-				resourceInfos: []string{fmt.Sprintf(unreadyInfoTemplate, "foo", "foo")},
+				check:   unreadyCheck,
+				results: []checkResult{unreadyCheck.result("foo", "foo", fmt.Sprintf(unreadyInfoTemplate, "foo", "foo"))},
+			}},
+		},
+		"cluster that has an unready cert should not produce a notification if the check is disabled": {
+			podList:    &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList: &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: []cmapi.Certificate{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       cmapi.CertificateKind,
+					APIVersion: "v1",
+				},
+				Status: cmapi.CertificateStatus{},
+			}}},
+			opts: &verifyOptions{
+				DisabledChecks: []string{unreadyCheck.id},
+			},
+			want: []notification{},
+		},
+		"cluster that has an unready cert should not produce a notification if another check is selected": {
+			podList:    &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList: &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: []cmapi.Certificate{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       cmapi.CertificateKind,
+					APIVersion: "v1",
+				},
+				Status: cmapi.CertificateStatus{},
+			}}},
+			opts: &verifyOptions{
+				EnabledChecks: []string{ownerRefCheck.id},
+			},
+			want: []notification{},
+		},
+		"cluster that has an unready cert should not produce a notification if its namespace is out of scope": {
+			podList:    &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList: &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: []cmapi.Certificate{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       cmapi.CertificateKind,
+					APIVersion: "v1",
+				},
+				Status: cmapi.CertificateStatus{},
+			}}},
+			opts: &verifyOptions{
+				Namespaces: []string{"bar"},
+			},
+			want: []notification{},
+		},
+		"cluster that has a cert that will be renewed within a custom buffer should produce a notification": {
+			podList:    &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList: &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: []cmapi.Certificate{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       cmapi.CertificateKind,
+					APIVersion: "v1",
+				},
+				Status: cmapi.CertificateStatus{
+					RenewalTime: &metav1.Time{Time: fakeClock.Now().Add(2 * time.Hour)},
+					Conditions: []cmapi.CertificateCondition{
+						{
+							Type:   cmapi.CertificateConditionReady,
+							Status: cmmeta.ConditionTrue,
+						},
+					},
+				},
+			}}},
+			opts: &verifyOptions{
+				RenewalWarnBuffer: 3 * time.Hour,
+			},
+			want: []notification{{
+				check:   upcomingRenewalCheck,
+				results: []checkResult{upcomingRenewalCheck.result("foo", "foo", fmt.Sprintf(upcomingRenewalInfoTemplate, "foo", "foo", fakeClock.Now().Add(2*time.Hour)))},
 			}},
 		},
 		"cluster that has a cert that is currently being issued should produce a notification": {
@@ -149,8 +248,8 @@ func Test_findIssues(t *testing.T) {
 				},
 			}}},
 			want: []notification{{
-				header:        currentIssuancesHeader,
-				resourceInfos: []string{fmt.Sprintf(currentIssuancesInfoTemplate, "foo", "foo")},
+				check:   currentIssuanceCheck,
+				results: []checkResult{currentIssuanceCheck.result("foo", "foo", fmt.Sprintf(currentIssuancesInfoTemplate, "foo", "foo"))},
 			}},
 		},
 		"cluster that has a cert that failed issuance for latest renewal cycle should produce a notification": {
@@ -179,8 +278,8 @@ func Test_findIssues(t *testing.T) {
 				},
 			}}},
 			want: []notification{{
-				header:        failedInfoHeader,
-				resourceInfos: []string{fmt.Sprintf(failedInfoTemplate, "foo", "foo", 2)},
+				check:   failedIssuanceCheck,
+				results: []checkResult{failedIssuanceCheck.result("foo", "foo", fmt.Sprintf(failedInfoTemplate, "foo", "foo", 2))},
 			}},
 		},
 		"cluster that has a cert that is about to be renewed should produce a notification": {
@@ -206,8 +305,8 @@ func Test_findIssues(t *testing.T) {
 				},
 			}}},
 			want: []notification{{
-				header:        upcomingRenewalInfoHeader,
-				resourceInfos: []string{fmt.Sprintf(upcomingRenewalInfoTemplate, "foo", "foo", fakeClock.Now().Add(time.Minute))},
+				check:   upcomingRenewalCheck,
+				results: []checkResult{upcomingRenewalCheck.result("foo", "foo", fmt.Sprintf(upcomingRenewalInfoTemplate, "foo", "foo", fakeClock.Now().Add(time.Minute)))},
 			}},
 		},
 		"cluster that has a cert that is about to expire should produce a notification": {
@@ -233,8 +332,8 @@ func Test_findIssues(t *testing.T) {
 				},
 			}}},
 			want: []notification{{
-				header:        upcomingExpiriesHeader,
-				resourceInfos: []string{fmt.Sprintf(upcomingExpiriesInfoTemplate, "foo", "foo", fakeClock.Now().Add(time.Minute))},
+				check:   upcomingExpiryCheck,
+				results: []checkResult{upcomingExpiryCheck.result("foo", "foo", fmt.Sprintf(upcomingExpiriesInfoTemplate, "foo", "foo", fakeClock.Now().Add(time.Minute)))},
 			}},
 		},
 		"cluster that has an issued cert that would get garbage collected if cert-manager is uninstalled should produce a notification": {
@@ -254,8 +353,8 @@ func Test_findIssues(t *testing.T) {
 			}}},
 			certificateList: &cmapi.CertificateList{Items: nil},
 			want: []notification{{
-				header:        hasOwnerRefHeader,
-				resourceInfos: []string{fmt.Sprintf(hasOwnerRefInfoTemplate, "foo", "foo")},
+				check:   ownerRefCheck,
+				results: []checkResult{ownerRefCheck.result("foo", "foo", fmt.Sprintf(hasOwnerRefInfoTemplate, "foo", "foo"))},
 			}},
 		},
 		"cluster that appears to have cert-manager-csi-driver installed should produce a warning": {
@@ -275,8 +374,8 @@ func Test_findIssues(t *testing.T) {
 			secretList:      &corev1.SecretList{Items: nil},
 			certificateList: &cmapi.CertificateList{Items: nil},
 			want: []notification{{
-				header:        integrationHeader,
-				resourceInfos: []string{fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver")},
+				check:   integrationCheck,
+				results: []checkResult{integrationCheck.result("", "cert-manager-csi-driver", fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver"))},
 			}},
 		},
 		"cluster that appears to have cert-manager-csi-driver-spiffe installed should produce a warning": {
@@ -296,8 +395,8 @@ func Test_findIssues(t *testing.T) {
 			secretList:      &corev1.SecretList{Items: nil},
 			certificateList: &cmapi.CertificateList{Items: nil},
 			want: []notification{{
-				header:        integrationHeader,
-				resourceInfos: []string{fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver-spiffe")},
+				check:   integrationCheck,
+				results: []checkResult{integrationCheck.result("", "cert-manager-csi-driver-spiffe", fmt.Sprintf(integrationInfoTemplate, "cert-manager-csi-driver-spiffe"))},
 			}},
 		},
 		"cluster that appears to have cert-manager-istio-csr installed should produce a warning": {
@@ -317,8 +416,197 @@ func Test_findIssues(t *testing.T) {
 			secretList:      &corev1.SecretList{Items: nil},
 			certificateList: &cmapi.CertificateList{Items: nil},
 			want: []notification{{
-				header:        integrationHeader,
-				resourceInfos: []string{fmt.Sprintf(integrationInfoTemplate, "cert-manager-istio-csr")},
+				check:   integrationCheck,
+				results: []checkResult{integrationCheck.result("", "cert-manager-istio-csr", fmt.Sprintf(integrationInfoTemplate, "cert-manager-istio-csr"))},
+			}},
+		},
+		"cluster that has an unapproved certificate request should produce a notification": {
+			podList:         &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList:      &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: nil},
+			certificateRequestList: &cmapi.CertificateRequestList{Items: []cmapi.CertificateRequest{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+			}}},
+			want: []notification{{
+				check:   pendingRequestCheck,
+				results: []checkResult{pendingRequestCheck.result("foo", "foo", fmt.Sprintf(unapprovedRequestTemplate, "foo", "foo"))},
+			}},
+		},
+		"cluster that has an approved certificate request waiting for issuance should produce a notification": {
+			podList:         &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList:      &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: nil},
+			certificateRequestList: &cmapi.CertificateRequestList{Items: []cmapi.CertificateRequest{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+				Status: cmapi.CertificateRequestStatus{
+					Conditions: []cmapi.CertificateRequestCondition{
+						{
+							Type:   cmapi.CertificateRequestConditionApproved,
+							Status: cmmeta.ConditionTrue,
+						},
+						{
+							Type:   cmapi.CertificateRequestConditionReady,
+							Status: cmmeta.ConditionFalse,
+							Reason: cmapi.CertificateRequestReasonPending,
+						},
+					},
+				},
+			}}},
+			want: []notification{{
+				check:   pendingRequestCheck,
+				results: []checkResult{pendingRequestCheck.result("foo", "foo", fmt.Sprintf(pendingRequestTemplate, "foo", "foo"))},
+			}},
+		},
+		"cluster that only has issued or failed certificate requests should not produce any notifications": {
+			podList:         &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList:      &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: nil},
+			certificateRequestList: &cmapi.CertificateRequestList{Items: []cmapi.CertificateRequest{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "issued",
+						Namespace: "foo",
+					},
+					Status: cmapi.CertificateRequestStatus{
+						Conditions: []cmapi.CertificateRequestCondition{
+							{
+								Type:   cmapi.CertificateRequestConditionApproved,
+								Status: cmmeta.ConditionTrue,
+							},
+							{
+								Type:   cmapi.CertificateRequestConditionReady,
+								Status: cmmeta.ConditionTrue,
+								Reason: cmapi.CertificateRequestReasonIssued,
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "failed",
+						Namespace: "foo",
+					},
+					Status: cmapi.CertificateRequestStatus{
+						Conditions: []cmapi.CertificateRequestCondition{
+							{
+								Type:   cmapi.CertificateRequestConditionApproved,
+								Status: cmmeta.ConditionTrue,
+							},
+							{
+								Type:   cmapi.CertificateRequestConditionReady,
+								Status: cmmeta.ConditionFalse,
+								Reason: cmapi.CertificateRequestReasonFailed,
+							},
+						},
+					},
+				},
+			}},
+			want: []notification{},
+		},
+		"cluster that has ACME orders and challenges in flight should produce notifications": {
+			podList:         &corev1.PodList{Items: []corev1.Pod{fooPod}},
+			secretList:      &corev1.SecretList{Items: []corev1.Secret{fooSecret}},
+			certificateList: &cmapi.CertificateList{Items: nil},
+			orderList: &cmacme.OrderList{Items: []cmacme.Order{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "pending",
+						Namespace: "foo",
+					},
+					Status: cmacme.OrderStatus{
+						State: cmacme.Pending,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "valid",
+						Namespace: "foo",
+					},
+					Status: cmacme.OrderStatus{
+						State: cmacme.Valid,
+					},
+				},
+			}},
+			challengeList: &cmacme.ChallengeList{Items: []cmacme.Challenge{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "foo",
+				},
+				Spec: cmacme.ChallengeSpec{
+					Type:    cmacme.ACMEChallengeTypeDNS01,
+					DNSName: "example.com",
+				},
+				Status: cmacme.ChallengeStatus{
+					State:     cmacme.Pending,
+					Presented: true,
+				},
+			}}},
+			want: []notification{
+				{
+					check:   pendingOrderCheck,
+					results: []checkResult{pendingOrderCheck.result("foo", "pending", fmt.Sprintf(pendingOrderTemplate, "foo", "pending", cmacme.Pending))},
+				},
+				{
+					check: pendingChallengeCheck,
+					results: []checkResult{pendingChallengeCheck.result(
+						"foo",
+						"foo",
+						fmt.Sprintf(pendingChallengeTemplate, "foo", "foo", cmacme.ACMEChallengeTypeDNS01, "example.com", cmacme.Pending, true),
+					)},
+				},
+			},
+		},
+		"cluster that has ACME HTTP01 solver pods and ingresses should produce a notification": {
+			podList: &corev1.PodList{Items: []corev1.Pod{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cm-acme-http-solver-abcde",
+					Namespace: "foo",
+					Labels:    map[string]string{cmacme.SolverIdentificationLabelKey: "true"},
+				},
+			}}},
+			ingressList: &networkingv1.IngressList{Items: []networkingv1.Ingress{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cm-acme-http-solver-fghij",
+					Namespace: "foo",
+					Labels:    map[string]string{cmacme.SolverIdentificationLabelKey: "true"},
+				},
+			}}},
+			secretList:      &corev1.SecretList{Items: nil},
+			certificateList: &cmapi.CertificateList{Items: nil},
+			want: []notification{{
+				check: acmeSolverCheck,
+				results: []checkResult{
+					acmeSolverCheck.result("foo", "cm-acme-http-solver-abcde", fmt.Sprintf(acmeSolverPodTemplate, "foo", "cm-acme-http-solver-abcde")),
+					acmeSolverCheck.result("foo", "cm-acme-http-solver-fghij", fmt.Sprintf(acmeSolverIngressTemplate, "foo", "cm-acme-http-solver-fghij")),
+				},
+			}},
+		},
+		"cluster that has workloads mounting cert-manager CSI driver volumes should produce a notification": {
+			podList: &corev1.PodList{Items: []corev1.Pod{
+				csiPod("app-7d9f8b6c5d-abcde", "app-7d9f8b6c5d", "7d9f8b6c5d", csiDriverName),
+				csiPod("app-7d9f8b6c5d-fghij", "app-7d9f8b6c5d", "7d9f8b6c5d", csiDriverName),
+				csiPod("standalone", "", "", csiDriverSPIFFEName),
+				csiJobPod("backup-28391520-abcde", "backup-28391520", csiDriverName),
+				csiJobPod("backup-28391580-fghij", "backup-28391580", csiDriverName),
+				csiJobPod("migrate-2-klmno", "migrate-2", csiDriverName),
+				fooPod,
+			}},
+			secretList:      &corev1.SecretList{Items: nil},
+			certificateList: &cmapi.CertificateList{Items: nil},
+			want: []notification{{
+				check: csiWorkloadCheck,
+				results: []checkResult{
+					csiWorkloadCheck.result("foo", "backup", fmt.Sprintf(csiWorkloadInfoTemplate, "foo", "backup", "CronJob", 2, csiDriverName)),
+					csiWorkloadCheck.result("foo", "app", fmt.Sprintf(csiWorkloadInfoTemplate, "foo", "app", "Deployment", 2, csiDriverName)),
+					csiWorkloadCheck.result("foo", "migrate-2", fmt.Sprintf(csiWorkloadInfoTemplate, "foo", "migrate-2", "Job", 1, csiDriverName)),
+					csiWorkloadCheck.result("foo", "standalone", fmt.Sprintf(csiWorkloadInfoTemplate, "foo", "standalone", "Pod", 1, csiDriverSPIFFEName)),
+				},
 			}},
 		},
 	}
@@ -343,8 +631,44 @@ func Test_findIssues(t *testing.T) {
 						return nil
 					},
 				},
+				certificateRequests: &clients.FakeGeneric[*cmapi.CertificateRequest, *cmapi.CertificateRequestList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *cmapi.CertificateRequestList) error {
+						if scenario.certificateRequestList != nil {
+							result.Items = scenario.certificateRequestList.Items
+						}
+						return nil
+					},
+				},
+				orders: &clients.FakeGeneric[*cmacme.Order, *cmacme.OrderList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *cmacme.OrderList) error {
+						if scenario.orderList != nil {
+							result.Items = scenario.orderList.Items
+						}
+						return nil
+					},
+				},
+				challenges: &clients.FakeGeneric[*cmacme.Challenge, *cmacme.ChallengeList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *cmacme.ChallengeList) error {
+						if scenario.challengeList != nil {
+							result.Items = scenario.challengeList.Items
+						}
+						return nil
+					},
+				},
+				ingresses: &clients.FakeGeneric[*networkingv1.Ingress, *networkingv1.IngressList]{
+					FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, result *networkingv1.IngressList) error {
+						if scenario.ingressList != nil {
+							result.Items = scenario.ingressList.Items
+						}
+						return nil
+					},
+				},
+			}
+			opts := defaultVerifyOptions()
+			if scenario.opts != nil {
+				opts = *scenario.opts
 			}
-			got, err := findIssues(context.Background(), clientset, &fakeClock)
+			got, err := findIssues(context.Background(), clientset, &fakeClock, opts)
 			if (err != nil) != scenario.wantErr {
 				t.Errorf("findIssues() error = %v, wantErr %v", err, scenario.wantErr)
 				return
@@ -355,3 +679,157 @@ func Test_findIssues(t *testing.T) {
 		})
 	}
 }
+
+// csiPod returns a pod mounting a volume from the given CSI driver, owned by
+// the given ReplicaSet if set
+func csiPod(name, replicaSet, podTemplateHash, driver string) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "foo",
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "tls",
+				VolumeSource: corev1.VolumeSource{
+					CSI: &corev1.CSIVolumeSource{Driver: driver},
+				},
+			}},
+		},
+	}
+	if replicaSet != "" {
+		pod.Labels = map[string]string{"pod-template-hash": podTemplateHash}
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			Kind:       "ReplicaSet",
+			Name:       replicaSet,
+			Controller: pointer.Bool(true),
+		}}
+	}
+	return pod
+}
+
+// csiJobPod returns a pod mounting a volume from the given CSI driver, owned by
+// the given Job
+func csiJobPod(name, job, driver string) corev1.Pod {
+	pod := csiPod(name, "", "", driver)
+	pod.Labels = map[string]string{"job-name": job}
+	pod.OwnerReferences = []metav1.OwnerReference{{
+		Kind:       "Job",
+		Name:       job,
+		Controller: pointer.Bool(true),
+	}}
+	return pod
+}
+
+func Test_loadVerifyPolicyFile(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    verifyOptions
+		wantErr bool
+	}{
+		"empty policy file should keep defaults": {
+			content: "",
+			want:    defaultVerifyOptions(),
+		},
+		"policy file should override defaults": {
+			content: `renewalWarnBuffer: 2h
+disabledChecks:
+- upcoming-renewals
+namespaces:
+- foo
+`,
+			want: verifyOptions{
+				RenewalWarnBuffer: 2 * time.Hour,
+				ExpiryWarnBuffer:  defaultVerifyOptions().ExpiryWarnBuffer,
+				DisabledChecks:    []string{"upcoming-renewals"},
+				Namespaces:        []string{"foo"},
+			},
+		},
+		"policy file with unknown fields should produce an error": {
+			content: "foo: bar\n",
+			wantErr: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(scenario.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadVerifyPolicyFile(path, defaultVerifyOptions())
+			if (err != nil) != scenario.wantErr {
+				t.Fatalf("loadVerifyPolicyFile() error = %v, wantErr %v", err, scenario.wantErr)
+			}
+			if scenario.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, scenario.want) {
+				t.Errorf("loadVerifyPolicyFile() = %v, want %v", got, scenario.want)
+			}
+		})
+	}
+}
+
+func Test_verifyOptions_validate(t *testing.T) {
+	if err := defaultVerifyOptions().validate(); err != nil {
+		t.Errorf("validate() error = %v for default options", err)
+	}
+
+	opts := defaultVerifyOptions()
+	opts.DisabledChecks = []string{"not-a-check"}
+	if err := opts.validate(); err == nil {
+		t.Errorf("validate() expected error for unknown check ID")
+	}
+
+	opts = defaultVerifyOptions()
+	opts.ExpiryWarnBuffer = -time.Hour
+	if err := opts.validate(); err == nil {
+		t.Errorf("validate() expected error for negative buffer")
+	}
+}
+
+func Test_verifyResult(t *testing.T) {
+	tests := map[string]struct {
+		notifications []notification
+		wantExitCode  int
+	}{
+		"no notifications should not return an error": {
+			notifications: []notification{},
+			wantExitCode:  0,
+		},
+		"only advisory notifications should return the advisory exit code": {
+			notifications: []notification{
+				{check: unreadyCheck, results: []checkResult{unreadyCheck.result("foo", "foo", "")}},
+				{check: integrationCheck, results: []checkResult{integrationCheck.result("", "foo", "")}},
+			},
+			wantExitCode: exitCodeAdvisoryIssues,
+		},
+		"any blocking notification should return the blocking exit code": {
+			notifications: []notification{
+				{check: unreadyCheck, results: []checkResult{unreadyCheck.result("foo", "foo", "")}},
+				{check: ownerRefCheck, results: []checkResult{ownerRefCheck.result("foo", "foo", "")}},
+			},
+			wantExitCode: exitCodeBlockingIssues,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := verifyResult(scenario.notifications)
+			if scenario.wantExitCode == 0 {
+				if err != nil {
+					t.Errorf("verifyResult() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var exitCodeErr *internalerrors.ExitCodeError
+			if !errors.As(err, &exitCodeErr) {
+				t.Fatalf("verifyResult() error = %v, want ExitCodeError", err)
+			}
+			if exitCodeErr.Code != scenario.wantExitCode {
+				t.Errorf("verifyResult() exit code = %d, want %d", exitCodeErr.Code, scenario.wantExitCode)
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@ var (
 	kubeConfig string
 	apiURL     string
 	configDir  string
+
+	installersDir string
 )
 
 // Command returns the root cobra.Command instance for the entire command-line interface.
@@ -49,10 +51,12 @@ func Command() *cobra.Command {
 		Clusters(),
 		Config(),
 		Experimental(),
+		Images(),
 		Operator(),
 		Organizations(),
 		Registry(),
 		Users(),
+		Venafi(),
 		Version(&cmd.Version),
 	)
 
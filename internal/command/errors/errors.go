@@ -4,3 +4,18 @@ import "errors"
 
 // ErrNoOrganizationName is returned by commands that require an organization name to be provided, but none was provided.
 var ErrNoOrganizationName = errors.New("You do not have an organization selected, select one using: \n\n\tjsctl config set organization [name]")
+
+// ExitCodeError is returned by commands that need to exit with a specific non-zero exit code, for example so that
+// scripts can tell different kinds of failure apart.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
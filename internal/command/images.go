@@ -0,0 +1,25 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/images"
+)
+
+// Images returns a cobra.Command instance that is the root for all "jsctl images" subcommands.
+func Images() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "Subcommands for the images used by Jetstack Secure, such as mirroring them for air-gapped clusters",
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&installersDir, "installers-dir", "", "Location of a directory containing additional operator installers, listed with their checksums in an index.yaml file")
+
+	cmd.AddCommand(
+		images.List(runWithInstallers),
+		images.Mirror(runWithInstallers),
+	)
+
+	return cmd
+}
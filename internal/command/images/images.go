@@ -0,0 +1,101 @@
+// Package images contains the "jsctl images" subcommands for listing and mirroring the images used by Jetstack
+// Secure.
+package images
+
+import (
+	"fmt"
+	"os"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/spf13/pflag"
+
+	"github.com/jetstack/jsctl/internal/cluster"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+type (
+	// sourceOptions describe where the images used by Jetstack Secure are read from
+	sourceOptions struct {
+		operatorVersion  string
+		imageRegistry    string
+		agentRegistry    string
+		installationPath string
+	}
+
+	// sourceImage is an image along with where it is used
+	sourceImage struct {
+		Image  string `json:"image"`
+		Source string `json:"source"`
+	}
+)
+
+func (o *sourceOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.operatorVersion, "operator-version", "", "Specifies the version of the operator installer to read images from, defaults to latest")
+	flags.StringVar(&o.imageRegistry, "registry", operator.DefaultImageRegistry, "Specifies the image registry used by the operator installer")
+	flags.StringVar(&o.agentRegistry, "agent-registry", "quay.io/jetstack", "Specifies the image registry used by the agent installed by 'clusters connect'")
+	flags.StringVarP(&o.installationPath, "filename", "f", "", "Specifies a path to an Installation manifest, such as the output of 'operator installations apply --stdout', to read component images from, defaults to the Installation applied without flags")
+}
+
+// sourceImages returns the images of the operator installer, the agent and the components of the Installation.
+// Components that have no version in the Installation use the version chosen by the operator.
+func (o *sourceOptions) sourceImages() ([]sourceImage, error) {
+	var images []sourceImage
+
+	installerImages, err := operator.InstallerImages(o.operatorVersion, o.imageRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operator installer images: %w", err)
+	}
+	for _, image := range installerImages {
+		images = append(images, sourceImage{Image: image, Source: "operator"})
+	}
+
+	agentImages, err := cluster.AgentImages(o.agentRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent images: %w", err)
+	}
+	for _, image := range agentImages {
+		images = append(images, sourceImage{Image: image, Source: "agent"})
+	}
+
+	installation, err := o.installation()
+	if err != nil {
+		return nil, err
+	}
+
+	componentImages, err := operator.InstallationImages(installation, o.operatorVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine installation images: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, image := range images {
+		seen[image.Image] = true
+	}
+	for _, image := range componentImages {
+		if seen[image.Image] {
+			continue
+		}
+		seen[image.Image] = true
+		images = append(images, sourceImage{Image: image.Image, Source: "installation: " + image.Component})
+	}
+
+	return images, nil
+}
+
+func (o *sourceOptions) installation() (*operatorv1alpha1.Installation, error) {
+	if o.installationPath == "" {
+		installation, err := operator.GenerateInstallation(operator.ApplyInstallationYAMLOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate installation: %w", err)
+		}
+		return installation, nil
+	}
+
+	file, err := os.Open(o.installationPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening manifest file: %w", err)
+	}
+	defer file.Close()
+
+	return operator.LoadInstallation(file)
+}
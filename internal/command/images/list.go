@@ -0,0 +1,59 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/table"
+)
+
+func List(run types.RunFunc) *cobra.Command {
+	var (
+		source  sourceOptions
+		jsonOut bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the images used by the operator, the agent and the components of an Installation",
+		Long: `Lists the images used by the operator, the agent and the components of an Installation
+
+Images are read from the operator installer and agent manifests embedded in jsctl, and from the components enabled in an Installation. By default the Installation applied by 'operator installations apply' without flags is used, use --filename to read the components of another Installation, such as the output of 'operator installations apply --stdout'.
+
+Components without a version in the Installation are listed at the version installed by default by the operator version given with --operator-version. The default versions are read from the Installation CRD in the installer for that operator version. The command fails if the CRD does not give the default version of a component, in which case set a version for the component in the Installation.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			images, err := source.sourceImages()
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent(" ", " ")
+				return encoder.Encode(images)
+			}
+
+			tbl := table.NewBuilder([]string{
+				"IMAGE",
+				"SOURCE",
+			})
+
+			for _, image := range images {
+				tbl.AddRow(image.Image, image.Source)
+			}
+
+			return tbl.Build(os.Stdout)
+		}),
+	}
+
+	flags := cmd.Flags()
+	source.addFlags(flags)
+	flags.BoolVar(&jsonOut, "json", false, "Output images in JSON format")
+
+	return cmd
+}
@@ -0,0 +1,140 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/docker"
+	"github.com/jetstack/jsctl/internal/images"
+	"github.com/jetstack/jsctl/internal/registry"
+)
+
+func Mirror(run types.RunFunc) *cobra.Command {
+	var (
+		source           sourceOptions
+		to               string
+		plainHTTP        bool
+		dockerConfigPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Copies the images used by the operator, the agent and the components of an Installation to another registry",
+		Long: `Copies the images used by the operator, the agent and the components of an Installation to another registry
+
+The images listed by 'images list' are copied, including every platform of multi-platform images, using the OCI distribution API so that no container runtime is needed. Each image keeps only the last part of its repository, so for example --to registry.internal/jetstack copies eu.gcr.io/jetstack-secure-enterprise/js-operator to registry.internal/jetstack/js-operator. Pass the same registry to the --registry flags of 'operator deploy', 'operator installations apply' and 'clusters connect' to install from the mirror.
+
+The Jetstack Secure Enterprise registry is accessed with the credentials stored by 'registry auth init', and other registries with the credentials in the docker config file. Components without a version in the Installation are mirrored at the version installed by default by the operator version given with --operator-version, as described for 'images list'.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if to == "" {
+				return errors.New("error validating provided flags: --to must be specified")
+			}
+
+			sources, err := source.sourceImages()
+			if err != nil {
+				return err
+			}
+
+			credentials, err := mirrorCredentials(ctx, dockerConfigPath)
+			if err != nil {
+				return err
+			}
+
+			client := &images.Client{
+				HTTPClient:  http.DefaultClient,
+				Credentials: credentials,
+			}
+
+			for _, image := range sources {
+				src, err := images.ParseReference(image.Image)
+				if err != nil {
+					return err
+				}
+				dst, err := images.MirrorReference(src, to)
+				if err != nil {
+					return err
+				}
+				if plainHTTP {
+					client.PlainHTTP = []string{dst.Registry}
+				}
+
+				fmt.Fprintf(os.Stderr, "Copying %s to %s\n", src, dst)
+				if err := client.Copy(ctx, src, dst); err != nil {
+					return fmt.Errorf("failed to copy %s: %w", src, err)
+				}
+			}
+
+			fmt.Fprintf(os.Stderr, "Copied %d images to %s\n", len(sources), to)
+			return nil
+		}),
+	}
+
+	flags := cmd.Flags()
+	source.addFlags(flags)
+	flags.StringVar(&to, "to", "", "Specifies the registry, and optional path, to copy images to, such as registry.internal/jetstack")
+	flags.BoolVar(&plainHTTP, "plain-http", false, "If provided, the registry images are copied to is accessed over HTTP rather than HTTPS")
+	flags.StringVar(&dockerConfigPath, "docker-config", "~/.docker/config.json", "Location of the docker config file containing credentials for other registries")
+
+	return cmd
+}
+
+// mirrorCredentials returns the registry credentials from the docker config file, if it exists, along with the
+// stored credentials for the Jetstack Secure Enterprise registry
+func mirrorCredentials(ctx context.Context, dockerConfigPath string) (docker.Config, error) {
+	credentials := make(docker.Config)
+
+	path, err := homedir.Expand(dockerConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand docker config path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read docker config: %w", err)
+	default:
+		var config docker.ConfigJSON
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to decode docker config %s: %w", path, err)
+		}
+		for host, entry := range config.Auths {
+			credentials[host] = entry
+		}
+	}
+
+	registryCredentialsPath, err := registry.PathJetstackSecureEnterpriseRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyData, err := os.ReadFile(registryCredentialsPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Fprintf(os.Stderr, "No Jetstack Secure Enterprise registry credentials found, run 'jsctl registry auth init' to fetch them\n")
+		return credentials, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read registry credentials: %w", err)
+	}
+
+	configJSON, err := registry.DockerConfigJSON(string(keyData))
+	if err != nil {
+		return nil, err
+	}
+	var config docker.ConfigJSON
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode registry credentials: %w", err)
+	}
+	for host, entry := range config.Auths {
+		credentials[host] = entry
+	}
+
+	return credentials, nil
+}
@@ -18,9 +18,15 @@ management of 'Installation' resources. Get started by deploying
 the operator with "jsctl operator deploy --help"`,
 	}
 
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&installersDir, "installers-dir", "", "Location of a directory containing additional operator installers, listed with their checksums in an index.yaml file")
+
 	cmd.AddCommand(
-		operator.Deploy(run, &useStdout, &apiURL, &kubeConfig),
-		operator.Versions(run),
+		operator.Deploy(runWithInstallers, &useStdout, &apiURL, &kubeConfig),
+		operator.Upgrade(runWithInstallers, &apiURL, &kubeConfig),
+		operator.Remove(runWithInstallers, &kubeConfig),
+		operator.Render(runWithInstallers, &apiURL),
+		operator.Versions(runWithInstallers),
 		operatorInstallations(),
 	)
 
@@ -35,8 +41,9 @@ func operatorInstallations() *cobra.Command {
 	}
 
 	cmd.AddCommand(
-		operator.InstallationsApply(run, &useStdout, &apiURL, &kubeConfig),
-		operator.InstallationStatus(run, &useStdout, &kubeConfig),
+		operator.InstallationsApply(runWithInstallers, &useStdout, &apiURL, &kubeConfig),
+		operator.InstallationStatus(runWithInstallers, &useStdout, &kubeConfig),
+		operator.InstallationsValidate(runWithInstallers),
 	)
 
 	return cmd
@@ -4,22 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 
-	"github.com/jetstack/jsctl/internal/client"
-	internalerrors "github.com/jetstack/jsctl/internal/command/errors"
 	"github.com/jetstack/jsctl/internal/command/types"
-	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/kubernetes"
 	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+	"github.com/jetstack/jsctl/internal/kubernetes/rollout"
+	"github.com/jetstack/jsctl/internal/kubernetes/status"
 	"github.com/jetstack/jsctl/internal/operator"
 	"github.com/jetstack/jsctl/internal/prompt"
-	"github.com/jetstack/jsctl/internal/registry"
 	"github.com/jetstack/jsctl/internal/venafi"
 )
 
@@ -31,7 +37,9 @@ func InstallationsApply(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *
 		autoFetchRegistryCredentials  bool
 		certDiscoveryVenafi           bool
 		certDiscoveryVenafiConnection string
+		certDiscoveryVenafiFolder     string
 		certManagerReplicas           int
+		certManagerWebhookReplicas    int
 		certManagerVersion            string
 		csiDriver                     bool
 		csiDriverSpiffe               bool
@@ -39,149 +47,264 @@ func InstallationsApply(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *
 		istioCSR                      bool
 		istioCSRIssuer                string
 		istioCSRReplicas              int
+		istioCSRIssuerKind            string
+		istioCSRIssuerGroup           string
+		istioCSRIstioNamespace        string
 		operatorImageRegistry         string
 		registryCredentialsPath       string
 		tier                          string
 		venafiConnections             string
 		venafiIssuers                 []string
 		venafiOauthHelper             bool
+		trustManager                  bool
+		trustNamespace                string
+		trustBundle                   string
+		trustBundleSources            []string
+		approverPolicies              bool
 		backupFilePath                string
+		waitForReady                  bool
+		timeout                       time.Duration
+		configPath                    string
+		printConfig                   bool
+		merge                         bool
+		importFromCluster             bool
 	)
 
-	validator := func() error {
-		if certDiscoveryVenafi && certDiscoveryVenafiConnection == "" {
-			return errors.New("--cert-discovery-venafi set to true, but Venafi connection not provided, please provide via --experimental-cert-discovery-venafi-connection flag")
-		}
-		if registryCredentialsPath != "" && autoFetchRegistryCredentials {
-			return errors.New("cannot specify both --registry-credentials and --auto-fetch-registry-credentials")
-		}
-
-		if istioCSR && istioCSRIssuer == "" {
-			return errors.New("you must specify an issuer for istio-csr to use via the --istio-csr-issuer flag")
-		}
-
-		if tier != "" && tier != tierEnterprise && tier != tierEnterprisePlus {
-			return fmt.Errorf("invalid tier %q, must be either %q, %q or blank", tier, tierEnterprise, tierEnterprisePlus)
-		}
-
-		if backupFilePath != "" {
-			if _, err := os.Stat(backupFilePath); os.IsNotExist(err) {
-				return fmt.Errorf("backup file %q does not exist", backupFilePath)
-			}
-		}
-
-		return nil
-	}
+	defaults := defaultInstallationConfig()
 
-	cmd := &cobra.Command{
+	var cmd *cobra.Command
+	cmd = &cobra.Command{
 		Use:   "apply",
 		Short: "Applies an Installation manifest to the current cluster, configured via flags",
 		Long: `Applies an Installation manifest to the current cluster, configured via flags
 
+The Installation can also be configured with a file passed to --config, values set by flags take precedence over those in the file. Use --print-config to output the effective configuration as a file that can be kept in source control. Access tokens, passwords and API keys set inline in venafiConnections are redacted in the output, so credentials should be referenced with access-token-from, password-from and api-key-from instead.
+
+Use --import-from-cluster when moving an existing cert-manager installation to one managed by the operator. The version of cert-manager and the replica counts of its controller and webhook are read from its Deployments and its issuers are added to the Installation, taking the place of --experimental-issuers-backup-file. Settings that cannot be represented in the Installation, such as feature gates, DNS01 nameservers and ingress-shim defaults, are reported so that they can be reviewed. Values set by flags take precedence over those imported, and only the imported version and replica counts are included in the output of --print-config.
+
+By default the existing Installation is replaced. Use --merge to instead merge the requested configuration into the existing Installation, leaving components that were not requested untouched. The changes are shown as a diff and only they are applied.
+
 Note: If --auto-registry-credentials and --registry-credentials-path are unset, then the installation components will be deployed without an image pull secret. The images must be available for the component pods to start.`,
 		Args: cobra.ExactArgs(0),
 		Run: run(func(ctx context.Context, args []string) error {
 			var err error
 
-			var registryCredentials string
-			if registryCredentialsPath == "" && autoFetchRegistryCredentials {
-				cnf, ok := config.FromContext(ctx)
-				if !ok || cnf.Organization == "" {
-					return internalerrors.ErrNoOrganizationName
+			// fileCfg holds only the values set in the config file, so that
+			// they can be told apart from defaults
+			cfg, fileCfg := defaults, installationConfig{}
+			if configPath != "" {
+				cfg, err = loadInstallationConfig(configPath, cfg)
+				if err != nil {
+					return err
 				}
+				fileCfg, err = loadInstallationConfig(configPath, fileCfg)
+				if err != nil {
+					return err
+				}
+			}
+
+			flags := cmd.Flags()
+			if importFromCluster && (cfg.IssuersBackupFile != "" || flags.Changed("experimental-issuers-backup-file")) {
+				return errors.New("error validating provided flags: cannot specify both --import-from-cluster and an issuers backup file")
+			}
 
-				http := client.New(ctx, *apiURL)
+			var importCfg *rest.Config
+			if importFromCluster {
+				importCfg, err = kubernetes.NewConfig(*kubeConfig)
+				if err != nil {
+					return err
+				}
 
-				registryCredentialsBytes, err := registry.FetchOrLoadJetstackSecureEnterpriseRegistryCredentials(ctx, http)
+				imported, err := restore.FetchCertManagerConfig(ctx, importCfg)
 				if err != nil {
-					return fmt.Errorf("failed to fetch or load registry credentials: %s", err)
+					return fmt.Errorf("failed to import cert-manager configuration: %w", err)
 				}
+				reportImportedCertManagerConfig(imported, os.Stderr)
 
-				registryCredentials = string(registryCredentialsBytes)
+				// imported values only fill in what the config file and
+				// flags leave unset
+				if fileCfg.CertManager.Replicas == 0 {
+					cfg.CertManager.Replicas = imported.Replicas
+				}
+				// the webhook follows the controller's replica count
+				// unless they differ in the cluster
+				if fileCfg.CertManager.WebhookReplicas == 0 && imported.WebhookReplicas != imported.Replicas {
+					cfg.CertManager.WebhookReplicas = imported.WebhookReplicas
+				}
+				if imported.Version != "" && fileCfg.CertManager.Version == "" && !flags.Changed("cert-manager-version") {
+					operatorVersion, err := targetOperatorVersion(ctx, importCfg)
+					if err != nil {
+						return err
+					}
+					if err := checkImportedCertManagerVersion(imported.Version, operatorVersion); err != nil {
+						return err
+					}
+					cfg.CertManager.Version = imported.Version
+				}
 			}
 
-			if err := validator(); err != nil {
-				return fmt.Errorf("error validating provided flags: %w", err)
+			if flags.Changed("auto-registry-credentials") {
+				cfg.Registry.AutoFetchCredentials = autoFetchRegistryCredentials
 			}
-
-			issuers := &restore.RestoredIssuers{}
-			if backupFilePath != "" {
-				issuers, err = restore.ExtractOperatorManageableIssuersFromBackupFile(backupFilePath)
-				if err != nil {
-					return fmt.Errorf("error extracting issuers from backup file: %w", err)
+			if flags.Changed("registry") {
+				cfg.Registry.URL = operatorImageRegistry
+			}
+			if flags.Changed("registry-credentials-path") {
+				cfg.Registry.CredentialsPath = registryCredentialsPath
+			}
+			if flags.Changed("tier") {
+				cfg.Tier = tier
+			}
+			if flags.Changed("cert-manager-replicas") {
+				cfg.CertManager.Replicas = certManagerReplicas
+			}
+			if flags.Changed("cert-manager-webhook-replicas") {
+				cfg.CertManager.WebhookReplicas = certManagerWebhookReplicas
+			}
+			if flags.Changed("cert-manager-version") {
+				cfg.CertManager.Version = certManagerVersion
+			}
+			if flags.Changed("csi-driver") {
+				cfg.CSIDriver.Enabled = csiDriver
+			}
+			if flags.Changed("csi-driver-spiffe") {
+				cfg.CSIDriverSpiffe.Enabled = csiDriverSpiffe
+			}
+			if flags.Changed("csi-driver-spiffe-replicas") {
+				cfg.CSIDriverSpiffe.Replicas = csiDriverSpiffeReplicas
+			}
+			if flags.Changed("istio-csr") {
+				cfg.IstioCSR.Enabled = istioCSR
+			}
+			if flags.Changed("istio-csr-issuer") {
+				cfg.IstioCSR.Issuer = istioCSRIssuer
+			}
+			if flags.Changed("istio-csr-replicas") {
+				cfg.IstioCSR.Replicas = istioCSRReplicas
+			}
+			if flags.Changed("istio-csr-issuer-kind") {
+				cfg.IstioCSR.IssuerKind = istioCSRIssuerKind
+			}
+			if flags.Changed("istio-csr-issuer-group") {
+				cfg.IstioCSR.IssuerGroup = istioCSRIssuerGroup
+			}
+			if flags.Changed("istio-csr-istio-namespace") {
+				cfg.IstioCSR.IstioNamespace = istioCSRIstioNamespace
+			}
+			if flags.Changed("venafi-oauth-helper") {
+				cfg.VenafiOauthHelper.Enabled = venafiOauthHelper
+			}
+			if flags.Changed("trust-manager") {
+				cfg.TrustManager.Enabled = trustManager
+			}
+			if flags.Changed("trust-manager-trust-namespace") {
+				cfg.TrustManager.TrustNamespace = trustNamespace
+			}
+			if flags.Changed("trust-manager-bundle") || flags.Changed("trust-manager-bundle-sources") {
+				bundle := trustBundleConfig{}
+				if cfg.TrustManager.Bundle != nil {
+					bundle = *cfg.TrustManager.Bundle
 				}
+				if flags.Changed("trust-manager-bundle") {
+					bundle.Name = trustBundle
+				}
+				if flags.Changed("trust-manager-bundle-sources") {
+					bundle.Sources = trustBundleSources
+				}
+				cfg.TrustManager.Bundle = &bundle
 			}
-			if len(issuers.Missed) != 0 {
-				fmt.Fprintf(os.Stderr, "The following issuers cannot be managed by the operator and must be restored manually: %s\n", strings.Join(issuers.Missed, ", "))
+			if flags.Changed("generate-approver-policies") {
+				cfg.ApproverPolicy.GeneratePolicies = approverPolicies
 			}
-			if len(issuers.NeedsConversion) != 0 {
-				fmt.Fprintf(os.Stderr, "The following issuers need to be converted to cert-manager v1 resources: %s\n", strings.Join(issuers.NeedsConversion, ", "))
-				fmt.Fprintf(os.Stderr, "This can be done using cmctl convert, see here for more information: https://cert-manager.io/docs/reference/cmctl/#convert\n")
+			if flags.Changed("cert-discovery-venafi") {
+				cfg.CertDiscoveryVenafi.Enabled = certDiscoveryVenafi
+			}
+			if flags.Changed("experimental-cert-discovery-venafi-connection") {
+				cfg.CertDiscoveryVenafi.Connection = certDiscoveryVenafiConnection
+			}
+			if flags.Changed("experimental-cert-discovery-venafi-policy-folder") {
+				cfg.CertDiscoveryVenafi.PolicyFolder = certDiscoveryVenafiFolder
+			}
+			if flags.Changed("experimental-venafi-connections-config") {
+				cfg.VenafiConnectionsFile = venafiConnections
+			}
+			if flags.Changed("experimental-venafi-issuers") {
+				cfg.VenafiIssuers = nil
+				for _, issuer := range venafiIssuers {
+					issuerConfig, err := parseVenafiIssuerConfig(issuer)
+					if err != nil {
+						return fmt.Errorf("error validating provided flags: %w", err)
+					}
+					cfg.VenafiIssuers = append(cfg.VenafiIssuers, issuerConfig)
+				}
+			}
+			if flags.Changed("experimental-issuers-backup-file") {
+				cfg.IssuersBackupFile = backupFilePath
 			}
 
-			options := operator.ApplyInstallationYAMLOptions{
-				ImageRegistry:           operatorImageRegistry,
-				RegistryCredentialsPath: registryCredentialsPath,
-				RegistryCredentials:     registryCredentials,
-
-				// Cert Manager configuration
-				CertManagerReplicas: certManagerReplicas,
-				CertManagerVersion:  certManagerVersion,
-
-				// CSI Driver configuration
-				InstallCSIDriver:         csiDriver,
-				InstallSpiffeCSIDriver:   csiDriverSpiffe,
-				InstallVenafiOauthHelper: venafiOauthHelper,
-				SpiffeCSIDriverReplicas:  csiDriverSpiffeReplicas,
-
-				// Istio CSR configuration
-				InstallIstioCSR:  istioCSR,
-				IstioCSRIssuer:   istioCSRIssuer,
-				IstioCSRReplicas: istioCSRReplicas,
-
-				// Approver Policy configuration
-				InstallApproverPolicyEnterprise: false,
+			if err := cfg.validate(); err != nil {
+				return fmt.Errorf("error validating provided flags: %w", err)
+			}
+			if waitForReady && *useStdout {
+				return errors.New("error validating provided flags: cannot specify both --wait and --stdout")
+			}
+			if merge && *useStdout {
+				return errors.New("error validating provided flags: cannot specify both --merge and --stdout")
+			}
 
-				// Restored Issuers
-				ImportedCertManagerIssuers:        issuers.CertManagerIssuers,
-				ImportedCertManagerClusterIssuers: issuers.CertManagerClusterIssuers,
-				ImportedVenafiIssuers:             issuers.VenafiIssuers,
-				ImportedVenafiClusterIssuers:      issuers.VenafiClusterIssuers,
+			if printConfig {
+				return yaml.NewEncoder(os.Stdout).Encode(cfg.redacted())
 			}
 
-			if tier == tierEnterprisePlus {
-				options.InstallApproverPolicyEnterprise = true
+			if merge {
+				cfg = cfg.mergeableConfig(fileCfg, flags.Changed, importFromCluster)
 			}
 
-			vcs, err := parseVenafiConnections(venafiConnections)
+			registryCredentials, err := loadRegistryCredentials(ctx, *apiURL, cfg.Registry.CredentialsPath, cfg.Registry.AutoFetchCredentials)
 			if err != nil {
-				return fmt.Errorf("error parsing Venafi connection config: %w", err)
+				return err
 			}
 
-			vis, err := venafi.ParseIssuerConfig(venafiIssuers, vcs, venafiOauthHelper)
+			options, err := cfg.installationOptions(ctx, registryCredentials, venafi.NewKubernetesSecretGetter(*kubeConfig), os.Stderr)
 			if err != nil {
-				return fmt.Errorf("error parsing Venafi issuer config: %w", err)
+				return err
 			}
-			options.VenafiIssuers = vis
 
-			cdv, err := venafi.ParseCertDiscoveryVenafiConfig(certDiscoveryVenafiConnection, vcs, certDiscoveryVenafi)
-			if err != nil {
-				return fmt.Errorf("error parsing cert-discovery-venafi config: %w", err)
+			if importFromCluster {
+				issuers, err := restore.ExtractOperatorManageableIssuersFromCluster(ctx, importCfg)
+				if err != nil {
+					return fmt.Errorf("failed to import issuers: %w", err)
+				}
+				reportUnmanageableIssuers(issuers, os.Stderr)
+
+				options.ImportedCertManagerIssuers = issuers.CertManagerIssuers
+				options.ImportedCertManagerClusterIssuers = issuers.CertManagerClusterIssuers
+				options.ImportedVenafiIssuers = issuers.VenafiIssuers
+				options.ImportedVenafiClusterIssuers = issuers.VenafiClusterIssuers
 			}
-			options.CertDiscoveryVenafi = cdv
 
 			var applier operator.Applier
+			var existing *operatorv1alpha1.Installation
+			var installationClient *clients.InstallationClient
+			var kubeCfg *rest.Config
 			if *useStdout {
 				applier = kubernetes.NewStdOutApplier()
 			} else {
 				// before starting the application of the installation instance,
 				// we can check if the installation CRD is present
-				kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+				kubeCfg, err = kubernetes.NewConfig(*kubeConfig)
 				if err != nil {
 					return err
 				}
 
-				installationClient, err := clients.NewInstallationClient(kubeCfg)
+				// the Installation is validated against the CRD served by
+				// the operator running in the cluster
+				options.OperatorVersion, err = targetOperatorVersion(ctx, kubeCfg)
+				if err != nil {
+					return err
+				}
+
+				installationClient, err = clients.NewInstallationClient(kubeCfg)
 				if err != nil {
 					return err
 				}
@@ -194,17 +317,88 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 					return fmt.Errorf("failed to check cluster status before deploying new installation: %w", err)
 				}
 
+				if options.InstallIstioCSR && options.IstioCSRIssuer != "" {
+					if err := checkIstioCSRIssuer(ctx, kubeCfg, options); err != nil {
+						return err
+					}
+				}
+
+				if options.GenerateApproverPolicies {
+					// issuers that are not managed by the installation also
+					// need a policy once approver-policy is installed
+					options.PolicyIssuers, err = findPolicyIssuers(ctx, kubeCfg)
+					if err != nil {
+						return err
+					}
+				}
+
+				if merge {
+					existing, err = installationClient.Get(ctx, operator.InstallationName)
+					switch {
+					case errors.Is(err, clients.ErrNoInstallation):
+						fmt.Fprintf(os.Stderr, "No existing installation %q found, applying a new installation\n", operator.InstallationName)
+					case err != nil:
+						return err
+					}
+				}
+
 				applier, err = kubernetes.NewKubeConfigApplier(*kubeConfig)
 				if err != nil {
 					return err
 				}
 			}
 
-			err = operator.ApplyInstallationYAML(ctx, applier, options)
+			if existing != nil {
+				err = applyInstallationMerge(ctx, applier, installationClient, existing, options)
+			} else {
+				err = operator.ApplyInstallationYAML(ctx, applier, options)
+			}
 			if err != nil {
 				return fmt.Errorf("failed to apply component manifests: %w", err)
 			}
 
+			if options.TrustBundle != nil {
+				if *useStdout {
+					fmt.Println("---")
+				} else {
+					// the Bundle CRD is installed by the operator along with
+					// trust-manager, so the applier's discovery information is
+					// refreshed once it is established
+					crdClient, err := clients.NewCRDClient(kubeCfg)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(os.Stderr, "Waiting for trust-manager to be installed before applying Bundle %q\n", options.TrustBundle.Name)
+					err = rollout.WaitForCRD(ctx, crdClient, operator.TrustBundleCRDName, rollout.DefaultInterval, timeout, os.Stderr)
+					if err != nil {
+						return err
+					}
+
+					applier, err = kubernetes.NewKubeConfigApplier(*kubeConfig)
+					if err != nil {
+						return err
+					}
+				}
+
+				if err := operator.ApplyTrustBundleYAML(ctx, applier, options); err != nil {
+					return fmt.Errorf("failed to apply trust-manager Bundle: %w", err)
+				}
+			}
+
+			if options.GenerateApproverPolicies {
+				if err := applyApproverPolicies(ctx, applier, kubeCfg, *kubeConfig, timeout, options); err != nil {
+					return err
+				}
+			}
+
+			if waitForReady {
+				err = rollout.WaitForInstallation(ctx, installationClient, operator.InstallationName, rollout.DefaultInterval, timeout, os.Stderr)
+				if err != nil {
+					return err
+				}
+			}
+
 			suggestions := operator.SuggestedActions(options)
 			if len(suggestions) == 0 {
 				return nil
@@ -220,34 +414,261 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 	flags.BoolVar(&csiDriver, "csi-driver", false, "Include the cert-manager CSI driver (https://github.com/cert-manager/csi-driver)")
 	flags.BoolVar(&csiDriverSpiffe, "csi-driver-spiffe", false, "Include the cert-manager spiffe CSI driver (https://github.com/cert-manager/csi-driver-spiffe)")
 	flags.BoolVar(&istioCSR, "istio-csr", false, "Include the cert-manager Istio CSR agent (https://github.com/cert-manager/istio-csr)")
+	flags.BoolVar(&trustManager, "trust-manager", false, "Include trust-manager (https://cert-manager.io/docs/projects/trust-manager/)")
+	flags.StringVar(&trustNamespace, "trust-manager-trust-namespace", defaults.TrustManager.TrustNamespace, "Specifies the namespace that trust-manager reads Bundle sources from. This does not configure trust-manager, as the Installation has no setting for it, it only changes where the suggested commands create the sources of --trust-manager-bundle")
+	flags.StringVar(&trustBundle, "trust-manager-bundle", "", "Specifies the name of a trust-manager Bundle to create, which is distributed as a ConfigMap with the key "+operator.TrustBundleTargetKey+" to every namespace")
+	flags.StringSliceVar(&trustBundleSources, "trust-manager-bundle-sources", []string{}, "Specifies the sources of the --trust-manager-bundle Bundle in the form 'secret:name:key', 'configmap:name:key' or 'default-cas'. Secrets and ConfigMaps are read from the trust namespace. Defaults to the default CA package")
+	flags.BoolVar(&approverPolicies, "generate-approver-policies", false, "If set, a CertificateRequestPolicy that allows any request, and the RBAC for cert-manager to use it, is generated for each issuer in the Installation and, unless --stdout is set, each issuer found in the cluster. This keeps existing issuers working once approver-policy replaces cert-manager's default approver")
 	flags.BoolVar(&venafiOauthHelper, "venafi-oauth-helper", false, "Include venafi-oauth-helper (https://platform.jetstack.io/documentation/installation/venafi-oauth-helper)")
-	flags.IntVar(&certManagerReplicas, "cert-manager-replicas", 2, "Specifies the number of replicas for the cert-manager deployment")
-	flags.IntVar(&csiDriverSpiffeReplicas, "csi-driver-spiffe-replicas", 2, "Specifies the number of replicas for the csi-driver-spiffe deployment")
-	flags.IntVar(&istioCSRReplicas, "istio-csr-replicas", 2, "Specifies the number of replicas for the istio-csr deployment")
-	flags.StringSliceVar(&venafiIssuers, "experimental-venafi-issuers", []string{}, "Specifies a list of Venafi issuers to configure. Issuer names should be in form 'type:connection:name:[namespace]'. Type can be 'tpp', connection refers to a Venafi connection (see --experimental-venafi-connection flag), name is the name of the issuer and namespace is the namespace in which to create the issuer. Leave out namepsace to create a cluster scoped issuer. This flag is experimental and is likely to change.")
+	flags.IntVar(&certManagerReplicas, "cert-manager-replicas", defaults.CertManager.Replicas, "Specifies the number of replicas for the cert-manager deployment")
+	flags.IntVar(&certManagerWebhookReplicas, "cert-manager-webhook-replicas", defaults.CertManager.WebhookReplicas, "Specifies the number of replicas for the cert-manager webhook deployment. Defaults to the value of --cert-manager-replicas")
+	flags.IntVar(&csiDriverSpiffeReplicas, "csi-driver-spiffe-replicas", defaults.CSIDriverSpiffe.Replicas, "Specifies the number of replicas for the csi-driver-spiffe deployment")
+	flags.StringVar(&istioCSRIssuerKind, "istio-csr-issuer-kind", "", "Specifies the kind of the issuer that the Istio CSR should use, such as ClusterIssuer or an external issuer kind. Defaults to Issuer")
+	flags.StringVar(&istioCSRIssuerGroup, "istio-csr-issuer-group", "", "Specifies the API group of the issuer that the Istio CSR should use, required for external issuers. Defaults to cert-manager.io")
+	flags.StringVar(&istioCSRIstioNamespace, "istio-csr-istio-namespace", "", "Specifies the namespace Istio is installed in, namespaced issuers used by the Istio CSR must be in this namespace. Defaults to "+defaultIstioNamespace)
+	flags.IntVar(&istioCSRReplicas, "istio-csr-replicas", defaults.IstioCSR.Replicas, "Specifies the number of replicas for the istio-csr deployment")
+	flags.StringSliceVar(&venafiIssuers, "experimental-venafi-issuers", []string{}, "Specifies a list of Venafi issuers to configure. Issuer names should be in form 'type:connection:name:[namespace]'. Type can be 'tpp' or 'vaas' (Venafi as a Service), connection refers to a Venafi connection (see --experimental-venafi-connection flag), name is the name of the issuer and namespace is the namespace in which to create the issuer. Leave out namepsace to create a cluster scoped issuer. This flag is experimental and is likely to change.")
 	flags.StringVar(&certDiscoveryVenafiConnection, "experimental-cert-discovery-venafi-connection", "", "The name of the Venafi connection provided via --experimental-venafi-connections-config flag, to be used to configure cert-discovery-venafi")
+	flags.StringVar(&certDiscoveryVenafiFolder, "experimental-cert-discovery-venafi-policy-folder", "", "The TPP policy folder cert-discovery-venafi uploads certificates to, defaults to the zone of the --experimental-cert-discovery-venafi-connection")
 	flags.StringVar(&certManagerVersion, "cert-manager-version", "", "Specifies the version of cert-manager deployment. Defaults to latest")
 	flags.StringVar(&istioCSRIssuer, "istio-csr-issuer", "", "Specifies the cert-manager issuer that the Istio CSR should use")
 	flags.StringVar(&operatorImageRegistry, "registry", "", "Specifies the image registry to use for the operator's components")
 	flags.StringVar(&registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for image pull secrets")
 	flags.StringVar(&venafiConnections, "experimental-venafi-connections-config", "", "Specifies a path to a file with yaml formatted Venafi connection details")
 	flags.StringVar(&tier, "tier", "", "For users with access to enterprise tier functionality, setting this flag will enable enterprise defaults instead. Valid values are 'enterprise', 'enterprise-plus' or blank")
+	flags.StringVar(&configPath, "config", "", "Specifies a path to a file configuring the Installation, values set by other flags take precedence")
+	flags.BoolVar(&printConfig, "print-config", false, "If set, the effective configuration is output in the --config file format instead of being applied. Inline Venafi connection credentials are redacted, use the *-from credential sources to keep them out of the file")
+	flags.BoolVar(&merge, "merge", false, "If set, the requested configuration is merged into the existing Installation rather than replacing it, and only the differences are applied. The existing versions, replica counts and other settings of each component are kept unless they are set")
+	flags.BoolVar(&waitForReady, "wait", false, "If set, waits for all components of the Installation to become ready before returning")
+	flags.DurationVar(&timeout, "timeout", 10*time.Minute, "How long to wait for the Installation to become ready when --wait is set, for trust-manager to be installed when --trust-manager-bundle is set, and for approver-policy to be installed when --generate-approver-policies is set")
+	flags.BoolVar(&importFromCluster, "import-from-cluster", false, "If set, the version and replica counts of cert-manager and its issuers are imported from the existing installation in the cluster unless set by flags or the config file, settings that cannot be represented in the Installation are reported. An imported cert-manager version must be supported by the operator")
 	flags.StringVar(&backupFilePath, "experimental-issuers-backup-file", "", "Provide a file containing cert-manager.io/v1 Issuers or ClusterIssuers definitions to be added to Installation and to be managed by the operator. Note: only cert-manager.io/v1 Issuers and ClusterIssuers are currently supported. Support for other issuer groups and versions will be added in future.")
 
 	return cmd
 }
 
+// reportImportedCertManagerConfig writes the imported cert-manager settings,
+// and those that cannot be represented in the Installation, to out
+func reportImportedCertManagerConfig(imported *restore.CertManagerConfig, out io.Writer) {
+	version := imported.Version
+	if version == "" {
+		version = "unknown"
+	}
+	fmt.Fprintf(out, "Importing cert-manager %s with %d replica(s) from namespace %s\n", version, imported.Replicas, imported.Namespace)
+	if imported.WebhookReplicas != 0 && imported.WebhookReplicas != imported.Replicas {
+		fmt.Fprintf(out, "Importing the cert-manager webhook with %d replica(s)\n", imported.WebhookReplicas)
+	}
+
+	if len(imported.Unsupported) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "The following cert-manager settings cannot be represented in the Installation and will not be applied by the operator:")
+	for _, setting := range imported.Unsupported {
+		fmt.Fprintf(out, "  - %s\n", setting)
+	}
+}
+
+// defaultIstioNamespace is the namespace Istio is installed in when the
+// Installation does not specify one
+const defaultIstioNamespace = "istio-system"
+
+// checkIstioCSRIssuer returns an error if the issuer used by istio-csr does not exist in the cluster, unless it is one
+// of the issuers created by the Installation. Namespaced issuers must be in the Istio namespace.
+func checkIstioCSRIssuer(ctx context.Context, kubeCfg *rest.Config, options operator.ApplyInstallationYAMLOptions) error {
+	ref := operator.IstioCSRIssuerRef(options)
+	namespace := options.IstioCSRIstioNamespace
+	if namespace == "" {
+		namespace = defaultIstioNamespace
+	}
+
+	installation, err := operator.GenerateInstallation(options)
+	if err != nil {
+		return err
+	}
+	for _, issuer := range installation.Spec.Issuers {
+		if issuerMatchesRef(issuer, ref, namespace) {
+			return nil
+		}
+	}
+
+	issuerRefClient, err := clients.NewIssuerRefClient(kubeCfg)
+	if err != nil {
+		return err
+	}
+
+	err = issuerRefClient.Check(ctx, ref, namespace)
+	switch {
+	case errors.Is(err, clients.ErrNoIssuer), errors.Is(err, clients.ErrNoIssuerKind):
+		return fmt.Errorf("the issuer for istio-csr is not available, create it before applying the installation: %w", err)
+	case err != nil:
+		return fmt.Errorf("failed to check the issuer for istio-csr: %w", err)
+	}
+
+	return nil
+}
+
+// issuerMatchesRef returns true if the issuer managed by the Installation is the one referenced by ref
+func issuerMatchesRef(issuer *operatorv1alpha1.Issuer, ref *certmanagermetav1.ObjectReference, namespace string) bool {
+	kind, group := cmapi.IssuerKind, cmapi.SchemeGroupVersion.Group
+	if issuer.ClusterScope {
+		kind = cmapi.ClusterIssuerKind
+	}
+	if issuer.VenafiEnhancedIssuer != nil {
+		kind, group = "VenafiIssuer", veiv1alpha1.SchemeGroupVersion.Group
+		if issuer.ClusterScope {
+			kind = "VenafiClusterIssuer"
+		}
+	}
+
+	return issuer.Name == ref.Name && kind == ref.Kind && group == ref.Group &&
+		(issuer.ClusterScope || issuer.Namespace == namespace)
+}
+
+// applyApproverPolicies applies a CertificateRequestPolicy for each issuer
+// in the options. When applying to a cluster, kubeCfg is not nil and the
+// policies are applied once approver-policy has been installed by the
+// operator.
+func applyApproverPolicies(ctx context.Context, applier operator.Applier, kubeCfg *rest.Config, kubeConfig string, timeout time.Duration, options operator.ApplyInstallationYAMLOptions) error {
+	issuers, err := operator.ApproverPolicyIssuers(options)
+	if err != nil {
+		return err
+	}
+	if len(issuers) == 0 {
+		fmt.Fprintln(os.Stderr, "No issuers found, no CertificateRequestPolicies were generated")
+		return nil
+	}
+
+	if kubeCfg == nil {
+		fmt.Println("---")
+	} else {
+		// the CertificateRequestPolicy CRD is installed by the operator along
+		// with approver-policy, so the applier's discovery information is
+		// refreshed once it is established
+		crdClient, err := clients.NewCRDClient(kubeCfg)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Waiting for approver-policy to be installed before applying CertificateRequestPolicies for %d issuer(s)\n", len(issuers))
+		err = rollout.WaitForCRD(ctx, crdClient, operator.CertificateRequestPolicyCRDName, rollout.DefaultInterval, timeout, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		applier, err = kubernetes.NewKubeConfigApplier(kubeConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := operator.ApplyApproverPoliciesYAML(ctx, applier, options); err != nil {
+		return fmt.Errorf("failed to apply CertificateRequestPolicies: %w", err)
+	}
+	return nil
+}
+
+// findPolicyIssuers returns the issuers in the cluster that approver policies
+// should be generated for
+func findPolicyIssuers(ctx context.Context, kubeCfg *rest.Config) ([]operator.PolicyIssuer, error) {
+	issuers, err := status.FindIssuers(ctx, kubeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find issuers in the cluster: %w", err)
+	}
+	return policyIssuers(issuers)
+}
+
+// policyIssuers converts the issuers found in a cluster into the form used to
+// generate approver policies
+func policyIssuers(issuers []status.SummaryIssuer) ([]operator.PolicyIssuer, error) {
+	policyIssuers := make([]operator.PolicyIssuer, 0, len(issuers))
+	for _, issuer := range issuers {
+		gv, err := schema.ParseGroupVersion(issuer.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API version for issuer %s: %w", issuer.Name, err)
+		}
+		policyIssuers = append(policyIssuers, operator.PolicyIssuer{
+			Name:      issuer.Name,
+			Kind:      issuer.Kind,
+			Group:     gv.Group,
+			Namespace: issuer.Namespace,
+		})
+	}
+	return policyIssuers, nil
+}
+
+// applyInstallationMerge merges the requested configuration into the existing Installation, writes the resulting
+// changes to stderr as a diff and applies them
+func applyInstallationMerge(
+	ctx context.Context,
+	applier operator.Applier,
+	installationClient *clients.InstallationClient,
+	existing *operatorv1alpha1.Installation,
+	options operator.ApplyInstallationYAMLOptions,
+) error {
+	merged, err := operator.MergeInstallationYAML(existing, options)
+	if err != nil {
+		return err
+	}
+
+	if err := merged.ApplySecrets(ctx, applier); err != nil {
+		return err
+	}
+
+	if merged.Patch == nil {
+		fmt.Fprintf(os.Stderr, "Installation %q is unchanged\n", existing.Name)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Updating installation %q:\n%s", existing.Name, merged.Diff)
+
+	return installationClient.Patch(ctx, existing.Name, merged.Patch)
+}
+
 func parseVenafiConnections(configPath string) (map[string]*venafi.VenafiConnection, error) {
 	if configPath == "" {
 		return nil, nil
 	}
-	vcs := make(map[string]*venafi.VenafiConnection)
-	file, err := os.Open(configPath)
+	return venafi.LoadConnections(configPath)
+}
+
+// targetOperatorVersion returns the version of the operator running in the
+// cluster if jsctl has its installer, or an empty string to use the latest
+// installer otherwise
+func targetOperatorVersion(ctx context.Context, kubeCfg *rest.Config) (string, error) {
+	running, found, err := runningOperatorVersion(ctx, kubeCfg)
+	if err != nil || !found {
+		return "", err
+	}
+
+	versions, err := operator.Versions()
 	if err != nil {
-		return nil, fmt.Errorf("error opening config file: %w", err)
+		return "", fmt.Errorf("failed to get operator versions: %w", err)
 	}
-	if err = yaml.NewDecoder(file).Decode(&vcs); err != nil {
-		return nil, fmt.Errorf("error decoding connection configuration: %w", err)
+	for _, version := range versions {
+		if version == running {
+			return running, nil
+		}
 	}
-	return vcs, nil
+
+	return "", nil
+}
+
+// checkImportedCertManagerVersion returns an error if the cert-manager version
+// imported from the cluster is not supported by the given operator version
+func checkImportedCertManagerVersion(version, operatorVersion string) error {
+	supported, err := operator.SupportedCertManagerVersions(operatorVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get supported cert-manager versions: %w", err)
+	}
+	if len(supported) == 0 {
+		return nil
+	}
+
+	for _, v := range supported {
+		if v == version {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cert-manager %s imported from the cluster is not supported by the operator, which supports %s: set --cert-manager-version or certManager.version in the config file to choose a supported version", version, strings.Join(supported, ", "))
 }
@@ -0,0 +1,83 @@
+package operator
+
+import (
+	"reflect"
+	"testing"
+
+	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/status"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func Test_issuerMatchesRef(t *testing.T) {
+	tests := map[string]struct {
+		issuer *operatorv1alpha1.Issuer
+		ref    *certmanagermetav1.ObjectReference
+		want   bool
+	}{
+		"issuer in the istio namespace should match": {
+			issuer: &operatorv1alpha1.Issuer{Name: "istio-ca", Namespace: "istio-system", CA: &operatorv1alpha1.CAIssuer{}},
+			ref:    &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "Issuer", Group: "cert-manager.io"},
+			want:   true,
+		},
+		"issuer in another namespace should not match": {
+			issuer: &operatorv1alpha1.Issuer{Name: "istio-ca", Namespace: "default", CA: &operatorv1alpha1.CAIssuer{}},
+			ref:    &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "Issuer", Group: "cert-manager.io"},
+		},
+		"cluster issuer should match a ClusterIssuer ref": {
+			issuer: &operatorv1alpha1.Issuer{Name: "istio-ca", ClusterScope: true, CA: &operatorv1alpha1.CAIssuer{}},
+			ref:    &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+			want:   true,
+		},
+		"cluster issuer should not match an Issuer ref": {
+			issuer: &operatorv1alpha1.Issuer{Name: "istio-ca", ClusterScope: true, CA: &operatorv1alpha1.CAIssuer{}},
+			ref:    &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "Issuer", Group: "cert-manager.io"},
+		},
+		"venafi cluster issuer should match a VenafiClusterIssuer ref": {
+			issuer: &operatorv1alpha1.Issuer{Name: "venafi", ClusterScope: true, VenafiEnhancedIssuer: &veiv1alpha1.VenafiCertificateSource{}},
+			ref:    &certmanagermetav1.ObjectReference{Name: "venafi", Kind: "VenafiClusterIssuer", Group: "jetstack.io"},
+			want:   true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := issuerMatchesRef(scenario.issuer, scenario.ref, "istio-system"); got != scenario.want {
+				t.Errorf("issuerMatchesRef() = %v, want %v", got, scenario.want)
+			}
+		})
+	}
+}
+
+func Test_policyIssuers(t *testing.T) {
+	got, err := policyIssuers([]status.SummaryIssuer{
+		{APIVersion: "cert-manager.io/v1", Kind: "Issuer", Name: "ca", Namespace: "default"},
+		{APIVersion: "awspca.cert-manager.io/v1beta1", Kind: "AWSPCAClusterIssuer", Name: "pca"},
+	})
+	if err != nil {
+		t.Fatalf("policyIssuers() error = %v", err)
+	}
+
+	want := []operator.PolicyIssuer{
+		{Name: "ca", Kind: "Issuer", Group: "cert-manager.io", Namespace: "default"},
+		{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("policyIssuers() = %v, want %v", got, want)
+	}
+
+	if _, err := policyIssuers([]status.SummaryIssuer{{APIVersion: "a/b/c", Kind: "Issuer", Name: "ca"}}); err == nil {
+		t.Error("policyIssuers() expected an error for an invalid API version")
+	}
+}
+
+func Test_checkImportedCertManagerVersion(t *testing.T) {
+	if err := checkImportedCertManagerVersion("v1.10.1", ""); err != nil {
+		t.Errorf("checkImportedCertManagerVersion() unexpected error for supported version: %s", err)
+	}
+	if err := checkImportedCertManagerVersion("v1.8.0", ""); err == nil {
+		t.Errorf("checkImportedCertManagerVersion() expected error for unsupported version")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,24 +14,32 @@ import (
 	"github.com/jetstack/jsctl/internal/command/types"
 	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/rollout"
 	"github.com/jetstack/jsctl/internal/operator"
 	"github.com/jetstack/jsctl/internal/registry"
 )
 
-func Deploy(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *string) *cobra.Command {
-	const defaultRegistry = "eu.gcr.io/jetstack-secure-enterprise"
+// defaultRegistry is the registry that operator images are pulled from by default
+const defaultRegistry = operator.DefaultImageRegistry
 
+func Deploy(run types.RunFunc, useStdout *bool, apiURL, kubeConfig *string) *cobra.Command {
 	var (
 		operatorImageRegistry        string
 		registryCredentialsPath      string
 		autoFetchRegistryCredentials bool
 		version                      string
+		waitForReady                 bool
+		timeout                      time.Duration
 	)
 
 	validator := func() error {
 		if registryCredentialsPath != "" && autoFetchRegistryCredentials {
 			return errors.New("cannot specify both --registry-credentials and --auto-fetch-registry-credentials")
 		}
+		if waitForReady && *useStdout {
+			return errors.New("cannot specify both --wait and --stdout")
+		}
 		return nil
 	}
 
@@ -58,29 +67,11 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				}
 			}
 
-			var registryCredentials string
-			if registryCredentialsPath == "" && autoFetchRegistryCredentials {
-				cnf, ok := config.FromContext(ctx)
-				if !ok || cnf.Organization == "" {
-					return internalerrors.ErrNoOrganizationName
-				}
-
-				http := client.New(ctx, *apiURL)
-
-				registryCredentialsBytes, err := registry.FetchOrLoadJetstackSecureEnterpriseRegistryCredentials(ctx, http)
-				if err != nil {
-					return fmt.Errorf("failed to fetch or load registry credentials: %s", err)
-				}
-
-				registryCredentials = string(registryCredentialsBytes)
-			}
-			if registryCredentials == "" && registryCredentialsPath != "" {
-				registryCredentialsBytes, err := os.ReadFile(registryCredentialsPath)
-				if err != nil {
-					return fmt.Errorf("failed to read registry credentials file: %s", err)
-				}
-				registryCredentials = string(registryCredentialsBytes)
+			registryCredentials, err := loadRegistryCredentials(ctx, *apiURL, registryCredentialsPath, autoFetchRegistryCredentials)
+			if err != nil {
+				return err
 			}
+
 			// warn the user if no credentials are set by this point
 			if registryCredentials == "" {
 				fmt.Fprint(os.Stderr, "Note: no image pull credentials specified, the operator will be deployed without an image pull secret. If operator images are not present or accessible then the operator will be unable to start.\n")
@@ -101,6 +92,10 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 				return fmt.Errorf("failed to apply operator manifests: %s", err)
 			}
 
+			if waitForReady {
+				return waitForOperator(ctx, *kubeConfig, version, timeout)
+			}
+
 			return nil
 		}),
 	}
@@ -110,6 +105,59 @@ Note: If --auto-registry-credentials and --registry-credentials-path are unset,
 	flags.StringVar(&operatorImageRegistry, "registry", defaultRegistry, "Specifies an alternative image registry to use for js-operator and cainjector images")
 	flags.StringVar(&registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for docker image pull secrets")
 	flags.StringVar(&version, "version", "", "Specifies a specific version of the operator to install, defaults to latest")
+	flags.BoolVar(&waitForReady, "wait", false, "If set, waits for the operator Deployments to be rolled out before returning")
+	flags.DurationVar(&timeout, "timeout", 5*time.Minute, "How long to wait for the operator to become ready when --wait is set")
 
 	return cmd
 }
+
+// loadRegistryCredentials returns the credentials used to pull operator images,
+// either read from registryCredentialsPath or fetched from the Jetstack Secure
+// API if autoFetch is set. An empty string is returned if neither is set.
+func loadRegistryCredentials(ctx context.Context, apiURL, registryCredentialsPath string, autoFetch bool) (string, error) {
+	if registryCredentialsPath == "" && autoFetch {
+		cnf, ok := config.FromContext(ctx)
+		if !ok || cnf.Organization == "" {
+			return "", internalerrors.ErrNoOrganizationName
+		}
+
+		http := client.New(ctx, apiURL)
+
+		registryCredentialsBytes, err := registry.FetchOrLoadJetstackSecureEnterpriseRegistryCredentials(ctx, http)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch or load registry credentials: %s", err)
+		}
+
+		return string(registryCredentialsBytes), nil
+	}
+	if registryCredentialsPath != "" {
+		registryCredentialsBytes, err := os.ReadFile(registryCredentialsPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read registry credentials file: %s", err)
+		}
+		return string(registryCredentialsBytes), nil
+	}
+
+	return "", nil
+}
+
+// waitForOperator waits for the Deployments in the installer for the operator
+// version, or the latest version if empty, to be rolled out
+func waitForOperator(ctx context.Context, kubeConfig, version string, timeout time.Duration) error {
+	kubeCfg, err := kubernetes.NewConfig(kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := operator.ManifestDeployments(version)
+	if err != nil {
+		return fmt.Errorf("failed to determine operator deployments: %w", err)
+	}
+
+	deploymentClient, err := clients.NewDeploymentClient(kubeCfg)
+	if err != nil {
+		return fmt.Errorf("error creating deployment client: %s", err)
+	}
+
+	return rollout.WaitForDeployments(ctx, deploymentClient, deployments, rollout.DefaultInterval, timeout, os.Stderr)
+}
@@ -0,0 +1,453 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"gopkg.in/yaml.v2"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/restore"
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+const (
+	// installationConfigAPIVersion is the version of the installation config
+	// file schema understood by this version of jsctl
+	installationConfigAPIVersion = "jsctl.jetstack.io/v1alpha1"
+	installationConfigKind       = "InstallationConfig"
+)
+
+// installationConfig is the schema of the file passed to 'installations apply
+// --config'. It covers every option that can be set by the apply command's
+// flags, so that an installation can be reproduced from a file kept in source
+// control.
+type installationConfig struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+
+	// Tier is either blank, enterprise or enterprise-plus
+	Tier string `yaml:"tier,omitempty"`
+
+	Registry            registryConfig            `yaml:"registry,omitempty"`
+	CertManager         certManagerConfig         `yaml:"certManager,omitempty"`
+	CSIDriver           componentConfig           `yaml:"csiDriver,omitempty"`
+	CSIDriverSpiffe     replicatedComponentConfig `yaml:"csiDriverSpiffe,omitempty"`
+	IstioCSR            istioCSRConfig            `yaml:"istioCSR,omitempty"`
+	VenafiOauthHelper   componentConfig           `yaml:"venafiOauthHelper,omitempty"`
+	TrustManager        trustManagerConfig        `yaml:"trustManager,omitempty"`
+	ApproverPolicy      approverPolicyConfig      `yaml:"approverPolicy,omitempty"`
+	CertDiscoveryVenafi certDiscoveryVenafiConfig `yaml:"certDiscoveryVenafi,omitempty"`
+
+	// VenafiConnectionsFile is the path of a file of Venafi connections, in
+	// the same format as VenafiConnections
+	VenafiConnectionsFile string `yaml:"venafiConnectionsFile,omitempty"`
+	// VenafiConnections are Venafi connections keyed by name, these are
+	// combined with those in VenafiConnectionsFile
+	VenafiConnections map[string]*venafi.VenafiConnection `yaml:"venafiConnections,omitempty"`
+	VenafiIssuers     []venafiIssuerConfig                `yaml:"venafiIssuers,omitempty"`
+
+	// IssuersBackupFile is the path of a backup file containing issuers to be
+	// managed by the operator
+	IssuersBackupFile string `yaml:"issuersBackupFile,omitempty"`
+}
+
+type registryConfig struct {
+	// URL is the image registry used for the operator's components
+	URL                  string `yaml:"url,omitempty"`
+	CredentialsPath      string `yaml:"credentialsPath,omitempty"`
+	AutoFetchCredentials bool   `yaml:"autoFetchCredentials,omitempty"`
+}
+
+type componentConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type replicatedComponentConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Replicas int  `yaml:"replicas,omitempty"`
+}
+
+type certManagerConfig struct {
+	Version  string `yaml:"version,omitempty"`
+	Replicas int    `yaml:"replicas,omitempty"`
+	// WebhookReplicas is the replica count of the webhook, Replicas is used
+	// if it is zero
+	WebhookReplicas int `yaml:"webhookReplicas,omitempty"`
+}
+
+type istioCSRConfig struct {
+	replicatedComponentConfig `yaml:",inline"`
+	Issuer                    string `yaml:"issuer,omitempty"`
+	// IssuerKind and IssuerGroup identify the type of issuer, such as a
+	// ClusterIssuer or an external issuer, the defaults are Issuer and
+	// cert-manager.io
+	IssuerKind  string `yaml:"issuerKind,omitempty"`
+	IssuerGroup string `yaml:"issuerGroup,omitempty"`
+	// IstioNamespace is the namespace Istio is installed in
+	IstioNamespace string `yaml:"istioNamespace,omitempty"`
+}
+
+type trustManagerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// TrustNamespace is the namespace that trust-manager reads Bundle
+	// sources from. The Installation cannot configure it, so it is only used
+	// in the suggested commands for creating Bundle sources.
+	TrustNamespace string             `yaml:"trustNamespace,omitempty"`
+	Bundle         *trustBundleConfig `yaml:"bundle,omitempty"`
+}
+
+type trustBundleConfig struct {
+	Name string `yaml:"name"`
+	// Sources are in the 'secret:name:key', 'configmap:name:key' or
+	// 'default-cas' form, the default CAs are used if empty
+	Sources []string `yaml:"sources,omitempty"`
+}
+
+type approverPolicyConfig struct {
+	// GeneratePolicies generates a permissive CertificateRequestPolicy for
+	// each issuer, so that existing issuers keep working once approver-policy
+	// has replaced cert-manager's default approver
+	GeneratePolicies bool `yaml:"generatePolicies,omitempty"`
+}
+
+type certDiscoveryVenafiConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Connection is the name of the Venafi connection to use
+	Connection string `yaml:"connection,omitempty"`
+	// PolicyFolder is the TPP policy folder certificates are uploaded to,
+	// defaulting to the zone of the connection
+	PolicyFolder string `yaml:"policyFolder,omitempty"`
+}
+
+type venafiIssuerConfig struct {
+	// Type is the type of Venafi server, either tpp or vaas
+	Type string `yaml:"type"`
+	// Connection is the name of the Venafi connection to use
+	Connection string `yaml:"connection"`
+	Name       string `yaml:"name"`
+	// Namespace is the namespace to create the issuer in, a cluster scoped
+	// issuer is created if blank
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// String returns the issuer in the 'type:connection:name:[namespace]' form
+// used by the --experimental-venafi-issuers flag
+func (v venafiIssuerConfig) String() string {
+	parts := []string{v.Type, v.Connection, v.Name}
+	if v.Namespace != "" {
+		parts = append(parts, v.Namespace)
+	}
+	return strings.Join(parts, ":")
+}
+
+// parseVenafiIssuerConfig parses an issuer in the form used by the
+// --experimental-venafi-issuers flag
+func parseVenafiIssuerConfig(issuer string) (venafiIssuerConfig, error) {
+	parts := strings.Split(issuer, ":")
+	switch len(parts) {
+	case 3:
+		return venafiIssuerConfig{Type: parts[0], Connection: parts[1], Name: parts[2]}, nil
+	case 4:
+		return venafiIssuerConfig{Type: parts[0], Connection: parts[1], Name: parts[2], Namespace: parts[3]}, nil
+	}
+	return venafiIssuerConfig{}, fmt.Errorf("invalid issuer %q, expected 'type:connection:name:[namespace]'", issuer)
+}
+
+func defaultInstallationConfig() installationConfig {
+	return installationConfig{
+		APIVersion:      installationConfigAPIVersion,
+		Kind:            installationConfigKind,
+		CertManager:     certManagerConfig{Replicas: 2},
+		CSIDriverSpiffe: replicatedComponentConfig{Replicas: 2},
+		IstioCSR:        istioCSRConfig{replicatedComponentConfig: replicatedComponentConfig{Replicas: 2}},
+		TrustManager:    trustManagerConfig{TrustNamespace: operator.DefaultTrustNamespace},
+	}
+}
+
+// loadInstallationConfig reads an installationConfig from a YAML file, any
+// fields not set in the file keep the values in defaults
+func loadInstallationConfig(path string, defaults installationConfig) (installationConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return installationConfig{}, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var header struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return installationConfig{}, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	if header.APIVersion != installationConfigAPIVersion || header.Kind != installationConfigKind {
+		return installationConfig{}, fmt.Errorf("unsupported config file %s: expected apiVersion %q and kind %q, got %q and %q", path, installationConfigAPIVersion, installationConfigKind, header.APIVersion, header.Kind)
+	}
+
+	cfg := defaults
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return installationConfig{}, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// mergeableConfig returns the config with the replica counts cleared unless
+// they were set, either by a flag or in the config file, fileCfg. The
+// cert-manager replica count may also be imported from the cluster. When
+// merging into an existing Installation the default replica counts would
+// otherwise replace customised ones.
+func (c installationConfig) mergeableConfig(fileCfg installationConfig, changed func(name string) bool, imported bool) installationConfig {
+	if !changed("cert-manager-replicas") && fileCfg.CertManager.Replicas == 0 && !imported {
+		c.CertManager.Replicas = 0
+	}
+	if !changed("csi-driver-spiffe-replicas") && fileCfg.CSIDriverSpiffe.Replicas == 0 {
+		c.CSIDriverSpiffe.Replicas = 0
+	}
+	if !changed("istio-csr-replicas") && fileCfg.IstioCSR.Replicas == 0 {
+		c.IstioCSR.Replicas = 0
+	}
+	return c
+}
+
+// redacted returns the config with the inline credentials of its Venafi
+// connections hidden, so that it can be printed with --print-config
+func (c installationConfig) redacted() installationConfig {
+	if len(c.VenafiConnections) == 0 {
+		return c
+	}
+	vcs := make(map[string]*venafi.VenafiConnection, len(c.VenafiConnections))
+	for name, vc := range c.VenafiConnections {
+		vcs[name] = vc.Redacted()
+	}
+	c.VenafiConnections = vcs
+	return c
+}
+
+// validate checks the config for conflicting or incomplete options
+func (c installationConfig) validate() error {
+	if c.CertDiscoveryVenafi.Enabled && c.CertDiscoveryVenafi.Connection == "" {
+		return errors.New("cert-discovery-venafi is enabled, but a Venafi connection was not provided, please provide via the --experimental-cert-discovery-venafi-connection flag or certDiscoveryVenafi.connection")
+	}
+
+	if c.Registry.CredentialsPath != "" && c.Registry.AutoFetchCredentials {
+		return errors.New("cannot specify both --registry-credentials-path and --auto-registry-credentials")
+	}
+
+	if c.IstioCSR.Enabled && c.IstioCSR.Issuer == "" {
+		return errors.New("you must specify an issuer for istio-csr to use via the --istio-csr-issuer flag or istioCSR.issuer")
+	}
+
+	if c.IstioCSR.IssuerGroup == "" && c.IstioCSR.IssuerKind != "" && c.IstioCSR.IssuerKind != cmapi.IssuerKind && c.IstioCSR.IssuerKind != cmapi.ClusterIssuerKind {
+		return fmt.Errorf("istio-csr issuer kind %q is not a cert-manager issuer, you must specify its API group via the --istio-csr-issuer-group flag or istioCSR.issuerGroup", c.IstioCSR.IssuerKind)
+	}
+
+	if c.Tier != "" && c.Tier != tierEnterprise && c.Tier != tierEnterprisePlus {
+		return fmt.Errorf("invalid tier %q, must be either %q, %q or blank", c.Tier, tierEnterprise, tierEnterprisePlus)
+	}
+
+	if bundle := c.TrustManager.Bundle; bundle != nil {
+		if !c.TrustManager.Enabled {
+			return errors.New("a trust-manager Bundle was requested, but trust-manager is not enabled, please enable it via the --trust-manager flag or trustManager.enabled")
+		}
+		if bundle.Name == "" {
+			return errors.New("you must specify a name for the trust-manager Bundle via the --trust-manager-bundle flag or trustManager.bundle.name")
+		}
+		if _, err := bundle.sources(); err != nil {
+			return err
+		}
+	}
+
+	if c.IssuersBackupFile != "" {
+		if _, err := os.Stat(c.IssuersBackupFile); os.IsNotExist(err) {
+			return fmt.Errorf("backup file %q does not exist", c.IssuersBackupFile)
+		}
+	}
+
+	return nil
+}
+
+// sources parses the sources of the Bundle, defaulting to the default CAs
+func (b trustBundleConfig) sources() ([]operator.TrustBundleSource, error) {
+	if len(b.Sources) == 0 {
+		return []operator.TrustBundleSource{{Kind: operator.TrustBundleSourceDefaultCAs}}, nil
+	}
+
+	sources := make([]operator.TrustBundleSource, len(b.Sources))
+	for i, source := range b.Sources {
+		var err error
+		sources[i], err = operator.ParseTrustBundleSource(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// venafiConnections returns the Venafi connections from the connections file
+// combined with those set in the config
+func (c installationConfig) venafiConnections() (map[string]*venafi.VenafiConnection, error) {
+	vcs, err := parseVenafiConnections(c.VenafiConnectionsFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.VenafiConnections) == 0 {
+		return vcs, nil
+	}
+
+	if vcs == nil {
+		vcs = make(map[string]*venafi.VenafiConnection)
+	}
+	for name, vc := range c.VenafiConnections {
+		if _, ok := vcs[name]; ok {
+			return nil, fmt.Errorf("Venafi connection %q is defined in both the config and %s", name, c.VenafiConnectionsFile)
+		}
+		vcs[name] = vc
+	}
+
+	return vcs, nil
+}
+
+// validateVenafiConnections checks every Venafi connection, including those
+// not used by an issuer, once their credentials have been resolved. They are
+// checked in name order so that errors are reported consistently.
+func validateVenafiConnections(vcs map[string]*venafi.VenafiConnection) error {
+	names := make([]string, 0, len(vcs))
+	for name := range vcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vc := vcs[name]
+		if vc == nil {
+			return fmt.Errorf("Venafi connection %q is invalid: no settings given", name)
+		}
+		if err := vc.Validate(); err != nil {
+			return fmt.Errorf("Venafi connection %q is invalid: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// venafiIssuerStrings returns the Venafi issuers in the form accepted by
+// venafi.ParseIssuerConfig
+func (c installationConfig) venafiIssuerStrings() []string {
+	issuers := make([]string, len(c.VenafiIssuers))
+	for i, issuer := range c.VenafiIssuers {
+		issuers[i] = issuer.String()
+	}
+	return issuers
+}
+
+// reportUnmanageableIssuers writes the restored issuers that cannot be managed
+// by the operator to out
+func reportUnmanageableIssuers(issuers *restore.RestoredIssuers, out io.Writer) {
+	if len(issuers.Missed) != 0 {
+		fmt.Fprintf(out, "The following issuers cannot be managed by the operator and must be restored manually: %s\n", strings.Join(issuers.Missed, ", "))
+	}
+	if len(issuers.NeedsConversion) != 0 {
+		fmt.Fprintf(out, "The following issuers need to be converted to cert-manager v1 resources: %s\n", strings.Join(issuers.NeedsConversion, ", "))
+		fmt.Fprintf(out, "This can be done using cmctl convert, see here for more information: https://cert-manager.io/docs/reference/cmctl/#convert\n")
+	}
+}
+
+// installationOptions converts the config into the options used to generate
+// the Installation. Issuers in the backup file that cannot be managed by the
+// operator are reported to out. Venafi credentials read from Kubernetes
+// Secrets use getSecret, which may be nil for commands that do not connect to
+// a cluster.
+func (c installationConfig) installationOptions(ctx context.Context, registryCredentials string, getSecret venafi.SecretGetter, out io.Writer) (operator.ApplyInstallationYAMLOptions, error) {
+	issuers := &restore.RestoredIssuers{}
+	if c.IssuersBackupFile != "" {
+		var err error
+		issuers, err = restore.ExtractOperatorManageableIssuersFromBackupFile(c.IssuersBackupFile)
+		if err != nil {
+			return operator.ApplyInstallationYAMLOptions{}, fmt.Errorf("error extracting issuers from backup file: %w", err)
+		}
+	}
+	reportUnmanageableIssuers(issuers, out)
+
+	options := operator.ApplyInstallationYAMLOptions{
+		ImageRegistry:           c.Registry.URL,
+		RegistryCredentialsPath: c.Registry.CredentialsPath,
+		RegistryCredentials:     registryCredentials,
+
+		// Cert Manager configuration
+		CertManagerReplicas:        c.CertManager.Replicas,
+		CertManagerWebhookReplicas: c.CertManager.WebhookReplicas,
+		CertManagerVersion:         c.CertManager.Version,
+
+		// CSI Driver configuration
+		InstallCSIDriver:         c.CSIDriver.Enabled,
+		InstallSpiffeCSIDriver:   c.CSIDriverSpiffe.Enabled,
+		InstallVenafiOauthHelper: c.VenafiOauthHelper.Enabled,
+		SpiffeCSIDriverReplicas:  c.CSIDriverSpiffe.Replicas,
+
+		// Istio CSR configuration
+		InstallIstioCSR:        c.IstioCSR.Enabled,
+		IstioCSRIssuer:         c.IstioCSR.Issuer,
+		IstioCSRIssuerKind:     c.IstioCSR.IssuerKind,
+		IstioCSRIssuerGroup:    c.IstioCSR.IssuerGroup,
+		IstioCSRIstioNamespace: c.IstioCSR.IstioNamespace,
+		IstioCSRReplicas:       c.IstioCSR.Replicas,
+
+		// Approver Policy configuration
+		InstallApproverPolicyEnterprise: false,
+		GenerateApproverPolicies:        c.ApproverPolicy.GeneratePolicies,
+
+		// trust-manager configuration
+		InstallTrustManager: c.TrustManager.Enabled,
+		TrustNamespace:      c.TrustManager.TrustNamespace,
+
+		// Restored Issuers
+		ImportedCertManagerIssuers:        issuers.CertManagerIssuers,
+		ImportedCertManagerClusterIssuers: issuers.CertManagerClusterIssuers,
+		ImportedVenafiIssuers:             issuers.VenafiIssuers,
+		ImportedVenafiClusterIssuers:      issuers.VenafiClusterIssuers,
+	}
+
+	if c.Tier == tierEnterprisePlus {
+		options.InstallApproverPolicyEnterprise = true
+	}
+
+	if bundle := c.TrustManager.Bundle; bundle != nil {
+		sources, err := bundle.sources()
+		if err != nil {
+			return operator.ApplyInstallationYAMLOptions{}, err
+		}
+		options.TrustBundle = &operator.TrustBundle{Name: bundle.Name, Sources: sources}
+	}
+
+	vcs, err := c.venafiConnections()
+	if err != nil {
+		return operator.ApplyInstallationYAMLOptions{}, fmt.Errorf("error parsing Venafi connection config: %w", err)
+	}
+	if err := venafi.ResolveCredentials(ctx, vcs, getSecret); err != nil {
+		return operator.ApplyInstallationYAMLOptions{}, err
+	}
+	if err := validateVenafiConnections(vcs); err != nil {
+		return operator.ApplyInstallationYAMLOptions{}, err
+	}
+
+	vis, err := venafi.ParseIssuerConfig(c.venafiIssuerStrings(), vcs, c.VenafiOauthHelper.Enabled)
+	if err != nil {
+		return operator.ApplyInstallationYAMLOptions{}, fmt.Errorf("error parsing Venafi issuer config: %w", err)
+	}
+	options.VenafiIssuers = vis
+
+	cdv, err := venafi.ParseCertDiscoveryVenafiConfig(c.CertDiscoveryVenafi.Connection, c.CertDiscoveryVenafi.PolicyFolder, vcs, c.CertDiscoveryVenafi.Enabled)
+	if err != nil {
+		return operator.ApplyInstallationYAMLOptions{}, fmt.Errorf("error parsing cert-discovery-venafi config: %w", err)
+	}
+	options.CertDiscoveryVenafi = cdv
+
+	return options, nil
+}
@@ -0,0 +1,354 @@
+package operator
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+func Test_loadInstallationConfig(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    func() installationConfig
+		wantErr bool
+	}{
+		"config with only a header should keep defaults": {
+			content: `apiVersion: jsctl.jetstack.io/v1alpha1
+kind: InstallationConfig
+`,
+			want: defaultInstallationConfig,
+		},
+		"config should override defaults": {
+			content: `apiVersion: jsctl.jetstack.io/v1alpha1
+kind: InstallationConfig
+tier: enterprise-plus
+registry:
+  url: registry.example.com
+certManager:
+  replicas: 3
+  webhookReplicas: 1
+istioCSR:
+  enabled: true
+  issuer: istio-ca
+venafiIssuers:
+- type: tpp
+  connection: tpp
+  name: venafi
+  namespace: foo
+`,
+			want: func() installationConfig {
+				cfg := defaultInstallationConfig()
+				cfg.Tier = tierEnterprisePlus
+				cfg.Registry.URL = "registry.example.com"
+				cfg.CertManager.Replicas = 3
+				cfg.CertManager.WebhookReplicas = 1
+				cfg.IstioCSR.Enabled = true
+				cfg.IstioCSR.Issuer = "istio-ca"
+				cfg.VenafiIssuers = []venafiIssuerConfig{{Type: "tpp", Connection: "tpp", Name: "venafi", Namespace: "foo"}}
+				return cfg
+			},
+		},
+		"config with an unsupported version should produce an error": {
+			content: `apiVersion: jsctl.jetstack.io/v1
+kind: InstallationConfig
+`,
+			wantErr: true,
+		},
+		"config without a header should produce an error": {
+			content: "tier: enterprise\n",
+			wantErr: true,
+		},
+		"config with unknown fields should produce an error": {
+			content: `apiVersion: jsctl.jetstack.io/v1alpha1
+kind: InstallationConfig
+foo: bar
+`,
+			wantErr: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "installation.yaml")
+			if err := os.WriteFile(path, []byte(scenario.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadInstallationConfig(path, defaultInstallationConfig())
+			if (err != nil) != scenario.wantErr {
+				t.Fatalf("loadInstallationConfig() error = %v, wantErr %v", err, scenario.wantErr)
+			}
+			if scenario.wantErr {
+				return
+			}
+			if want := scenario.want(); !reflect.DeepEqual(got, want) {
+				t.Errorf("loadInstallationConfig() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func Test_loadInstallationConfig_printedConfig(t *testing.T) {
+	cfg := defaultInstallationConfig()
+	cfg.CSIDriver.Enabled = true
+	cfg.CertDiscoveryVenafi = certDiscoveryVenafiConfig{Enabled: true, Connection: "tpp"}
+	cfg.VenafiConnections = map[string]*venafi.VenafiConnection{
+		"tpp": {URL: "https://tpp.example.com", AccessToken: "token"},
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "installation.yaml")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadInstallationConfig(path, defaultInstallationConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("loadInstallationConfig() = %+v, want %+v", got, cfg)
+	}
+}
+
+func Test_installationConfig_validate(t *testing.T) {
+	tests := map[string]struct {
+		modify  func(*installationConfig)
+		wantErr bool
+	}{
+		"default config should be valid": {
+			modify: func(*installationConfig) {},
+		},
+		"cert-discovery-venafi without a connection should be invalid": {
+			modify: func(c *installationConfig) {
+				c.CertDiscoveryVenafi.Enabled = true
+			},
+			wantErr: true,
+		},
+		"both registry credentials options should be invalid": {
+			modify: func(c *installationConfig) {
+				c.Registry.CredentialsPath = "key.json"
+				c.Registry.AutoFetchCredentials = true
+			},
+			wantErr: true,
+		},
+		"istio-csr without an issuer should be invalid": {
+			modify: func(c *installationConfig) {
+				c.IstioCSR.Enabled = true
+			},
+			wantErr: true,
+		},
+		"istio-csr external issuer without a group should be invalid": {
+			modify: func(c *installationConfig) {
+				c.IstioCSR.Enabled = true
+				c.IstioCSR.Issuer = "google-cas"
+				c.IstioCSR.IssuerKind = "GoogleCASClusterIssuer"
+			},
+			wantErr: true,
+		},
+		"istio-csr cluster issuer should be valid": {
+			modify: func(c *installationConfig) {
+				c.IstioCSR.Enabled = true
+				c.IstioCSR.Issuer = "istio-ca"
+				c.IstioCSR.IssuerKind = "ClusterIssuer"
+			},
+		},
+		"unknown tier should be invalid": {
+			modify: func(c *installationConfig) {
+				c.Tier = "free"
+			},
+			wantErr: true,
+		},
+		"trust-manager bundle without trust-manager should be invalid": {
+			modify: func(c *installationConfig) {
+				c.TrustManager.Bundle = &trustBundleConfig{Name: "ca-bundle"}
+			},
+			wantErr: true,
+		},
+		"trust-manager bundle with an invalid source should be invalid": {
+			modify: func(c *installationConfig) {
+				c.TrustManager.Enabled = true
+				c.TrustManager.Bundle = &trustBundleConfig{Name: "ca-bundle", Sources: []string{"secret:ca"}}
+			},
+			wantErr: true,
+		},
+		"trust-manager bundle with sources should be valid": {
+			modify: func(c *installationConfig) {
+				c.TrustManager.Enabled = true
+				c.TrustManager.Bundle = &trustBundleConfig{Name: "ca-bundle", Sources: []string{"secret:ca:ca.crt", "default-cas"}}
+			},
+		},
+		"missing backup file should be invalid": {
+			modify: func(c *installationConfig) {
+				c.IssuersBackupFile = filepath.Join(t.TempDir(), "missing.yaml")
+			},
+			wantErr: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultInstallationConfig()
+			scenario.modify(&cfg)
+
+			err := cfg.validate()
+			if (err != nil) != scenario.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, scenario.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseVenafiIssuerConfig(t *testing.T) {
+	for _, issuer := range []string{"tpp:conn:name", "tpp:conn:name:namespace"} {
+		parsed, err := parseVenafiIssuerConfig(issuer)
+		if err != nil {
+			t.Fatalf("parseVenafiIssuerConfig(%q) unexpected error: %v", issuer, err)
+		}
+		if parsed.String() != issuer {
+			t.Errorf("parseVenafiIssuerConfig(%q).String() = %q", issuer, parsed.String())
+		}
+	}
+
+	if _, err := parseVenafiIssuerConfig("tpp:name"); err == nil {
+		t.Errorf("parseVenafiIssuerConfig() expected error for invalid issuer")
+	}
+}
+
+func Test_installationConfig_redacted(t *testing.T) {
+	tokenFrom := &venafi.CredentialSource{Env: "TPP_TOKEN"}
+	cfg := defaultInstallationConfig()
+	cfg.VenafiConnections = map[string]*venafi.VenafiConnection{
+		"inline": {URL: "https://tpp.example.com", AccessToken: "token", Username: "user", Password: "password"},
+		"cloud":  {APIKey: "key"},
+		"source": {URL: "https://tpp.example.com", AccessTokenFrom: tokenFrom},
+	}
+
+	got := cfg.redacted()
+	want := map[string]*venafi.VenafiConnection{
+		"inline": {URL: "https://tpp.example.com", AccessToken: "<redacted>", Username: "user", Password: "<redacted>"},
+		"cloud":  {APIKey: "<redacted>"},
+		"source": {URL: "https://tpp.example.com", AccessTokenFrom: tokenFrom},
+	}
+	if !reflect.DeepEqual(got.VenafiConnections, want) {
+		t.Errorf("redacted() = %v, want %v", got.VenafiConnections, want)
+	}
+	if cfg.VenafiConnections["inline"].AccessToken != "token" {
+		t.Errorf("redacted() modified the original config")
+	}
+}
+
+func Test_installationConfig_venafiConnections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.yaml")
+	err := os.WriteFile(path, []byte("from-file:\n  url: https://file.example.com\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultInstallationConfig()
+	cfg.VenafiConnectionsFile = path
+	cfg.VenafiConnections = map[string]*venafi.VenafiConnection{
+		"inline": {URL: "https://inline.example.com"},
+	}
+
+	vcs, err := cfg.venafiConnections()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*venafi.VenafiConnection{
+		"from-file": {URL: "https://file.example.com"},
+		"inline":    {URL: "https://inline.example.com"},
+	}
+	if !reflect.DeepEqual(vcs, want) {
+		t.Errorf("venafiConnections() = %v, want %v", vcs, want)
+	}
+
+	cfg.VenafiConnections["from-file"] = &venafi.VenafiConnection{}
+	if _, err := cfg.venafiConnections(); err == nil {
+		t.Errorf("venafiConnections() expected error for duplicate connection")
+	}
+}
+
+func Test_installationConfig_mergeableConfig(t *testing.T) {
+	changed := func(set ...string) func(string) bool {
+		return func(name string) bool {
+			for _, s := range set {
+				if s == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	tests := map[string]struct {
+		fileCfg              installationConfig
+		changed              func(string) bool
+		imported             bool
+		wantReplicas         int
+		wantIstioCSRReplicas int
+	}{
+		"default replicas should be cleared": {
+			changed:      changed(),
+			wantReplicas: 0,
+		},
+		"replicas set by flag should be kept": {
+			changed:              changed("cert-manager-replicas", "istio-csr-replicas"),
+			wantReplicas:         2,
+			wantIstioCSRReplicas: 2,
+		},
+		"replicas set in the config file should be kept": {
+			fileCfg: installationConfig{
+				CertManager: certManagerConfig{Replicas: 2},
+				IstioCSR:    istioCSRConfig{replicatedComponentConfig: replicatedComponentConfig{Replicas: 2}},
+			},
+			changed:              changed(),
+			wantReplicas:         2,
+			wantIstioCSRReplicas: 2,
+		},
+		"imported replicas should be kept": {
+			changed:      changed(),
+			imported:     true,
+			wantReplicas: 2,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := defaultInstallationConfig().mergeableConfig(test.fileCfg, test.changed, test.imported)
+			if got.CertManager.Replicas != test.wantReplicas {
+				t.Errorf("mergeableConfig() replicas = %d, want %d", got.CertManager.Replicas, test.wantReplicas)
+			}
+			if got.IstioCSR.Replicas != test.wantIstioCSRReplicas {
+				t.Errorf("mergeableConfig() istio-csr replicas = %d, want %d", got.IstioCSR.Replicas, test.wantIstioCSRReplicas)
+			}
+		})
+	}
+}
+
+func Test_installationConfig_installationOptions_invalidVenafiConnection(t *testing.T) {
+	cfg := defaultInstallationConfig()
+	cfg.VenafiConnections = map[string]*venafi.VenafiConnection{
+		"valid":   {URL: "https://tpp.example.com", Zone: "zone", AccessToken: "token"},
+		"no-zone": {URL: "https://tpp.example.com", AccessToken: "token"},
+	}
+
+	_, err := cfg.installationOptions(context.Background(), "", nil, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `"no-zone"`) {
+		t.Errorf("installationOptions() error = %v, want error for connection no-zone", err)
+	}
+
+	delete(cfg.VenafiConnections, "no-zone")
+	if _, err := cfg.installationOptions(context.Background(), "", nil, io.Discard); err != nil {
+		t.Errorf("installationOptions() unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,592 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/ownerrefs"
+	"github.com/jetstack/jsctl/internal/kubernetes/rollout"
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/prompt"
+	"github.com/jetstack/jsctl/internal/registry"
+)
+
+func Remove(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		dryRun      bool
+		keepCRDs    bool
+		keepSecrets bool
+		journalPath string
+		version     string
+		timeout     time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Removes the operator and its Installation from the current Kubernetes context",
+		Long: `Removes the operator and its Installation from the current Kubernetes context
+
+The Installation is deleted first and the command waits for the operator to remove the components it manages. The resources from the operator's installer manifest are then deleted in the reverse of the order they were applied in. The jetstack-secure namespace is left in place.
+
+Use --keep-crds to retain the cert-manager CRDs, and with them any cert-manager custom resources in the cluster. The Installation's owner references are removed from the CRDs in the cert-manager.io and acme.cert-manager.io groups before it is deleted, and the command fails if any of them are removed regardless. The CRDs of other components, such as trust-manager and approver-policy, are not released and may be removed with the Installation.
+
+The operator-managed components and the CRDs owned by the Installation are listed before asking for confirmation. Deleting the cert-manager CRDs also deletes every Certificate, Issuer and other cert-manager resource in the cluster.
+
+Use --keep-secrets to ensure that Secrets containing issued certificates are not garbage collected when their Certificates are deleted along with the cert-manager CRDs. Certificate owner references are removed from Secrets just before the Installation is deleted, and recorded in a journal file (see --journal) so that they can be re-attached with 'jsctl experimental clusters cleanup secrets restore-certificate-owner-refs' once cert-manager has been re-installed. It does not protect Secrets deleted in other ways, such as those in namespaces that are later deleted. With --keep-crds, Certificates are not deleted, so Secrets are left unchanged.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			if version == "" {
+				runningVersion, found, err := runningOperatorVersion(ctx, kubeCfg)
+				if err != nil {
+					return err
+				}
+				if found {
+					version = runningVersion
+				}
+			}
+
+			resources, err := operator.RemovalOrder(version)
+			switch {
+			case errors.Is(err, operator.ErrNoManifest):
+				fmt.Fprintf(os.Stderr, "Note: operator version %s is not known to this version of jsctl, removing the resources of the latest version instead\n", version)
+				resources, err = operator.RemovalOrder("")
+				if err != nil {
+					return fmt.Errorf("failed to determine operator resources: %w", err)
+				}
+			case err != nil:
+				return fmt.Errorf("failed to determine operator resources: %w", err)
+			}
+			resources = append(resources, operator.ManifestResource{
+				Resource:  corev1.SchemeGroupVersion.WithResource("secrets"),
+				Kind:      "Secret",
+				Namespace: "jetstack-secure",
+				Name:      registry.ImagePullSecretName,
+			})
+
+			installationClient, err := clients.NewInstallationClient(kubeCfg)
+			if err != nil {
+				return err
+			}
+
+			_, err = installationClient.Get(ctx, operator.InstallationName)
+			installationExists := true
+			switch {
+			case errors.Is(err, clients.ErrNoInstallation):
+				installationExists = false
+			case err != nil:
+				return err
+			}
+
+			if installationExists {
+				fmt.Fprintf(os.Stdout, "Installation %s\n", operator.InstallationName)
+
+				// the components managed by the operator, and the CRDs
+				// owned by the Installation, are removed with it
+				deployments, err := installationDeployments(ctx, kubeCfg, version)
+				if err != nil {
+					return err
+				}
+				for _, deployment := range deployments {
+					fmt.Fprintf(os.Stdout, "%s\n", deployment)
+				}
+
+				crds, err := installationCRDs(ctx, kubeCfg, keepCRDs)
+				if err != nil {
+					return err
+				}
+				removesCertManagerCRDs := false
+				for _, crd := range crds {
+					fmt.Fprintf(os.Stdout, "CustomResourceDefinition %s\n", crd.Name)
+					removesCertManagerCRDs = removesCertManagerCRDs || isCertManagerCRDGroup(crd.Spec.Group)
+				}
+				if removesCertManagerCRDs {
+					fmt.Fprintf(os.Stderr, "Warning: deleting the cert-manager CRDs also deletes every Certificate, Issuer, ClusterIssuer and other cert-manager resource in the cluster, use --keep-crds to keep them\n")
+
+					secretsClient, err := newSecretsClient(kubeCfg)
+					if err != nil {
+						return err
+					}
+					owned, err := certificateOwnedSecrets(ctx, secretsClient)
+					if err != nil {
+						return err
+					}
+					for _, secret := range owned {
+						if keepSecrets {
+							fmt.Fprintf(os.Stderr, "Secret %s/%s will be kept, its Certificate owner references will be removed and recorded in %s\n", secret.Namespace, secret.Name, journalPath)
+						} else {
+							fmt.Fprintf(os.Stderr, "Warning: Secret %s/%s will be deleted with its Certificate, use --keep-secrets to keep it\n", secret.Namespace, secret.Name)
+						}
+					}
+				}
+			}
+			for _, resource := range resources {
+				fmt.Fprintf(os.Stdout, "%s\n", resource)
+			}
+
+			if dryRun {
+				fmt.Fprintf(os.Stderr, "Dry run, no action taken\n")
+				return nil
+			}
+
+			ok, err := prompt.YesNo(os.Stdin, os.Stderr, "Would you like to remove the operator and delete these resources?")
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintf(os.Stderr, "No action taken\n")
+				return nil
+			}
+
+			if installationExists {
+				// Certificates may have been issued since the listing, and
+				// cert-manager keeps running until the Installation is
+				// deleted, so owner references are removed as late as
+				// possible
+				if keepSecrets && !keepCRDs {
+					secretsClient, err := newSecretsClient(kubeCfg)
+					if err != nil {
+						return err
+					}
+					if err := releaseCertificateOwnedSecrets(ctx, secretsClient, journalPath); err != nil {
+						return err
+					}
+				}
+
+				var keptCRDs []string
+				if keepCRDs {
+					keptCRDs, err = releaseCertManagerCRDs(ctx, kubeCfg)
+					if err != nil {
+						return err
+					}
+				}
+
+				err = installationClient.Delete(ctx, operator.InstallationName)
+				if err != nil && !errors.Is(err, clients.ErrNoInstallation) {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Installation %s deleted, waiting for the operator to remove its components\n", operator.InstallationName)
+
+				if err := waitForTeardown(ctx, kubeCfg, installationClient, version, timeout); err != nil {
+					return err
+				}
+
+				if err := checkCRDsKept(ctx, kubeCfg, keptCRDs); err != nil {
+					return err
+				}
+			}
+
+			return deleteManifestResources(ctx, kubeCfg, resources)
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.BoolVar(&dryRun, "dry-run", false, "If set, the resources that would be deleted are listed but not deleted")
+	flags.BoolVar(&keepCRDs, "keep-crds", false, "If set, the cert-manager CRDs are not removed along with the Installation")
+	flags.BoolVar(&keepSecrets, "keep-secrets", false, "If set, Certificate owner references are removed from Secrets so that they are not garbage collected with their Certificates")
+	flags.StringVar(&journalPath, "journal", ownerrefs.DefaultJournalPath, "Path of the journal file to record owner references removed by --keep-secrets in, entries are appended if the file exists")
+	flags.StringVar(&version, "version", "", "Specifies the version of the operator to remove, defaults to the running version")
+	flags.DurationVar(&timeout, "timeout", 5*time.Minute, "How long to wait for the operator to remove the components it manages")
+
+	return cmd
+}
+
+func newSecretsClient(kubeCfg *rest.Config) (clients.Generic[*corev1.Secret, *corev1.SecretList], error) {
+	secretsClient, err := clients.NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&clients.GenericClientOptions{
+			RestConfig: kubeCfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating secrets client: %s", err)
+	}
+
+	return secretsClient, nil
+}
+
+// certificateOwnedSecrets returns the secrets with Certificate owner
+// references, which are garbage collected when their Certificate is deleted
+// along with the cert-manager CRDs
+func certificateOwnedSecrets(ctx context.Context, secretsClient clients.Generic[*corev1.Secret, *corev1.SecretList]) ([]corev1.Secret, error) {
+	var secrets corev1.SecretList
+	err := secretsClient.List(ctx, &clients.GenericRequestOptions{}, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets: %s", err)
+	}
+
+	var owned []corev1.Secret
+	for _, secret := range secrets.Items {
+		if _, removed := ownerrefs.WithoutCertificateOwnerRefs(&secret); len(removed) > 0 {
+			owned = append(owned, secret)
+		}
+	}
+
+	return owned, nil
+}
+
+// releaseCertificateOwnedSecrets removes the Certificate owner references from
+// secrets so that they are not garbage collected with their Certificates. The
+// removed owner references are recorded in the journal at journalPath before
+// any secret is patched, so that they can be restored with 'jsctl
+// experimental clusters cleanup secrets restore-certificate-owner-refs'.
+func releaseCertificateOwnedSecrets(ctx context.Context, secretsClient clients.Generic[*corev1.Secret, *corev1.SecretList], journalPath string) error {
+	secrets, err := certificateOwnedSecrets(ctx, secretsClient)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	var journal ownerrefs.Journal
+	patches := make([][]byte, len(secrets))
+	for i := range secrets {
+		newSecret, removed := ownerrefs.WithoutCertificateOwnerRefs(&secrets[i])
+		journal.Add(&secrets[i], removed)
+
+		patches[i], err = ownerrefs.SecretPatch(&secrets[i], newSecret)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := ownerrefs.AppendJournal(journalPath, journal); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Removed owner references recorded in %s\n", journalPath)
+
+	for i, secret := range secrets {
+		err := secretsClient.Patch(ctx, &clients.GenericRequestOptions{Name: secret.Name, Namespace: secret.Namespace}, patches[i])
+		if err != nil {
+			return fmt.Errorf("error patching secret %s/%s: %s", secret.Namespace, secret.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Secret %s/%s will be kept\n", secret.Namespace, secret.Name)
+	}
+
+	return nil
+}
+
+// isCertManagerCRDGroup returns true for the API groups of cert-manager's own
+// CRDs, rather than those of other cert-manager ecosystem components
+func isCertManagerCRDGroup(group string) bool {
+	return group == "cert-manager.io" || group == "acme.cert-manager.io"
+}
+
+// releaseCertManagerCRDs removes owner references to the Installation from
+// the cert-manager CRDs, so that they are not garbage collected when the
+// Installation is deleted. It returns the names of the cert-manager CRDs in
+// the cluster, which are expected to remain once the Installation is deleted.
+func releaseCertManagerCRDs(ctx context.Context, kubeCfg *rest.Config) ([]string, error) {
+	crdClient, err := clients.NewCRDClient(kubeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CRD client: %s", err)
+	}
+
+	var crds apiextensionsv1.CustomResourceDefinitionList
+	err = crdClient.List(ctx, &clients.GenericRequestOptions{}, &crds)
+	if err != nil {
+		return nil, fmt.Errorf("error listing CRDs: %s", err)
+	}
+
+	var kept []string
+	for _, crd := range crds.Items {
+		if !isCertManagerCRDGroup(crd.Spec.Group) {
+			continue
+		}
+		kept = append(kept, crd.Name)
+
+		refs, ok := withoutInstallationOwnerRefs(crd.OwnerReferences)
+		if !ok {
+			continue
+		}
+
+		released := crd.DeepCopy()
+		released.OwnerReferences = refs
+		patch, err := crdPatch(&crd, released)
+		if err != nil {
+			return nil, err
+		}
+		err = crdClient.Patch(ctx, &clients.GenericRequestOptions{Name: crd.Name}, patch)
+		if err != nil {
+			return nil, fmt.Errorf("error removing installation owner reference from CRD %s: %w", crd.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "CRD %s will be kept\n", crd.Name)
+	}
+
+	return kept, nil
+}
+
+// checkCRDsKept returns an error if any of the named CRDs have been deleted,
+// or are being deleted, so that --keep-crds does not silently lose the
+// cert-manager custom resources in the cluster
+func checkCRDsKept(ctx context.Context, kubeCfg *rest.Config, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	crdClient, err := clients.NewCRDClient(kubeCfg)
+	if err != nil {
+		return fmt.Errorf("error creating CRD client: %s", err)
+	}
+
+	var crds apiextensionsv1.CustomResourceDefinitionList
+	err = crdClient.List(ctx, &clients.GenericRequestOptions{}, &crds)
+	if err != nil {
+		return fmt.Errorf("error listing CRDs: %s", err)
+	}
+
+	present := make(map[string]bool)
+	for _, crd := range crds.Items {
+		if crd.DeletionTimestamp == nil {
+			present[crd.Name] = true
+		}
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !present[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the following cert-manager CRDs were removed along with the Installation despite --keep-crds: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// withoutInstallationOwnerRefs returns refs without any references to an
+// operator Installation, and false if there were none to remove
+func withoutInstallationOwnerRefs(refs []metav1.OwnerReference) ([]metav1.OwnerReference, bool) {
+	kept := make([]metav1.OwnerReference, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Kind == "Installation" && strings.HasPrefix(ref.APIVersion, "operator.jetstack.io/") {
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	return kept, len(kept) != len(refs)
+}
+
+func crdPatch(crd, newCRD *apiextensionsv1.CustomResourceDefinition) ([]byte, error) {
+	crdData, err := json.Marshal(crd)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling CRD: %s", err)
+	}
+	newCRDData, err := json.Marshal(newCRD)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling new CRD: %s", err)
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(crdData, newCRDData, apiextensionsv1.CustomResourceDefinition{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating patch for CRD %s: %s", crd.Name, err)
+	}
+
+	return patch, nil
+}
+
+// installationCRDs returns the CRDs owned by the Installation, which are
+// garbage collected when it is deleted. With keepCRDs, the cert-manager CRDs
+// are released before the Installation is deleted and so are not included.
+func installationCRDs(ctx context.Context, kubeCfg *rest.Config, keepCRDs bool) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	crdClient, err := clients.NewCRDClient(kubeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CRD client: %s", err)
+	}
+
+	var crds apiextensionsv1.CustomResourceDefinitionList
+	err = crdClient.List(ctx, &clients.GenericRequestOptions{}, &crds)
+	if err != nil {
+		return nil, fmt.Errorf("error listing CRDs: %s", err)
+	}
+
+	var owned []apiextensionsv1.CustomResourceDefinition
+	for _, crd := range crds.Items {
+		if keepCRDs && isCertManagerCRDGroup(crd.Spec.Group) {
+			continue
+		}
+		if _, ok := withoutInstallationOwnerRefs(crd.OwnerReferences); ok {
+			owned = append(owned, crd)
+		}
+	}
+
+	return owned, nil
+}
+
+// operatorDeployments returns the Deployments of the operator itself, falling
+// back to those of the latest version if version is not known
+func operatorDeployments(version string) ([]k8stypes.NamespacedName, error) {
+	deployments, err := operator.ManifestDeployments(version)
+	if errors.Is(err, operator.ErrNoManifest) {
+		deployments, err = operator.ManifestDeployments("")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine operator deployments: %w", err)
+	}
+	return deployments, nil
+}
+
+// installationDeployments returns the Deployments of the components managed
+// by the operator, which are removed when the Installation is deleted
+func installationDeployments(ctx context.Context, kubeCfg *rest.Config, version string) ([]string, error) {
+	operatorDeployments, err := operatorDeployments(version)
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentClient, err := clients.NewDeploymentClient(kubeCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating deployment client: %s", err)
+	}
+
+	var deployments appsv1.DeploymentList
+	err = deploymentClient.List(ctx, &clients.GenericRequestOptions{Namespace: "jetstack-secure"}, &deployments)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %s", err)
+	}
+
+	return managedDeployments(deployments.Items, operatorDeployments), nil
+}
+
+// waitForTeardown waits for the Installation to be deleted and for the
+// operator to remove the Deployments of the components it manages
+func waitForTeardown(ctx context.Context, kubeCfg *rest.Config, installationClient *clients.InstallationClient, version string, timeout time.Duration) error {
+	operatorDeployments, err := operatorDeployments(version)
+	if err != nil {
+		return err
+	}
+
+	deploymentClient, err := clients.NewDeploymentClient(kubeCfg)
+	if err != nil {
+		return fmt.Errorf("error creating deployment client: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var remaining []string
+	err = wait.PollImmediateUntilWithContext(ctx, rollout.DefaultInterval, func(ctx context.Context) (bool, error) {
+		_, err := installationClient.Get(ctx, operator.InstallationName)
+		switch {
+		case err == nil:
+			remaining = []string{"Installation " + operator.InstallationName}
+			return false, nil
+		case !errors.Is(err, clients.ErrNoInstallation):
+			return false, err
+		}
+
+		var deployments appsv1.DeploymentList
+		err = deploymentClient.List(ctx, &clients.GenericRequestOptions{Namespace: "jetstack-secure"}, &deployments)
+		if err != nil {
+			return false, err
+		}
+
+		remaining = managedDeployments(deployments.Items, operatorDeployments)
+		return len(remaining) == 0, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s waiting for the operator to remove its components, still present: %s", timeout, strings.Join(remaining, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("error waiting for the operator to remove its components: %w", err)
+	}
+
+	return nil
+}
+
+// managedDeployments returns the names of the deployments that are not part
+// of the operator itself, and so are still to be removed by the operator
+func managedDeployments(deployments []appsv1.Deployment, operatorDeployments []k8stypes.NamespacedName) []string {
+	isOperator := make(map[k8stypes.NamespacedName]bool)
+	for _, name := range operatorDeployments {
+		isOperator[name] = true
+	}
+
+	var managed []string
+	for _, deployment := range deployments {
+		name := k8stypes.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name}
+		if isOperator[name] {
+			continue
+		}
+		managed = append(managed, "Deployment "+name.String())
+	}
+	sort.Strings(managed)
+
+	return managed
+}
+
+// deleteManifestResources deletes each of the resources in order. Resources
+// which have already been deleted, or whose kind is no longer served, are
+// ignored.
+func deleteManifestResources(ctx context.Context, kubeCfg *rest.Config, resources []operator.ManifestResource) error {
+	resourceClients := make(map[schema.GroupVersionResource]clients.Generic[*metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList])
+
+	for _, resource := range resources {
+		client, ok := resourceClients[resource.Resource]
+		if !ok {
+			apiPath := "/apis/"
+			if resource.Resource.Group == "" {
+				apiPath = "/api/"
+			}
+
+			var err error
+			client, err = clients.NewGenericClient[*metav1.PartialObjectMetadata, *metav1.PartialObjectMetadataList](
+				&clients.GenericClientOptions{
+					RestConfig: kubeCfg,
+					APIPath:    apiPath,
+					Group:      resource.Resource.Group,
+					Version:    resource.Resource.Version,
+					Kind:       resource.Resource.Resource,
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("error creating %s client: %s", resource.Kind, err)
+			}
+			resourceClients[resource.Resource] = client
+		}
+
+		err := client.Delete(ctx, &clients.GenericRequestOptions{Name: resource.Name, Namespace: resource.Namespace})
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return fmt.Errorf("error deleting %s: %w", resource, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s deleted\n", resource)
+	}
+
+	fmt.Fprintf(os.Stderr, "The operator has been removed\n")
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/ownerrefs"
+)
+
+func Test_withoutInstallationOwnerRefs(t *testing.T) {
+	installation := metav1.OwnerReference{APIVersion: "operator.jetstack.io/v1alpha1", Kind: "Installation", Name: "jetstack-secure"}
+	other := metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Installation", Name: "other"}
+
+	tests := map[string]struct {
+		refs      []metav1.OwnerReference
+		want      []metav1.OwnerReference
+		wantFound bool
+	}{
+		"no owner references should not be changed": {
+			want: []metav1.OwnerReference{},
+		},
+		"installation owner reference should be removed": {
+			refs:      []metav1.OwnerReference{installation, other},
+			want:      []metav1.OwnerReference{other},
+			wantFound: true,
+		},
+		"other owner references should be kept": {
+			refs: []metav1.OwnerReference{other},
+			want: []metav1.OwnerReference{other},
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, found := withoutInstallationOwnerRefs(scenario.refs)
+			if found != scenario.wantFound {
+				t.Errorf("withoutInstallationOwnerRefs() found = %v, want %v", found, scenario.wantFound)
+			}
+			if !reflect.DeepEqual(got, scenario.want) {
+				t.Errorf("withoutInstallationOwnerRefs() = %v, want %v", got, scenario.want)
+			}
+		})
+	}
+}
+
+func Test_managedDeployments(t *testing.T) {
+	deployment := func(name string) appsv1.Deployment {
+		return appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "jetstack-secure", Name: name}}
+	}
+
+	deployments := []appsv1.Deployment{
+		deployment("js-operator-operator"),
+		deployment("cert-manager"),
+		deployment("cert-manager-webhook"),
+	}
+	operatorDeployments := []k8stypes.NamespacedName{
+		{Namespace: "jetstack-secure", Name: "js-operator-operator"},
+	}
+
+	got := managedDeployments(deployments, operatorDeployments)
+	want := []string{"Deployment jetstack-secure/cert-manager", "Deployment jetstack-secure/cert-manager-webhook"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("managedDeployments() = %v, want %v", got, want)
+	}
+
+	if got := managedDeployments(deployments[:1], operatorDeployments); len(got) != 0 {
+		t.Errorf("managedDeployments() = %v, want none", got)
+	}
+}
+
+// crdServer serves the given CRDs and records the names of patched CRDs
+func crdServer(t *testing.T, crds []apiextensionsv1.CustomResourceDefinition, patched *[]string) *httptest.Server {
+	const crdPath = "/apis/apiextensions.k8s.io/v1/customresourcedefinitions"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == crdPath:
+			json.NewEncoder(w).Encode(apiextensionsv1.CustomResourceDefinitionList{Items: crds})
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, crdPath+"/"):
+			*patched = append(*patched, strings.TrimPrefix(r.URL.Path, crdPath+"/"))
+			w.Write([]byte("{}"))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func Test_releaseCertManagerCRDs(t *testing.T) {
+	installationRef := metav1.OwnerReference{APIVersion: "operator.jetstack.io/v1alpha1", Kind: "Installation", Name: "jetstack-secure"}
+	crd := func(name, group string, refs ...metav1.OwnerReference) apiextensionsv1.CustomResourceDefinition {
+		return apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: refs},
+			Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: group},
+		}
+	}
+
+	var patched []string
+	server := crdServer(t, []apiextensionsv1.CustomResourceDefinition{
+		crd("certificates.cert-manager.io", "cert-manager.io", installationRef),
+		crd("orders.acme.cert-manager.io", "acme.cert-manager.io"),
+		crd("bundles.trust.cert-manager.io", "trust.cert-manager.io", installationRef),
+		crd("widgets.example.com", "example.com", installationRef),
+	}, &patched)
+
+	kept, err := releaseCertManagerCRDs(context.Background(), &rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"certificates.cert-manager.io", "orders.acme.cert-manager.io"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("releaseCertManagerCRDs() = %v, want %v", kept, want)
+	}
+	if want := []string{"certificates.cert-manager.io"}; !reflect.DeepEqual(patched, want) {
+		t.Errorf("releaseCertManagerCRDs() patched %v, want %v", patched, want)
+	}
+}
+
+func Test_checkCRDsKept(t *testing.T) {
+	deleting := metav1.Now()
+	var patched []string
+	server := crdServer(t, []apiextensionsv1.CustomResourceDefinition{
+		{ObjectMeta: metav1.ObjectMeta{Name: "certificates.cert-manager.io"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "issuers.cert-manager.io", DeletionTimestamp: &deleting}},
+	}, &patched)
+	cfg := &rest.Config{Host: server.URL}
+
+	if err := checkCRDsKept(context.Background(), cfg, []string{"certificates.cert-manager.io"}); err != nil {
+		t.Errorf("checkCRDsKept() unexpected error: %s", err)
+	}
+
+	err := checkCRDsKept(context.Background(), cfg, []string{"certificates.cert-manager.io", "issuers.cert-manager.io", "orders.acme.cert-manager.io"})
+	if err == nil || !strings.Contains(err.Error(), "issuers.cert-manager.io, orders.acme.cert-manager.io") {
+		t.Errorf("checkCRDsKept() error = %v, want the removed CRDs to be reported", err)
+	}
+}
+
+func Test_installationCRDs(t *testing.T) {
+	installationRef := metav1.OwnerReference{APIVersion: "operator.jetstack.io/v1alpha1", Kind: "Installation", Name: "jetstack-secure"}
+	crd := func(name, group string, refs ...metav1.OwnerReference) apiextensionsv1.CustomResourceDefinition {
+		return apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: refs},
+			Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: group},
+		}
+	}
+
+	var patched []string
+	server := crdServer(t, []apiextensionsv1.CustomResourceDefinition{
+		crd("certificates.cert-manager.io", "cert-manager.io", installationRef),
+		crd("orders.acme.cert-manager.io", "acme.cert-manager.io"),
+		crd("bundles.trust.cert-manager.io", "trust.cert-manager.io", installationRef),
+		crd("widgets.example.com", "example.com"),
+	}, &patched)
+	cfg := &rest.Config{Host: server.URL}
+
+	names := func(crds []apiextensionsv1.CustomResourceDefinition) []string {
+		var names []string
+		for _, crd := range crds {
+			names = append(names, crd.Name)
+		}
+		return names
+	}
+
+	crds, err := installationCRDs(context.Background(), cfg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"certificates.cert-manager.io", "bundles.trust.cert-manager.io"}; !reflect.DeepEqual(names(crds), want) {
+		t.Errorf("installationCRDs() = %v, want %v", names(crds), want)
+	}
+
+	crds, err = installationCRDs(context.Background(), cfg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bundles.trust.cert-manager.io"}; !reflect.DeepEqual(names(crds), want) {
+		t.Errorf("installationCRDs() with kept CRDs = %v, want %v", names(crds), want)
+	}
+}
+
+func Test_releaseCertificateOwnedSecrets(t *testing.T) {
+	certificateRef := metav1.OwnerReference{APIVersion: "cert-manager.io/v1", Kind: "Certificate", Name: "owned", UID: "1"}
+	secrets := []corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "unowned"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "owned", OwnerReferences: []metav1.OwnerReference{certificateRef}}},
+	}
+
+	var patched []string
+	secretsClient := &clients.FakeGeneric[*corev1.Secret, *corev1.SecretList]{
+		FakeList: func(_ context.Context, _ *clients.GenericRequestOptions, list *corev1.SecretList) error {
+			list.Items = secrets
+			return nil
+		},
+		FakePatch: func(_ context.Context, options *clients.GenericRequestOptions, _ []byte) error {
+			patched = append(patched, options.Namespace+"/"+options.Name)
+			return nil
+		},
+	}
+	journalPath := filepath.Join(t.TempDir(), "journal.json")
+
+	if err := releaseCertificateOwnedSecrets(context.Background(), secretsClient, journalPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"foo/owned"}; !reflect.DeepEqual(patched, want) {
+		t.Errorf("releaseCertificateOwnedSecrets() patched %v, want %v", patched, want)
+	}
+
+	journal, err := ownerrefs.ReadJournal(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ownerrefs.JournalEntry{{
+		Namespace:       "foo",
+		SecretName:      "owned",
+		CertificateName: "owned",
+		CertificateUID:  "1",
+		OwnerReference:  certificateRef,
+	}}
+	if !reflect.DeepEqual(journal.Entries, want) {
+		t.Errorf("releaseCertificateOwnedSecrets() journal = %v, want %v", journal.Entries, want)
+	}
+}
@@ -0,0 +1,143 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func Render(run types.RunFunc, apiURL *string) *cobra.Command {
+	var (
+		outputDir                    string
+		operatorImageRegistry        string
+		registryCredentialsPath      string
+		autoFetchRegistryCredentials bool
+		version                      string
+		configPath                   string
+		secretFormat                 string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Renders the operator and Installation manifests to a directory for use with GitOps tools",
+		Long: `Renders the operator and Installation manifests to a directory for use with GitOps tools
+
+The operator manifests, each Secret, the Installation, any trust-manager Bundle and any approver policies are written to separate files in --output-dir, along with a kustomization.yaml that references them, so that the directory can be committed to a repository used by Argo CD or Flux. Existing files with the same names are overwritten.
+
+The Installation is configured with a file passed to --config, in the format used by 'installations apply --config'. If --config is unset the default Installation is rendered. When approverPolicy.generatePolicies is set, policies are only generated for the issuers in the Installation, as the cluster is not read.
+
+Secrets are written in plain text by default. Use --secret-format to write SealedSecret or ExternalSecret placeholders instead, these must be completed before they are committed: the REPLACE_ME values of a SealedSecret are replaced with the output of kubeseal, and the secretStoreRef of an ExternalSecret is set to a store containing the values.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if outputDir == "" {
+				return errors.New("error validating provided flags: --output-dir must be specified")
+			}
+			if !validSecretFormat(secretFormat) {
+				return fmt.Errorf("error validating provided flags: invalid secret format %q, must be one of %s", secretFormat, secretFormatList())
+			}
+
+			cfg := defaultInstallationConfig()
+			if configPath != "" {
+				var err error
+				cfg, err = loadInstallationConfig(configPath, cfg)
+				if err != nil {
+					return err
+				}
+			}
+			if registryCredentialsPath != "" || autoFetchRegistryCredentials {
+				cfg.Registry.CredentialsPath = registryCredentialsPath
+				cfg.Registry.AutoFetchCredentials = autoFetchRegistryCredentials
+			}
+			if err := cfg.validate(); err != nil {
+				return fmt.Errorf("error validating provided flags: %w", err)
+			}
+
+			registryCredentials, err := loadRegistryCredentials(ctx, *apiURL, cfg.Registry.CredentialsPath, cfg.Registry.AutoFetchCredentials)
+			if err != nil {
+				return err
+			}
+			if registryCredentials == "" {
+				fmt.Fprint(os.Stderr, "Note: no image pull credentials specified, the manifests will not include an image pull secret\n")
+			}
+
+			installationOptions, err := cfg.installationOptions(ctx, registryCredentials, nil, os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			files, err := operator.Render(ctx, operator.RenderOptions{
+				Operator: operator.ApplyOperatorYAMLOptions{
+					Version:             version,
+					ImageRegistry:       operatorImageRegistry,
+					RegistryCredentials: registryCredentials,
+				},
+				Installation: installationOptions,
+				SecretFormat: operator.SecretFormat(secretFormat),
+			})
+			switch {
+			case errors.Is(err, operator.ErrNoManifest):
+				return fmt.Errorf("operator version %s is unknown or not supported by this version of jsctl. Run 'jsctl operator versions' to see the supported operator versions", version)
+			case err != nil:
+				return fmt.Errorf("failed to render manifests: %w", err)
+			}
+
+			for _, file := range files {
+				path := filepath.Join(outputDir, filepath.FromSlash(file.Path))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return fmt.Errorf("failed to create output directory: %w", err)
+				}
+
+				mode := os.FileMode(0644)
+				if file.Sensitive {
+					mode = 0600
+				}
+				if err := os.WriteFile(path, file.Data, mode); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+				fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+			}
+
+			if operator.SecretFormat(secretFormat) == operator.SecretFormatPlain {
+				fmt.Fprintf(os.Stderr, "Note: Secrets have been written in plain text, use --secret-format to write placeholders instead before committing the manifests\n")
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&outputDir, "output-dir", "", "Specifies the directory to write the manifests to")
+	flags.BoolVar(&autoFetchRegistryCredentials, "auto-registry-credentials", false, "If set, then credentials to pull images from the Jetstack Secure Enterprise registry will be automatically fetched")
+	flags.StringVar(&operatorImageRegistry, "registry", defaultRegistry, "Specifies an alternative image registry to use for js-operator and cainjector images")
+	flags.StringVar(&registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for docker image pull secrets")
+	flags.StringVar(&version, "version", "", "Specifies a specific version of the operator to render, defaults to latest")
+	flags.StringVar(&configPath, "config", "", "Specifies a path to a file configuring the Installation, in the format used by 'installations apply --config'")
+	flags.StringVar(&secretFormat, "secret-format", string(operator.SecretFormatPlain), fmt.Sprintf("Specifies how Secrets are written, one of %s", secretFormatList()))
+
+	return cmd
+}
+
+func validSecretFormat(format string) bool {
+	for _, f := range operator.SecretFormats {
+		if string(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+func secretFormatList() string {
+	formats := make([]string, len(operator.SecretFormats))
+	for i, f := range operator.SecretFormats {
+		formats[i] = string(f)
+	}
+	return strings.Join(formats, ", ")
+}
@@ -0,0 +1,251 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/status/components"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func Upgrade(run types.RunFunc, apiURL, kubeConfig *string) *cobra.Command {
+	var (
+		operatorImageRegistry        string
+		registryCredentialsPath      string
+		autoFetchRegistryCredentials bool
+		version                      string
+		force                        bool
+		timeout                      time.Duration
+	)
+
+	var cmd *cobra.Command
+	cmd = &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrades the operator in the current Kubernetes context to a newer version",
+		Long: `Upgrades the operator in the current Kubernetes context to a newer version
+
+The version of the running operator is compared with the target version and any changes to the operator's CRDs are shown before the new version is applied. The command then waits for the operator Deployments to be rolled out. Downgrades are refused unless --force is set.
+
+The images of the upgraded operator are pulled from the same registry as the running operator, such as a mirror made with 'jsctl images mirror', unless --registry is set.
+
+Note: If --auto-registry-credentials and --registry-credentials-path are unset, then the existing image pull secret is left in place.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if registryCredentialsPath != "" && autoFetchRegistryCredentials {
+				return errors.New("error validating provided flags: cannot specify both --registry-credentials and --auto-fetch-registry-credentials")
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			currentVersion, found, err := runningOperatorVersion(ctx, kubeCfg)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return errors.New("the operator is not running in this cluster, deploy it with 'jsctl operator deploy'")
+			}
+
+			versions, err := operator.Versions()
+			if err != nil {
+				return fmt.Errorf("failed to get operator versions: %w", err)
+			}
+			targetVersion := version
+			if targetVersion == "" {
+				targetVersion = versions[len(versions)-1]
+			}
+
+			if err := checkUpgrade(currentVersion, targetVersion, versions, force); err != nil {
+				if errors.Is(err, errAlreadyAtVersion) {
+					fmt.Fprintf(os.Stderr, "The operator is already running version %s, no action needed\n", currentVersion)
+					return nil
+				}
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "Upgrading the operator from %s to %s\n", currentVersion, targetVersion)
+
+			crdChanges, err := operator.DiffCRDs(currentVersion, targetVersion)
+			switch {
+			case errors.Is(err, operator.ErrNoManifest):
+				fmt.Fprintf(os.Stderr, "Note: version %s is not known to this version of jsctl, CRD changes cannot be shown\n", currentVersion)
+			case err != nil:
+				return fmt.Errorf("failed to compare operator CRDs: %w", err)
+			case len(crdChanges) == 0:
+				fmt.Fprintf(os.Stderr, "No CRD changes\n")
+			default:
+				fmt.Fprintf(os.Stderr, "CRD changes:\n")
+				for _, change := range crdChanges {
+					fmt.Fprintf(os.Stderr, "  %s\n", change)
+				}
+			}
+
+			pods, err := listPods(ctx, kubeCfg)
+			if err != nil {
+				return err
+			}
+			registryChanged := cmd.Flags().Changed("registry")
+			operatorImageRegistry = upgradeRegistry(pods, operatorImageRegistry, registryChanged)
+			if !registryChanged && operatorImageRegistry != defaultRegistry {
+				fmt.Fprintf(os.Stderr, "Using the registry of the running operator, %s, set --registry to use another\n", operatorImageRegistry)
+			}
+
+			registryCredentials, err := loadRegistryCredentials(ctx, *apiURL, registryCredentialsPath, autoFetchRegistryCredentials)
+			if err != nil {
+				return err
+			}
+
+			applier, err := kubernetes.NewKubeConfigApplier(*kubeConfig)
+			if err != nil {
+				return fmt.Errorf("failed initialize deployment configuration using kubeconfig: %s", err)
+			}
+
+			err = operator.ApplyOperatorYAML(ctx, applier, operator.ApplyOperatorYAMLOptions{
+				Version:             targetVersion,
+				ImageRegistry:       operatorImageRegistry,
+				RegistryCredentials: registryCredentials,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply operator manifests: %s", err)
+			}
+
+			if err := waitForOperator(ctx, *kubeConfig, targetVersion, timeout); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "The operator has been upgraded to %s\n", targetVersion)
+
+			return nil
+		}),
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.BoolVar(&autoFetchRegistryCredentials, "auto-registry-credentials", false, "If set, then credentials to pull images from the Jetstack Secure Enterprise registry will be automatically fetched")
+	flags.StringVar(&operatorImageRegistry, "registry", defaultRegistry, "Specifies an alternative image registry to use for js-operator and cainjector images. If unset, the registry of the running operator is kept")
+	flags.StringVar(&registryCredentialsPath, "registry-credentials-path", "", "Specifies the location of the credentials file to use for docker image pull secrets")
+	flags.StringVar(&version, "version", "", "Specifies the version of the operator to upgrade to, defaults to latest")
+	flags.BoolVar(&force, "force", false, "If set, allows the operator to be downgraded or upgraded from an unrecognised version")
+	flags.DurationVar(&timeout, "timeout", 5*time.Minute, "How long to wait for the upgraded operator to become ready")
+
+	return cmd
+}
+
+// runningOperatorVersion returns the version of the operator running in the
+// cluster, or false if the operator's pods cannot be found
+func runningOperatorVersion(ctx context.Context, kubeCfg *rest.Config) (string, bool, error) {
+	pods, err := listPods(ctx, kubeCfg)
+	if err != nil {
+		return "", false, err
+	}
+
+	var operatorStatus components.JetstackSecureOperatorStatus
+	found, err := operatorStatus.Match(&components.MatchData{Pods: pods})
+	if err != nil {
+		return "", false, fmt.Errorf("error matching operator status: %s", err)
+	}
+	if !found {
+		return "", false, nil
+	}
+
+	return operatorStatus.Version(), true, nil
+}
+
+// listPods returns the pods in all namespaces of the cluster
+func listPods(ctx context.Context, kubeCfg *rest.Config) ([]corev1.Pod, error) {
+	podClient, err := clients.NewGenericClient[*corev1.Pod, *corev1.PodList](
+		&clients.GenericClientOptions{
+			RestConfig: kubeCfg,
+			APIPath:    "/api/",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "pods",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pod client: %s", err)
+	}
+
+	var pods corev1.PodList
+	err = podClient.List(ctx, &clients.GenericRequestOptions{}, &pods)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %s", err)
+	}
+
+	return pods.Items, nil
+}
+
+// upgradeRegistry returns the registry that the images of the upgraded
+// operator are pulled from. Unless --registry was set, the registry of the
+// running operator is kept, so that an operator deployed from a mirrored
+// registry is not moved back to the default registry.
+func upgradeRegistry(pods []corev1.Pod, registry string, registryChanged bool) string {
+	if registryChanged {
+		return registry
+	}
+
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if running, _, ok := strings.Cut(container.Image, "/js-operator:"); ok {
+				return running
+			}
+		}
+	}
+
+	return registry
+}
+
+// errAlreadyAtVersion is returned by checkUpgrade when the operator is already
+// running the target version
+var errAlreadyAtVersion = errors.New("already at version")
+
+// checkUpgrade validates an upgrade of the operator from currentVersion to
+// targetVersion. The target must be one of the available versions, and
+// downgrades are only permitted when force is set.
+func checkUpgrade(currentVersion, targetVersion string, versions []string, force bool) error {
+	var known bool
+	for _, v := range versions {
+		if v == targetVersion {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("operator version %s is unknown or not supported by this version of jsctl. Run 'jsctl operator versions' to see the supported operator versions", targetVersion)
+	}
+
+	target, err := semver.NewVersion(targetVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse target version %s: %w", targetVersion, err)
+	}
+
+	current, err := semver.NewVersion(currentVersion)
+	if err != nil {
+		if force {
+			return nil
+		}
+		return fmt.Errorf("unable to determine the version of the running operator from %q, use --force to upgrade anyway", currentVersion)
+	}
+
+	switch {
+	case current.Equal(target):
+		return errAlreadyAtVersion
+	case current.GreaterThan(target) && !force:
+		return fmt.Errorf("refusing to downgrade the operator from %s to %s, use --force to downgrade anyway", currentVersion, targetVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_checkUpgrade(t *testing.T) {
+	versions := []string{"v0.0.1-alpha.19", "v0.0.1-alpha.20"}
+
+	tests := map[string]struct {
+		current string
+		target  string
+		force   bool
+		wantErr error
+		anyErr  bool
+	}{
+		"upgrade to a newer version should be permitted": {
+			current: "v0.0.1-alpha.19",
+			target:  "v0.0.1-alpha.20",
+		},
+		"upgrade to the running version should be reported": {
+			current: "v0.0.1-alpha.20",
+			target:  "v0.0.1-alpha.20",
+			wantErr: errAlreadyAtVersion,
+		},
+		"unknown target version should be refused": {
+			current: "v0.0.1-alpha.19",
+			target:  "v99.99.99",
+			force:   true,
+			anyErr:  true,
+		},
+		"downgrade should be refused": {
+			current: "v0.0.1-alpha.20",
+			target:  "v0.0.1-alpha.19",
+			anyErr:  true,
+		},
+		"downgrade should be permitted when forced": {
+			current: "v0.0.1-alpha.20",
+			target:  "v0.0.1-alpha.19",
+			force:   true,
+		},
+		"unparsable running version should be refused": {
+			current: "latest",
+			target:  "v0.0.1-alpha.20",
+			anyErr:  true,
+		},
+		"unparsable running version should be permitted when forced": {
+			current: "latest",
+			target:  "v0.0.1-alpha.20",
+			force:   true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkUpgrade(scenario.current, scenario.target, versions, scenario.force)
+			switch {
+			case scenario.wantErr != nil:
+				if !errors.Is(err, scenario.wantErr) {
+					t.Fatalf("checkUpgrade() error = %v, want %v", err, scenario.wantErr)
+				}
+			case scenario.anyErr:
+				if err == nil {
+					t.Fatalf("checkUpgrade() expected error")
+				}
+			case err != nil:
+				t.Fatalf("checkUpgrade() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_upgradeRegistry(t *testing.T) {
+	pods := func(images ...string) []corev1.Pod {
+		var containers []corev1.Container
+		for _, image := range images {
+			containers = append(containers, corev1.Container{Image: image})
+		}
+		return []corev1.Pod{{Spec: corev1.PodSpec{Containers: containers}}}
+	}
+
+	tests := map[string]struct {
+		pods            []corev1.Pod
+		registry        string
+		registryChanged bool
+		want            string
+	}{
+		"registry of the running operator should be kept": {
+			pods:     pods("registry.internal/jetstack/js-operator:v0.0.1-alpha.19", "registry.internal/jetstack/cert-manager-cainjector:v1.10.1"),
+			registry: defaultRegistry,
+			want:     "registry.internal/jetstack",
+		},
+		"registry set by flag should be used": {
+			pods:            pods("registry.internal/jetstack/js-operator:v0.0.1-alpha.19"),
+			registry:        "registry.example.com/jse",
+			registryChanged: true,
+			want:            "registry.example.com/jse",
+		},
+		"default registry should be used when the operator image is not found": {
+			pods:     pods("nginx:1.23"),
+			registry: defaultRegistry,
+			want:     defaultRegistry,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := upgradeRegistry(scenario.pods, scenario.registry, scenario.registryChanged)
+			if got != scenario.want {
+				t.Fatalf("upgradeRegistry() = %q, want %q", got, scenario.want)
+			}
+		})
+	}
+}
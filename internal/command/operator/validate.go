@@ -0,0 +1,90 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func InstallationsValidate(run types.RunFunc) *cobra.Command {
+	var (
+		configPath   string
+		manifestPath string
+		version      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validates an Installation without applying it or connecting to a cluster",
+		Long: `Validates an Installation without applying it or connecting to a cluster
+
+The Installation is checked against the schema of the Installation CRD embedded in jsctl, and against the rules enforced by the operator's validating webhook. Either a config file in the format used by 'installations apply --config' or an Installation manifest, such as the output of 'installations apply --stdout', can be validated. Use '-f -' to read the manifest from stdin.
+
+Registry credentials are not fetched when validating a config file, so the image pull secret is not included.
+
+Installations are also validated automatically by 'installations apply'.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if (configPath == "") == (manifestPath == "") {
+				return errors.New("error validating provided flags: exactly one of --config or --filename must be specified")
+			}
+
+			if manifestPath != "" {
+				var r io.Reader = os.Stdin
+				if manifestPath != "-" {
+					file, err := os.Open(manifestPath)
+					if err != nil {
+						return fmt.Errorf("error opening manifest file: %w", err)
+					}
+					defer file.Close()
+					r = file
+				}
+
+				if err := operator.ValidateInstallationManifest(r, version); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(os.Stderr, "%s is valid\n", manifestPath)
+				return nil
+			}
+
+			cfg, err := loadInstallationConfig(configPath, defaultInstallationConfig())
+			if err != nil {
+				return err
+			}
+			if err := cfg.validate(); err != nil {
+				return fmt.Errorf("invalid config: %w", err)
+			}
+
+			options, err := cfg.installationOptions(ctx, "", nil, os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			installation, err := operator.GenerateInstallation(options)
+			if err != nil {
+				return err
+			}
+			if err := operator.ValidateInstallation(installation, version); err != nil {
+				return fmt.Errorf("generated Installation is invalid: %w", err)
+			}
+
+			fmt.Fprintf(os.Stderr, "%s is valid\n", configPath)
+			return nil
+		}),
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&configPath, "config", "", "Specifies a path to a file configuring the Installation, in the format used by 'installations apply --config'")
+	flags.StringVarP(&manifestPath, "filename", "f", "", "Specifies a path to a file containing an Installation manifest, or - to read from stdin")
+	flags.StringVar(&version, "version", "", "Specifies the operator version whose Installation CRD is used for validation, defaults to latest")
+
+	return cmd
+}
@@ -2,19 +2,26 @@ package operator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/config"
 	"github.com/jetstack/jsctl/internal/operator"
 )
 
 func Versions(run types.RunFunc) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "versions",
 		Short: "Outputs all available versions of the jetstack operator",
-		Args:  cobra.ExactArgs(0),
+		Long: `Outputs all available versions of the jetstack operator
+
+This includes the versions embedded in jsctl, those downloaded with 'jsctl operator versions refresh' and those in the directory given by --installers-dir.`,
+		Args: cobra.ExactArgs(0),
 		Run: run(func(ctx context.Context, args []string) error {
 			versions, err := operator.Versions()
 			if err != nil {
@@ -28,4 +35,81 @@ func Versions(run types.RunFunc) *cobra.Command {
 			return nil
 		}),
 	}
+
+	cmd.AddCommand(VersionsRefresh(run))
+
+	return cmd
+}
+
+func VersionsRefresh(run types.RunFunc) *cobra.Command {
+	var (
+		indexURL      string
+		publicKeyPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Downloads additional operator versions from a signed index",
+		Long: `Downloads additional operator versions from a signed index
+
+The index at --index-url lists installer manifests and their SHA-256 checksums. Its ed25519 signature is read from the same URL with a .sig suffix, and is verified before any installers are downloaded. Release builds of jsctl verify it with the key that signs the indexes published for jsctl releases, use --public-key to verify an index signed with another key, such as that of a mirror. Installers are verified against their checksums and saved to the installers directory within the jsctl config directory, where they are available to all operator commands.
+
+An installer directory has the following index.yaml, with paths relative to the index:
+
+  installers:
+  - version: v0.0.1-alpha.21
+    path: v0.0.1-alpha.21.yaml
+    sha256: <hex-encoded checksum>
+
+The same format is used by the directory given with --installers-dir.`,
+		Args: cobra.ExactArgs(0),
+		Run: run(func(ctx context.Context, args []string) error {
+			if indexURL == "" {
+				return errors.New("error validating provided flags: --index-url must be specified")
+			}
+
+			configDir, ok := ctx.Value(config.ContextKey{}).(string)
+			if !ok {
+				return fmt.Errorf("no config path provided")
+			}
+
+			publicKey, err := operator.ReleasePublicKey()
+			switch {
+			case publicKeyPath != "":
+				publicKeyData, err := os.ReadFile(publicKeyPath)
+				if err != nil {
+					return fmt.Errorf("failed to read public key: %w", err)
+				}
+				publicKey, err = operator.ParsePublicKey(publicKeyData)
+				if err != nil {
+					return err
+				}
+			case errors.Is(err, operator.ErrNoReleasePublicKey):
+				return errors.New("error validating provided flags: this build of jsctl has no release signing key, --public-key must be specified")
+			case err != nil:
+				return err
+			}
+
+			versions, err := operator.RefreshInstallers(ctx, operator.RefreshInstallersOptions{
+				IndexURL:  indexURL,
+				PublicKey: publicKey,
+				Directory: filepath.Join(configDir, operator.CatalogueDirectory),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to refresh operator versions: %w", err)
+			}
+
+			for _, version := range versions {
+				fmt.Fprintf(os.Stderr, "Downloaded operator version %s\n", version)
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&indexURL, "index-url", "", "Specifies the URL of the installer index")
+	flags.StringVar(&publicKeyPath, "public-key", "", "Specifies a path to a PEM-encoded ed25519 public key used to verify the index signature instead of the release signing key")
+
+	return cmd
 }
@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/jetstack/jsctl/internal/auth"
+	internalerrors "github.com/jetstack/jsctl/internal/command/errors"
 	"github.com/jetstack/jsctl/internal/config"
+	"github.com/jetstack/jsctl/internal/operator"
 )
 
 // run is the wrapper function that is used to wrap all subcommands
@@ -67,11 +70,43 @@ func run(fn func(ctx context.Context, args []string) error) func(cmd *cobra.Comm
 		}
 
 		if err = fn(ctx, args); err != nil {
+			// some commands use the exit code to communicate their result
+			var exitCodeErr *internalerrors.ExitCodeError
+			if errors.As(err, &exitCodeErr) {
+				fmt.Fprintln(os.Stderr, exitCodeErr.Error())
+				os.Exit(exitCodeErr.Code)
+			}
+
 			exitf(err.Error())
 		}
 	}
 }
 
+// runWithInstallers is the wrapper function used for subcommands that use
+// operator installers. It makes operator versions downloaded with 'jsctl
+// operator versions refresh', or provided with --installers-dir, available
+// before calling fn. A problem with the downloaded installers is reported
+// without stopping the command, so that 'jsctl operator versions refresh' can
+// be used to repair them.
+func runWithInstallers(fn func(ctx context.Context, args []string) error) func(cmd *cobra.Command, args []string) {
+	return run(func(ctx context.Context, args []string) error {
+		catalogueDir := filepath.Join(configDir, operator.CatalogueDirectory)
+		err := operator.LoadInstallers(catalogueDir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "failed to load downloaded operator installers from %s, only the versions built in to jsctl are available: %s\n", catalogueDir, err)
+		}
+
+		if installersDir != "" {
+			err = operator.LoadInstallers(installersDir)
+			if err != nil {
+				return fmt.Errorf("failed to load operator installers from %s: %w", installersDir, err)
+			}
+		}
+
+		return fn(ctx, args)
+	})
+}
+
 func exitf(format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
 	fmt.Fprintln(os.Stderr, message)
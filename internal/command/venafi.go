@@ -0,0 +1,65 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/venafi"
+)
+
+// Venafi returns a cobra.Command instance that is the root for all "jsctl venafi" subcommands.
+func Venafi() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "venafi",
+		Short: "Subcommands for managing the Venafi connections and tokens used by Jetstack Secure components",
+	}
+
+	cmd.AddCommand(
+		venafiConnections(),
+		venafiTokens(),
+		venafiDiscovery(),
+	)
+
+	return cmd
+}
+
+func venafiConnections() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "connections",
+		Aliases: []string{"connection"},
+		Short:   "Subcommands for Venafi connections, as passed to --experimental-venafi-connections-config",
+	}
+
+	cmd.AddCommand(
+		venafi.ConnectionsTest(run, &kubeConfig),
+	)
+
+	return cmd
+}
+
+func venafiTokens() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tokens",
+		Aliases: []string{"token"},
+		Short:   "Subcommands for the TPP access tokens managed by venafi-oauth-helper",
+	}
+
+	cmd.AddCommand(
+		venafi.TokensStatus(run, &kubeConfig),
+		venafi.TokensRotate(run, &kubeConfig),
+	)
+
+	return cmd
+}
+
+func venafiDiscovery() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "discovery",
+		Short: "Subcommands for the certificates cert-discovery-venafi uploads to TPP",
+	}
+
+	cmd.AddCommand(
+		venafi.DiscoveryStatus(run, &kubeConfig),
+	)
+
+	return cmd
+}
@@ -0,0 +1,127 @@
+package venafi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/table"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+func ConnectionsTest(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		configPath string
+		offline    bool
+		timeout    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test [name...]",
+		Short: "Validates Venafi connections and tests that they can authenticate with TPP",
+		Long: `Validates Venafi connections and tests that they can authenticate with TPP
+
+Each connection in the file passed to --config, in the format used by 'operator installations apply --experimental-venafi-connections-config', is checked for a zone, a valid URL and a usable combination of credentials. Credentials given as sources, such as access-token-from, are read first, Kubernetes Secrets are read from the cluster of --kubeconfig. Only the named connections are tested if any are given.
+
+Unless --offline is set, jsctl then authenticates with each TPP server and checks that the zone exists. Connections using an access token have the token verified, while those using a username and password request a token with the cert-manager.io client ID. Venafi as a Service connections are only validated.`,
+		Run: run(func(ctx context.Context, args []string) error {
+			if configPath == "" {
+				return errors.New("error validating provided flags: --config must be specified")
+			}
+
+			vcs, err := venafi.LoadConnections(configPath)
+			if err != nil {
+				return err
+			}
+
+			names := args
+			if len(names) == 0 {
+				for name := range vcs {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("no Venafi connections found in %s", configPath)
+			}
+
+			client := &http.Client{Timeout: timeout}
+			getSecret := venafi.NewKubernetesSecretGetter(*kubeConfig)
+			tbl := table.NewBuilder([]string{"Name", "Status", "Message"})
+
+			var failed int
+			for _, name := range names {
+				vc, ok := vcs[name]
+				if !ok {
+					failed++
+					tbl.AddRow(name, connectionFailed, fmt.Sprintf("not found in %s", configPath))
+					continue
+				}
+
+				// credentials are resolved per connection so that each failure is reported
+				err := venafi.ResolveCredentials(ctx, map[string]*venafi.VenafiConnection{name: vc}, getSecret)
+				if err != nil {
+					failed++
+					tbl.AddRow(name, connectionFailed, err.Error())
+					continue
+				}
+
+				status, message := testConnection(ctx, client, vc, offline)
+				if status == connectionFailed {
+					failed++
+				}
+				tbl.AddRow(name, status, message)
+			}
+
+			if err := tbl.Build(os.Stdout); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d Venafi connection(s) failed", failed, len(names))
+			}
+			return nil
+		}),
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&configPath, "config", "", "Specifies a path to a file with yaml formatted Venafi connection details")
+	flags.BoolVar(&offline, "offline", false, "If set, connections are only validated and no requests are made to TPP")
+	flags.DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for each request to TPP")
+
+	return cmd
+}
+
+const (
+	connectionOK      = "OK"
+	connectionValid   = "Valid"
+	connectionFailed  = "Failed"
+	connectionSkipped = "connectivity checks are only supported for TPP connections"
+)
+
+// testConnection validates the connection and, unless offline is set, checks
+// that it can authenticate with TPP. It returns the status and a message to
+// report for the connection.
+func testConnection(ctx context.Context, client *http.Client, vc *venafi.VenafiConnection, offline bool) (string, string) {
+	if err := vc.Validate(); err != nil {
+		return connectionFailed, err.Error()
+	}
+	switch {
+	case offline:
+		return connectionValid, ""
+	case vc.IsVaas():
+		return connectionValid, connectionSkipped
+	}
+
+	if err := venafi.CheckConnection(ctx, client, vc); err != nil {
+		return connectionFailed, err.Error()
+	}
+	return connectionOK, fmt.Sprintf("authenticated with %s", vc.URL)
+}
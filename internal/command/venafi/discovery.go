@@ -0,0 +1,101 @@
+package venafi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/table"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+func DiscoveryStatus(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Reports the certificates cert-discovery-venafi has uploaded to TPP",
+		Long: `Reports the certificates cert-discovery-venafi has uploaded to TPP
+
+The TPP URL and policy folder are read from the Installation in the cluster, along with the access token cert-discovery-venafi uses. jsctl then searches the policy folder, and its subfolders, for uploaded certificates and reports how many there are and when the most recent was uploaded.
+
+TPP records when a certificate is first created, so uploads that only update existing certificates are not reflected in the last upload time.`,
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			installationClient, err := clients.NewInstallationClient(kubeCfg)
+			if err != nil {
+				return err
+			}
+
+			cdv, err := installedCertDiscoveryVenafi(ctx, installationClient, kubeCfg.Host)
+			if err != nil {
+				return err
+			}
+
+			namespace, name, key := venafi.DiscoveryTokenSecret(cdv)
+			data, err := venafi.NewKubernetesSecretGetter(*kubeConfig)(ctx, namespace, name)
+			if err != nil {
+				return fmt.Errorf("error getting cert-discovery-venafi access token: %w", err)
+			}
+			token, ok := data[key]
+			if !ok {
+				return fmt.Errorf("Secret %s/%s has no key %q", namespace, name, key)
+			}
+
+			client := &http.Client{Timeout: timeout}
+			upload, err := venafi.LastDiscoveryUpload(ctx, client, cdv.TPP.URL, cdv.TPP.Zone, string(token))
+			if err != nil {
+				return fmt.Errorf("error searching TPP for uploaded certificates: %w", err)
+			}
+
+			last, lastName := "never", ""
+			if !upload.Last.IsZero() {
+				last = fmt.Sprintf("%s (%s ago)", upload.Last.Format(time.RFC3339), time.Since(upload.Last).Round(time.Minute))
+				lastName = upload.LastName
+			}
+
+			tbl := table.NewBuilder([]string{"URL", "Policy Folder", "Certificates", "Last Upload", "Last Certificate"})
+			tbl.AddRow(cdv.TPP.URL, cdv.TPP.Zone, strconv.Itoa(upload.Certificates), last, lastName)
+			return tbl.Build(os.Stdout)
+		}),
+	}
+
+	flags := cmd.Flags()
+	flags.DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for each request to TPP")
+
+	return cmd
+}
+
+// installedCertDiscoveryVenafi returns the cert-discovery-venafi configuration of the Installation applied by
+// 'operator installations apply'
+func installedCertDiscoveryVenafi(ctx context.Context, installationClient *clients.InstallationClient, host string) (*operatorv1alpha1.CertDiscoveryVenafi, error) {
+	installation, err := installationClient.Get(ctx, operator.InstallationName)
+	switch {
+	case errors.Is(err, clients.ErrNoInstallation):
+		return nil, fmt.Errorf("no installations.operator.jetstack.io resources found in cluster %q, have you run 'jsctl operator installations apply'?", host)
+	case err != nil:
+		return nil, fmt.Errorf("failed to query installation: %w", err)
+	}
+
+	cdv := installation.Spec.CertDiscoveryVenafi
+	if cdv == nil || cdv.TPP == nil {
+		return nil, errors.New("cert-discovery-venafi is not configured in the Installation, enable it with 'operator installations apply --cert-discovery-venafi'")
+	}
+
+	return cdv, nil
+}
@@ -0,0 +1,49 @@
+package venafi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func TestInstalledCertDiscoveryVenafi(t *testing.T) {
+	ctx := context.Background()
+
+	// the Installation is generated as 'operator installations apply' does,
+	// so that it is named in the same way
+	installation, err := operator.GenerateInstallation(operator.ApplyInstallationYAMLOptions{})
+	require.NoError(t, err)
+	installation.Spec.CertDiscoveryVenafi = &operatorv1alpha1.CertDiscoveryVenafi{
+		TPP: &operatorv1alpha1.TPP{URL: "https://tpp.example.com/vedsdk", Zone: "\\VED\\Policy\\Discovered"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasSuffix(r.URL.Path, "/installations/"+installation.Name) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(installation)
+	}))
+	defer server.Close()
+
+	installationClient, err := clients.NewInstallationClient(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	cdv, err := installedCertDiscoveryVenafi(ctx, installationClient, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "https://tpp.example.com/vedsdk", cdv.TPP.URL)
+}
@@ -0,0 +1,214 @@
+package venafi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/jsctl/internal/command/types"
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+	"github.com/jetstack/jsctl/internal/kubernetes/rollout"
+	"github.com/jetstack/jsctl/internal/prompt"
+	"github.com/jetstack/jsctl/internal/table"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+const defaultTokenNamespace = "jetstack-secure"
+
+func TokensStatus(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		namespace string
+		offline   bool
+		timeout   time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Shows the expiry and refresh state of the access tokens managed by venafi-oauth-helper",
+		Long: `Shows the expiry and refresh state of the access tokens managed by venafi-oauth-helper
+
+Tokens are found by their bootstrap Secrets, named <secret>-voh-bootstrap, which are created by 'operator installations apply' for TPP issuers when --venafi-oauth-helper is set. For each token, the issuer using it and the time venafi-oauth-helper last wrote it are shown.
+
+Unless --offline is set, jsctl then verifies each token with the TPP server of its issuer to show when it expires. Tokens rejected by TPP, for example because they have expired without being refreshed, are reported as invalid.`,
+		Run: run(func(ctx context.Context, args []string) error {
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			tokens, err := venafi.FindManagedTokens(ctx, kubeCfg, namespace)
+			if err != nil {
+				return err
+			}
+			if len(tokens) == 0 {
+				fmt.Fprintf(os.Stderr, "No tokens managed by venafi-oauth-helper found in namespace %s\n", namespace)
+				return nil
+			}
+
+			client := &http.Client{Timeout: timeout}
+			tbl := table.NewBuilder([]string{"Namespace", "Name", "Issuer", "Status", "Expires", "Refreshed", "Message"})
+
+			var failed int
+			for _, token := range tokens {
+				status, expires, message := tokenStatus(ctx, client, token, offline)
+				if status == tokenMissing || status == tokenInvalid {
+					failed++
+				}
+
+				refreshed := ""
+				if !token.Refreshed.IsZero() {
+					refreshed = token.Refreshed.Format(time.RFC3339)
+				}
+				tbl.AddRow(token.Namespace, token.Name, token.Issuer, status, expires, refreshed, message)
+			}
+
+			if err := tbl.Build(os.Stdout); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d token(s) are missing or invalid", failed, len(tokens))
+			}
+			return nil
+		}),
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&namespace, "namespace", defaultTokenNamespace, "The namespace of the venafi-oauth-helper managed Secrets")
+	flags.BoolVar(&offline, "offline", false, "If set, tokens are not verified with TPP")
+	flags.DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for each request to TPP")
+
+	return cmd
+}
+
+const (
+	tokenValid   = "Valid"
+	tokenPresent = "Present"
+	tokenMissing = "Missing"
+	tokenInvalid = "Invalid"
+	tokenUnknown = "Unknown"
+)
+
+// tokenStatus returns the status, expiry and a message to report for a managed
+// token, verifying it with TPP unless offline is set
+func tokenStatus(ctx context.Context, client *http.Client, token *venafi.ManagedToken, offline bool) (string, string, string) {
+	switch {
+	case token.AccessToken == "":
+		return tokenMissing, "", "venafi-oauth-helper has not created a token from " + token.BootstrapSecretName()
+	case offline:
+		return tokenPresent, "", ""
+	case token.URL == "":
+		return tokenPresent, "", "no TPP issuer found using the token"
+	}
+
+	info, err := venafi.VerifyToken(ctx, client, token.URL, token.AccessToken)
+	switch {
+	case errors.Is(err, venafi.ErrAuthenticationFailed):
+		return tokenInvalid, "", "token rejected by TPP, it may have expired"
+	case err != nil:
+		return tokenUnknown, "", err.Error()
+	}
+
+	return tokenValid, info.Expires.Format(time.RFC3339), fmt.Sprintf("expires in %s", time.Until(info.Expires).Round(time.Minute))
+}
+
+func TokensRotate(run types.RunFunc, kubeConfig *string) *cobra.Command {
+	var (
+		namespace string
+		all       bool
+		yes       bool
+		timeout   time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate [name...]",
+		Short: "Rotates access tokens managed by venafi-oauth-helper",
+		Long: `Rotates access tokens managed by venafi-oauth-helper
+
+Each named token Secret, or every managed token if --all is set, is deleted so that venafi-oauth-helper requests a new access token from TPP using the credentials of its bootstrap Secret. jsctl then waits for the new token to be written, unless --timeout is 0. The tokens rotated by --all are confirmed first unless --yes is set.
+
+Issuers using a token cannot issue certificates until it has been recreated.`,
+		Run: run(func(ctx context.Context, args []string) error {
+			switch {
+			case all && len(args) > 0:
+				return errors.New("error validating provided flags: token names cannot be given with --all")
+			case !all && len(args) == 0:
+				return errors.New("error validating provided flags: token names or --all must be specified")
+			}
+
+			kubeCfg, err := kubernetes.NewConfig(*kubeConfig)
+			if err != nil {
+				return err
+			}
+
+			tokens, err := venafi.FindManagedTokens(ctx, kubeCfg, namespace)
+			if err != nil {
+				return err
+			}
+
+			if !all {
+				tokensByName := make(map[string]*venafi.ManagedToken)
+				for _, token := range tokens {
+					tokensByName[token.Name] = token
+				}
+
+				tokens = nil
+				for _, name := range args {
+					token, ok := tokensByName[name]
+					if !ok {
+						return fmt.Errorf("no token named %s managed by venafi-oauth-helper found in namespace %s", name, namespace)
+					}
+					tokens = append(tokens, token)
+				}
+			}
+			if len(tokens) == 0 {
+				fmt.Fprintf(os.Stderr, "No tokens managed by venafi-oauth-helper found in namespace %s\n", namespace)
+				return nil
+			}
+
+			// every managed token is deleted with --all, so the tokens are
+			// listed for confirmation unless --yes is set
+			if all && !yes {
+				for _, token := range tokens {
+					fmt.Fprintf(os.Stderr, "%s/%s\n", namespace, token.Name)
+				}
+				ok, err := prompt.YesNo(os.Stdin, os.Stderr, "Would you like to rotate these %d tokens?", len(tokens))
+				if err != nil {
+					return fmt.Errorf("failed to prompt: %w", err)
+				}
+				if !ok {
+					fmt.Fprintf(os.Stderr, "No action taken\n")
+					return nil
+				}
+			}
+
+			secretClient, err := clients.NewSecretClient(kubeCfg)
+			if err != nil {
+				return err
+			}
+
+			for _, token := range tokens {
+				err := venafi.RotateToken(ctx, secretClient, token, rollout.DefaultInterval, timeout, os.Stderr)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}),
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&namespace, "namespace", defaultTokenNamespace, "The namespace of the venafi-oauth-helper managed Secrets")
+	flags.BoolVar(&all, "all", false, "If set, all tokens in the namespace are rotated")
+	flags.BoolVar(&yes, "yes", false, "If set, tokens rotated with --all are not confirmed")
+	flags.DurationVar(&timeout, "timeout", 2*time.Minute, "How long to wait for each new token, 0 to not wait")
+
+	return cmd
+}
@@ -0,0 +1,410 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jetstack/jsctl/internal/docker"
+)
+
+// manifestMediaTypes are the manifest media types accepted from registries, index media types are copied along
+// with each of the manifests they list
+var (
+	manifestMediaTypes = []string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+	}
+	indexMediaTypes = map[string]bool{
+		"application/vnd.oci.image.index.v1+json":                   true,
+		"application/vnd.docker.distribution.manifest.list.v2+json": true,
+	}
+)
+
+type (
+	// The Client type copies images between registries using the OCI distribution API.
+	Client struct {
+		// HTTPClient is used for requests to registries
+		HTTPClient *http.Client
+		// Credentials are the credentials for each registry, keyed as in a docker config file
+		Credentials docker.Config
+		// PlainHTTP lists the registries that are served over HTTP rather than HTTPS
+		PlainHTTP []string
+
+		// authorizations caches the Authorization header for each registry and scope
+		authorizations map[string]string
+	}
+
+	descriptor struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+		Size      int64  `json:"size"`
+	}
+
+	manifest struct {
+		Config    *descriptor  `json:"config"`
+		Layers    []descriptor `json:"layers"`
+		Manifests []descriptor `json:"manifests"`
+	}
+)
+
+// Copy copies an image, including all the platforms of a multi-platform image, from src to dst. Blobs that already
+// exist in dst are not copied again. The manifest is pushed to the tag of dst, or its digest if it has no tag.
+func (c *Client) Copy(ctx context.Context, src, dst Reference) error {
+	data, mediaType, err := c.getManifest(ctx, src, src.manifestReference())
+	if err != nil {
+		return err
+	}
+
+	return c.copyManifest(ctx, src, dst, data, mediaType, pushReference(dst))
+}
+
+func (c *Client) copyManifest(ctx context.Context, src, dst Reference, data []byte, mediaType, reference string) error {
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to decode manifest of %s: %w", src, err)
+	}
+
+	if indexMediaTypes[mediaType] {
+		for _, child := range m.Manifests {
+			childData, childMediaType, err := c.getManifest(ctx, src, child.Digest)
+			if err != nil {
+				return err
+			}
+			if err := c.copyManifest(ctx, src, dst, childData, childMediaType, child.Digest); err != nil {
+				return err
+			}
+		}
+	} else {
+		blobs := m.Layers
+		if m.Config != nil {
+			blobs = append([]descriptor{*m.Config}, blobs...)
+		}
+		for _, blob := range blobs {
+			if err := c.copyBlob(ctx, src, dst, blob); err != nil {
+				return err
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.url(dst, "manifests", reference), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+
+	resp, err := c.do(req, dst, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to push manifest %s to %s: %w", reference, dst.Registry+"/"+dst.Repository, responseError(resp))
+	}
+
+	return nil
+}
+
+// getManifest fetches a manifest by tag or digest, manifests fetched by digest are verified against it
+func (c *Client) getManifest(ctx context.Context, ref Reference, reference string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ref, "manifests", reference), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	resp, err := c.do(req, ref, false)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch manifest %s of %s: %w", reference, ref.Registry+"/"+ref.Repository, responseError(resp))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest of %s: %w", ref, err)
+	}
+
+	if strings.HasPrefix(reference, "sha256:") && digest(data) != reference {
+		return nil, "", fmt.Errorf("manifest %s of %s does not match its digest", reference, ref.Registry+"/"+ref.Repository)
+	}
+
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	return data, mediaType, nil
+}
+
+// copyBlob streams a blob from src to dst using a monolithic upload, unless dst already has it
+func (c *Client) copyBlob(ctx context.Context, src, dst Reference, blob descriptor) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.url(dst, "blobs", blob.Digest), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, dst, true)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.url(src, "blobs", blob.Digest), nil)
+	if err != nil {
+		return err
+	}
+	srcResp, err := c.do(req, src, false)
+	if err != nil {
+		return err
+	}
+	defer srcResp.Body.Close()
+	if srcResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch blob %s of %s: %w", blob.Digest, src.Registry+"/"+src.Repository, responseError(srcResp))
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.url(dst, "blobs", "uploads/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err = c.do(req, dst, true)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to start upload of blob %s to %s: %w", blob.Digest, dst.Registry+"/"+dst.Repository, responseError(resp))
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location for blob %s: %w", blob.Digest, err)
+	}
+	query := location.Query()
+	query.Set("digest", blob.Digest)
+	location.RawQuery = query.Encode()
+
+	// the upload is authorized by the POST above, so the blob is streamed
+	// without being able to retry the request
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, location.String(), srcResp.Body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = blob.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err = c.do(req, dst, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to upload blob %s to %s: %w", blob.Digest, dst.Registry+"/"+dst.Repository, responseError(resp))
+	}
+
+	return nil
+}
+
+// do performs a request against the registry of ref. When the registry requires authentication the request is
+// retried with credentials, if its body can be replayed, and the authorization is cached for later requests. A cached
+// authorization that the registry rejects is replaced in the same way.
+func (c *Client) do(req *http.Request, ref Reference, push bool) (*http.Response, error) {
+	scope := "repository:" + ref.Repository + ":pull"
+	if push {
+		scope += ",push"
+	}
+	key := ref.apiHost() + " " + scope
+
+	if authorization, ok := c.authorizations[key]; ok {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	// a cached authorization may have been rejected because its token has expired, so it is replaced by a new one
+	// and the request is retried once
+	delete(c.authorizations, key)
+	authorization, err := c.authorize(req.Context(), ref, scope, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate with %s: %w", ref.Registry, err)
+	}
+	if c.authorizations == nil {
+		c.authorizations = make(map[string]string)
+	}
+	c.authorizations[key] = authorization
+
+	if req.Body != nil && req.GetBody == nil {
+		return nil, fmt.Errorf("request to %s requires authentication but cannot be retried", ref.Registry)
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", authorization)
+
+	return c.httpClient().Do(retry)
+}
+
+var challengeParams = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authorize returns the Authorization header for a WWW-Authenticate challenge, fetching a token from the token
+// server for Bearer challenges
+func (c *Client) authorize(ctx context.Context, ref Reference, scope, challenge string) (string, error) {
+	username, password, hasCredentials := c.credentials(ref)
+
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredentials {
+			return "", errors.New("no credentials found")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	values := make(map[string]string)
+	for _, match := range challengeParams.FindAllStringSubmatch(params, -1) {
+		values[match[1]] = match[2]
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return "", fmt.Errorf("invalid authentication realm %q", values["realm"])
+	}
+
+	query := realm.Query()
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasCredentials {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch token: %w", responseError(resp))
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", errors.New("no token returned")
+	}
+
+	return "Bearer " + token.Token, nil
+}
+
+// credentials returns the username and password for the registry of ref, docker config files key Docker Hub
+// credentials by its legacy index URL
+func (c *Client) credentials(ref Reference) (string, string, bool) {
+	keys := []string{ref.Registry, "https://" + ref.Registry, "http://" + ref.Registry}
+	if ref.Registry == dockerHubRegistry {
+		keys = append(keys, "https://index.docker.io/v1/")
+	}
+
+	for _, key := range keys {
+		entry, ok := c.Credentials[key]
+		if !ok {
+			continue
+		}
+		if entry.Username != "" || entry.Password != "" {
+			return entry.Username, entry.Password, true
+		}
+		auth, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		if username, password, ok := strings.Cut(string(auth), ":"); ok {
+			return username, password, true
+		}
+	}
+
+	return "", "", false
+}
+
+func (c *Client) url(ref Reference, kind, reference string) string {
+	scheme := "https"
+	for _, host := range c.PlainHTTP {
+		if host == ref.Registry {
+			scheme = "http"
+		}
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, ref.apiHost(), ref.Repository, kind, reference)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// pushReference returns the tag or digest a manifest is pushed to
+func pushReference(ref Reference) string {
+	if ref.Tag != "" {
+		return ref.Tag
+	}
+	return ref.Digest
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// responseError describes an unexpected response from a registry, including the errors reported in its body
+func responseError(resp *http.Response) error {
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err == nil && len(body.Errors) > 0 {
+		return fmt.Errorf("%s: %s: %s", resp.Status, body.Errors[0].Code, body.Errors[0].Message)
+	}
+	return errors.New(resp.Status)
+}
@@ -0,0 +1,273 @@
+package images_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/docker"
+	"github.com/jetstack/jsctl/internal/images"
+)
+
+type (
+	// fakeRegistry is an in-memory registry implementing the parts of the OCI distribution API used by images.Client,
+	// optionally requiring a token fetched with basic credentials
+	fakeRegistry struct {
+		*httptest.Server
+
+		username, password string
+
+		mu        sync.Mutex
+		token     string
+		blobs     map[string][]byte
+		manifests map[string]fakeManifest
+		uploads   int
+	}
+
+	fakeManifest struct {
+		mediaType string
+		data      []byte
+	}
+)
+
+const fakeToken = "fake-token"
+
+func newFakeRegistry(t *testing.T, username, password string) *fakeRegistry {
+	registry := &fakeRegistry{
+		username:  username,
+		password:  password,
+		token:     fakeToken,
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]fakeManifest),
+	}
+	registry.Server = httptest.NewServer(http.HandlerFunc(registry.serveHTTP))
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.URL, "http://")
+}
+
+func (r *fakeRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		username, password, _ := req.BasicAuth()
+		if username != r.username || password != r.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+		return
+	}
+
+	if r.username != "" && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, r.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		repository, reference, _ := strings.Cut(path, "/manifests/")
+		r.serveManifest(w, req, repository, reference)
+	case strings.Contains(path, "/blobs/uploads/"):
+		repository, _, _ := strings.Cut(path, "/blobs/uploads/")
+		r.serveUpload(w, req, repository)
+	case strings.Contains(path, "/blobs/"):
+		_, digest, _ := strings.Cut(path, "/blobs/")
+		data, ok := r.blobs[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	switch req.Method {
+	case http.MethodGet:
+		m, ok := r.manifests[repository+"@"+reference]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		_, _ = w.Write(m.data)
+	case http.MethodPut:
+		data, _ := io.ReadAll(req.Body)
+		m := fakeManifest{mediaType: req.Header.Get("Content-Type"), data: data}
+		r.manifests[repository+"@"+reference] = m
+		r.manifests[repository+"@"+digest(data)] = m
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func (r *fakeRegistry) serveUpload(w http.ResponseWriter, req *http.Request, repository string) {
+	switch req.Method {
+	case http.MethodPost:
+		w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/upload-id")
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		data, _ := io.ReadAll(req.Body)
+		if digest(data) != req.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest(data)] = data
+		r.uploads++
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// expireToken rejects the tokens issued so far, as a registry does once its short-lived tokens expire
+func (r *fakeRegistry) expireToken() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token += "-renewed"
+}
+
+// pushImage adds an image with a config and a single layer, returning its manifest
+func (r *fakeRegistry) pushImage(repository, tag, content string) []byte {
+	config, layer := []byte(`{"architecture":"`+content+`"}`), []byte(content)
+	r.blobs[digest(config)] = config
+	r.blobs[digest(layer)] = layer
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]interface{}{"digest": digest(config), "size": len(config)},
+		"layers":        []interface{}{map[string]interface{}{"digest": digest(layer), "size": len(layer)}},
+	})
+	m := fakeManifest{mediaType: "application/vnd.oci.image.manifest.v1+json", data: data}
+	r.manifests[repository+"@"+tag] = m
+	r.manifests[repository+"@"+digest(data)] = m
+	return data
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestClient_Copy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("It should copy an image from a registry requiring a token", func(t *testing.T) {
+		src := newFakeRegistry(t, "_json_key", "key")
+		dst := newFakeRegistry(t, "", "")
+		manifest := src.pushImage("jetstack-secure-enterprise/js-operator", "v0.0.1", "amd64")
+
+		client := &images.Client{
+			Credentials: docker.Config{src.host(): {Username: "_json_key", Password: "key"}},
+			PlainHTTP:   []string{src.host(), dst.host()},
+		}
+		srcRef := images.Reference{Registry: src.host(), Repository: "jetstack-secure-enterprise/js-operator", Tag: "v0.0.1"}
+		dstRef := images.Reference{Registry: dst.host(), Repository: "mirror/js-operator", Tag: "v0.0.1"}
+
+		require.NoError(t, client.Copy(ctx, srcRef, dstRef))
+		assert.Equal(t, manifest, dst.manifests["mirror/js-operator@v0.0.1"].data)
+		assert.Equal(t, 2, dst.uploads)
+
+		// copying again should reuse the blobs already in the destination
+		require.NoError(t, client.Copy(ctx, srcRef, dstRef))
+		assert.Equal(t, 2, dst.uploads)
+	})
+
+	t.Run("It should renew a cached token that has expired", func(t *testing.T) {
+		src := newFakeRegistry(t, "_json_key", "key")
+		dst := newFakeRegistry(t, "", "")
+		src.pushImage("js-operator", "v0.0.1", "amd64")
+		src.pushImage("js-operator", "v0.0.2", "arm64")
+
+		client := &images.Client{
+			Credentials: docker.Config{src.host(): {Username: "_json_key", Password: "key"}},
+			PlainHTTP:   []string{src.host(), dst.host()},
+		}
+		srcRef := images.Reference{Registry: src.host(), Repository: "js-operator", Tag: "v0.0.1"}
+		dstRef := images.Reference{Registry: dst.host(), Repository: "js-operator", Tag: "v0.0.1"}
+		require.NoError(t, client.Copy(ctx, srcRef, dstRef))
+
+		src.expireToken()
+
+		srcRef.Tag, dstRef.Tag = "v0.0.2", "v0.0.2"
+		require.NoError(t, client.Copy(ctx, srcRef, dstRef))
+		assert.Contains(t, dst.manifests, "js-operator@v0.0.2")
+	})
+
+	t.Run("It should copy every platform of a multi-platform image", func(t *testing.T) {
+		src := newFakeRegistry(t, "", "")
+		dst := newFakeRegistry(t, "user", "pass")
+		amd64 := src.pushImage("jetstack/preflight", "amd64", "amd64")
+		arm64 := src.pushImage("jetstack/preflight", "arm64", "arm64")
+		index, _ := json.Marshal(map[string]interface{}{
+			"schemaVersion": 2,
+			"manifests": []interface{}{
+				map[string]interface{}{"digest": digest(amd64), "size": len(amd64)},
+				map[string]interface{}{"digest": digest(arm64), "size": len(arm64)},
+			},
+		})
+		src.manifests["jetstack/preflight@v0.1.39"] = fakeManifest{mediaType: "application/vnd.oci.image.index.v1+json", data: index}
+
+		client := &images.Client{
+			Credentials: docker.Config{"http://" + dst.host(): {Auth: "dXNlcjpwYXNz"}},
+			PlainHTTP:   []string{src.host(), dst.host()},
+		}
+		srcRef := images.Reference{Registry: src.host(), Repository: "jetstack/preflight", Tag: "v0.1.39"}
+		dstRef := images.Reference{Registry: dst.host(), Repository: "preflight", Tag: "v0.1.39"}
+
+		require.NoError(t, client.Copy(ctx, srcRef, dstRef))
+		assert.Equal(t, index, dst.manifests["preflight@v0.1.39"].data)
+		assert.Equal(t, "application/vnd.oci.image.index.v1+json", dst.manifests["preflight@v0.1.39"].mediaType)
+		assert.Equal(t, amd64, dst.manifests["preflight@"+digest(amd64)].data)
+		assert.Equal(t, arm64, dst.manifests["preflight@"+digest(arm64)].data)
+		assert.Equal(t, 4, dst.uploads)
+	})
+
+	t.Run("It should return an error when the credentials are rejected", func(t *testing.T) {
+		src := newFakeRegistry(t, "_json_key", "key")
+		dst := newFakeRegistry(t, "", "")
+		src.pushImage("js-operator", "v0.0.1", "amd64")
+
+		client := &images.Client{
+			Credentials: docker.Config{src.host(): {Username: "_json_key", Password: "wrong"}},
+			PlainHTTP:   []string{src.host(), dst.host()},
+		}
+		srcRef := images.Reference{Registry: src.host(), Repository: "js-operator", Tag: "v0.0.1"}
+		dstRef := images.Reference{Registry: dst.host(), Repository: "js-operator", Tag: "v0.0.1"}
+
+		err := client.Copy(ctx, srcRef, dstRef)
+		assert.ErrorContains(t, err, "failed to authenticate with "+src.host()+": failed to fetch token: 401 Unauthorized")
+	})
+
+	t.Run("It should return an error for a missing image", func(t *testing.T) {
+		src := newFakeRegistry(t, "", "")
+		dst := newFakeRegistry(t, "", "")
+
+		client := &images.Client{PlainHTTP: []string{src.host(), dst.host()}}
+		srcRef := images.Reference{Registry: src.host(), Repository: "js-operator", Tag: "v0.0.1"}
+		dstRef := images.Reference{Registry: dst.host(), Repository: "js-operator", Tag: "v0.0.1"}
+
+		err := client.Copy(ctx, srcRef, dstRef)
+		assert.EqualError(t, err, "failed to fetch manifest v0.0.1 of "+src.host()+"/js-operator: 404 Not Found: MANIFEST_UNKNOWN: manifest unknown")
+	})
+}
@@ -0,0 +1,109 @@
+// Package images contains types and methods for listing the images used by Jetstack Secure and copying them between
+// registries using the OCI distribution API.
+package images
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// dockerHubRegistry is the registry used by image references that do not name one
+	dockerHubRegistry = "docker.io"
+	// dockerHubAPIHost is the host serving the distribution API for Docker Hub
+	dockerHubAPIHost = "registry-1.docker.io"
+)
+
+// The Reference type describes a reference to an image in a registry, which must be pinned to a tag, a digest or
+// both.
+type Reference struct {
+	// Registry is the host, and optional port, of the registry
+	Registry string
+	// Repository is the path of the image within the registry
+	Repository string
+	// Tag is the tag of the image, it may be empty if Digest is set
+	Tag string
+	// Digest is the digest of the image manifest, it may be empty if Tag is set
+	Digest string
+}
+
+// ParseReference parses an image reference in the form used by Kubernetes, such as
+// "eu.gcr.io/jetstack-secure-enterprise/js-operator:v0.0.1". References without a registry use Docker Hub.
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+
+	name := image
+	if before, digest, ok := strings.Cut(name, "@"); ok {
+		name, ref.Digest = before, digest
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+
+	ref.Registry, ref.Repository = dockerHubRegistry, name
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, ref.Repository = first, rest
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	switch {
+	case ref.Repository == "":
+		return Reference{}, fmt.Errorf("image %q has no repository", image)
+	case ref.Tag == "" && ref.Digest == "":
+		return Reference{}, fmt.Errorf("image %q has no tag or digest", image)
+	case ref.Digest != "" && !strings.HasPrefix(ref.Digest, "sha256:"):
+		return Reference{}, fmt.Errorf("image %q has an unsupported digest, only sha256 is supported", image)
+	}
+
+	return ref, nil
+}
+
+// MirrorReference returns the reference that an image is copied to when mirroring it to a registry and optional
+// path, such as "registry.internal/jetstack". Only the last path component of the image's repository is kept, so
+// that components installed with a custom image registry are able to find their mirrored images.
+func MirrorReference(ref Reference, to string) (Reference, error) {
+	to = strings.TrimSuffix(to, "/")
+	registry, prefix, _ := strings.Cut(to, "/")
+	if registry == "" {
+		return Reference{}, fmt.Errorf("invalid registry %q", to)
+	}
+
+	return Reference{
+		Registry:   registry,
+		Repository: strings.TrimPrefix(prefix+"/"+path.Base(ref.Repository), "/"),
+		Tag:        ref.Tag,
+		Digest:     ref.Digest,
+	}, nil
+}
+
+// String returns the reference in the form accepted by ParseReference.
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// apiHost returns the host serving the distribution API for the registry
+func (r Reference) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIHost
+	}
+	return r.Registry
+}
+
+// manifestReference returns the tag or digest used to fetch the image manifest, the digest is preferred as it
+// cannot change
+func (r Reference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
@@ -0,0 +1,76 @@
+package images_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jetstack/jsctl/internal/images"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := map[string]struct {
+		image       string
+		expected    images.Reference
+		expectedErr string
+	}{
+		"registry with a path": {
+			image:    "eu.gcr.io/jetstack-secure-enterprise/js-operator:v0.0.1-alpha.20",
+			expected: images.Reference{Registry: "eu.gcr.io", Repository: "jetstack-secure-enterprise/js-operator", Tag: "v0.0.1-alpha.20"},
+		},
+		"registry with a port and digest": {
+			image: "localhost:5000/preflight:v0.1.39@sha256:abc",
+			expected: images.Reference{
+				Registry: "localhost:5000", Repository: "preflight", Tag: "v0.1.39", Digest: "sha256:abc",
+			},
+		},
+		"docker hub image": {
+			image:    "nginx:1.23",
+			expected: images.Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.23"},
+		},
+		"docker hub image with an organisation": {
+			image:    "jetstack/preflight:v0.1.39",
+			expected: images.Reference{Registry: "docker.io", Repository: "jetstack/preflight", Tag: "v0.1.39"},
+		},
+		"no tag": {
+			image:       "quay.io/jetstack/preflight",
+			expectedErr: `image "quay.io/jetstack/preflight" has no tag or digest`,
+		},
+		"unsupported digest": {
+			image:       "quay.io/jetstack/preflight@md5:abc",
+			expectedErr: `image "quay.io/jetstack/preflight@md5:abc" has an unsupported digest, only sha256 is supported`,
+		},
+	}
+
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, err := images.ParseReference(scenario.image)
+			if scenario.expectedErr != "" {
+				assert.EqualError(t, err, scenario.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, scenario.expected, ref)
+		})
+	}
+}
+
+func TestMirrorReference(t *testing.T) {
+	ref := images.Reference{Registry: "eu.gcr.io", Repository: "jetstack-secure-enterprise/js-operator", Tag: "v0.0.1"}
+
+	tests := map[string]struct {
+		to       string
+		expected string
+	}{
+		"registry":           {to: "registry.internal", expected: "registry.internal/js-operator:v0.0.1"},
+		"registry with path": {to: "registry.internal:5000/mirror/jetstack/", expected: "registry.internal:5000/mirror/jetstack/js-operator:v0.0.1"},
+	}
+
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			mirrored, err := images.MirrorReference(ref, scenario.to)
+			assert.NoError(t, err)
+			assert.Equal(t, scenario.expected, mirrored.String())
+		})
+	}
+}
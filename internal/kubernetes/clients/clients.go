@@ -4,7 +4,10 @@ import (
 	"fmt"
 
 	v1alpha1approverpolicy "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1extensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/client-go/rest"
 )
@@ -63,6 +66,42 @@ func NewCertificateRequestClient(config *rest.Config) (Generic[*cmapi.Certificat
 	return genericClient, nil
 }
 
+// NewOrderClient returns an instance of a generic client for querying cert-manager ACME Orders
+func NewOrderClient(config *rest.Config) (Generic[*cmacme.Order, *cmacme.OrderList], error) {
+	genericClient, err := NewGenericClient[*cmacme.Order, *cmacme.OrderList](
+		&GenericClientOptions{
+			RestConfig: config,
+			APIPath:    "/apis",
+			Group:      cmacme.SchemeGroupVersion.Group,
+			Version:    cmacme.SchemeGroupVersion.Version,
+			Kind:       "orders",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating generic client: %w", err)
+	}
+
+	return genericClient, nil
+}
+
+// NewChallengeClient returns an instance of a generic client for querying cert-manager ACME Challenges
+func NewChallengeClient(config *rest.Config) (Generic[*cmacme.Challenge, *cmacme.ChallengeList], error) {
+	genericClient, err := NewGenericClient[*cmacme.Challenge, *cmacme.ChallengeList](
+		&GenericClientOptions{
+			RestConfig: config,
+			APIPath:    "/apis",
+			Group:      cmacme.SchemeGroupVersion.Group,
+			Version:    cmacme.SchemeGroupVersion.Version,
+			Kind:       "challenges",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating generic client: %w", err)
+	}
+
+	return genericClient, nil
+}
+
 // NewCertificateRequestPolicyClient returns an instance of a generic client for
 // querying approver policy CertificateRequestPolicies
 func NewCertificateRequestPolicyClient(config *rest.Config) (Generic[*v1alpha1approverpolicy.CertificateRequestPolicy, *v1alpha1approverpolicy.CertificateRequestPolicyList], error) {
@@ -81,3 +120,39 @@ func NewCertificateRequestPolicyClient(config *rest.Config) (Generic[*v1alpha1ap
 
 	return genericClient, nil
 }
+
+// NewDeploymentClient returns an instance of a generic client for querying Deployments
+func NewDeploymentClient(config *rest.Config) (Generic[*appsv1.Deployment, *appsv1.DeploymentList], error) {
+	genericClient, err := NewGenericClient[*appsv1.Deployment, *appsv1.DeploymentList](
+		&GenericClientOptions{
+			RestConfig: config,
+			APIPath:    "/apis",
+			Group:      appsv1.GroupName,
+			Version:    appsv1.SchemeGroupVersion.Version,
+			Kind:       "deployments",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating generic client: %w", err)
+	}
+
+	return genericClient, nil
+}
+
+// NewSecretClient returns an instance of a generic client for querying Secrets
+func NewSecretClient(config *rest.Config) (Generic[*corev1.Secret, *corev1.SecretList], error) {
+	genericClient, err := NewGenericClient[*corev1.Secret, *corev1.SecretList](
+		&GenericClientOptions{
+			RestConfig: config,
+			APIPath:    "/api",
+			Group:      corev1.GroupName,
+			Version:    corev1.SchemeGroupVersion.Version,
+			Kind:       "secrets",
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating generic client: %w", err)
+	}
+
+	return genericClient, nil
+}
@@ -11,6 +11,7 @@ type FakeGeneric[T, ListT runtime.Object] struct {
 	FakeList    func(context.Context, *GenericRequestOptions, ListT) error
 	FakePresent func(context.Context, *GenericRequestOptions) (bool, error)
 	FakePatch   func(context.Context, *GenericRequestOptions, []byte) error
+	FakeDelete  func(context.Context, *GenericRequestOptions) error
 }
 
 var _ Generic[*runtime.Unknown, *runtime.Unknown] = &FakeGeneric[*runtime.Unknown, *runtime.Unknown]{}
@@ -29,3 +30,7 @@ func (f *FakeGeneric[T, ListT]) Present(ctx context.Context, options *GenericReq
 func (f *FakeGeneric[T, ListT]) Patch(ctx context.Context, options *GenericRequestOptions, patch []byte) error {
 	return f.FakePatch(ctx, options, patch)
 }
+
+func (f *FakeGeneric[T, ListT]) Delete(ctx context.Context, options *GenericRequestOptions) error {
+	return f.FakeDelete(ctx, options)
+}
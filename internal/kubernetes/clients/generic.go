@@ -19,6 +19,7 @@ type Generic[T, ListT runtime.Object] interface {
 	List(context.Context, *GenericRequestOptions, ListT) error
 	Present(ctx context.Context, options *GenericRequestOptions) (bool, error)
 	Patch(ctx context.Context, options *GenericRequestOptions, patch []byte) error
+	Delete(ctx context.Context, options *GenericRequestOptions) error
 }
 
 type generic[T, ListT runtime.Object] struct {
@@ -48,6 +49,11 @@ type GenericRequestOptions struct {
 
 	// DropFields is a list of fields to drop from the response
 	DropFields []string
+
+	// PatchType is the type of patch sent by Patch, it defaults to a
+	// strategic merge patch. Custom resources do not support strategic merge
+	// patches, so a JSON merge patch must be used for them instead.
+	PatchType types.PatchType
 }
 
 // NewGenericClient returns a new instance of a Generic client configured to
@@ -208,7 +214,12 @@ func (c *generic[T, ListT]) Present(ctx context.Context, options *GenericRequest
 }
 
 func (c *generic[T, ListT]) Patch(ctx context.Context, options *GenericRequestOptions, patch []byte) error {
-	r := c.restClient.Patch(types.StrategicMergePatchType).Body(patch).Resource(c.resource)
+	patchType := options.PatchType
+	if patchType == "" {
+		patchType = types.StrategicMergePatchType
+	}
+
+	r := c.restClient.Patch(patchType).Body(patch).Resource(c.resource)
 
 	if options.Namespace != "" {
 		r = r.Namespace(options.Namespace)
@@ -224,3 +235,21 @@ func (c *generic[T, ListT]) Patch(ctx context.Context, options *GenericRequestOp
 
 	return nil
 }
+
+func (c *generic[T, ListT]) Delete(ctx context.Context, options *GenericRequestOptions) error {
+	r := c.restClient.Delete().Resource(c.resource)
+
+	if options.Namespace != "" {
+		r = r.Namespace(options.Namespace)
+	}
+	if options.Name != "" {
+		r = r.Name(options.Name)
+	}
+
+	err := r.Do(ctx).Error()
+	if err != nil {
+		return fmt.Errorf("error deleting resource: %w", err)
+	}
+
+	return nil
+}
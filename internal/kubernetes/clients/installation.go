@@ -8,6 +8,7 @@ import (
 
 	"github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 )
 
@@ -62,6 +63,10 @@ func NewInstallationClient(config *rest.Config) (*InstallationClient, error) {
 	return &InstallationClient{client: genericClient}, nil
 }
 
+// InstallationName is the name of the single Installation resource that jsctl applies to, and reads from, a cluster.
+// It matches the jetstack-secure namespace that the operator installs components into.
+const InstallationName = "jetstack-secure"
+
 // Status returns a slice of ComponentStatus types that describe the state of individual components installed by the
 // operator. Returns ErrNoInstallation if an Installation resource cannot be found in the cluster. It uses the
 // status conditions on an Installation resource and maps those to a ComponentStatus, the ComponentStatus.Name field
@@ -71,10 +76,23 @@ func (ic *InstallationClient) Status(ctx context.Context) ([]ComponentStatus, er
 	var err error
 	var installation v1alpha1.Installation
 
-	// this is the expected name of the single Installation resource in the cluster
-	name := "installation"
+	err = ic.client.Get(ctx, &GenericRequestOptions{Name: InstallationName}, &installation)
+	switch {
+	case apiErrors.IsNotFound(err):
+		return nil, ErrNoInstallation
+	case err != nil:
+		return nil, fmt.Errorf("error getting installation: %w", err)
+	}
+
+	return componentStatuses(&installation), nil
+}
+
+// Get returns the named Installation resource. Returns ErrNoInstallation if the Installation resource cannot be found in
+// the cluster.
+func (ic *InstallationClient) Get(ctx context.Context, name string) (*v1alpha1.Installation, error) {
+	var installation v1alpha1.Installation
 
-	err = ic.client.Get(ctx, &GenericRequestOptions{Name: name}, &installation)
+	err := ic.client.Get(ctx, &GenericRequestOptions{Name: name}, &installation)
 	switch {
 	case apiErrors.IsNotFound(err):
 		return nil, ErrNoInstallation
@@ -82,6 +100,67 @@ func (ic *InstallationClient) Status(ctx context.Context) ([]ComponentStatus, er
 		return nil, fmt.Errorf("error getting installation: %w", err)
 	}
 
+	return &installation, nil
+}
+
+// Patch applies a JSON merge patch to the named Installation resource.
+func (ic *InstallationClient) Patch(ctx context.Context, name string, patch []byte) error {
+	err := ic.client.Patch(ctx, &GenericRequestOptions{Name: name, PatchType: types.MergePatchType}, patch)
+	if err != nil {
+		return fmt.Errorf("error patching installation: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes the named Installation resource, the operator then removes the components it manages. Returns
+// ErrNoInstallation if the Installation resource cannot be found in the cluster.
+func (ic *InstallationClient) Delete(ctx context.Context, name string) error {
+	err := ic.client.Delete(ctx, &GenericRequestOptions{Name: name})
+	switch {
+	case apiErrors.IsNotFound(err):
+		return ErrNoInstallation
+	case err != nil:
+		return fmt.Errorf("error deleting installation: %w", err)
+	}
+
+	return nil
+}
+
+// Ready returns true if every component of the named Installation resource is ready and its status reflects the
+// latest generation of the Installation's spec. The individual component statuses are also returned so that progress
+// can be reported. Returns ErrNoInstallation if the Installation resource cannot be found in the cluster.
+func (ic *InstallationClient) Ready(ctx context.Context, name string) (bool, []ComponentStatus, error) {
+	var installation v1alpha1.Installation
+
+	err := ic.client.Get(ctx, &GenericRequestOptions{Name: name}, &installation)
+	switch {
+	case apiErrors.IsNotFound(err):
+		return false, nil, ErrNoInstallation
+	case err != nil:
+		return false, nil, fmt.Errorf("error getting installation: %w", err)
+	}
+
+	statuses := componentStatuses(&installation)
+	if len(statuses) == 0 {
+		return false, statuses, nil
+	}
+
+	for _, condition := range installation.Status.Conditions {
+		if condition.ObservedGeneration < installation.Generation {
+			return false, statuses, nil
+		}
+	}
+	for _, status := range statuses {
+		if !status.Ready {
+			return false, statuses, nil
+		}
+	}
+
+	return true, statuses, nil
+}
+
+func componentStatuses(installation *v1alpha1.Installation) []ComponentStatus {
 	statuses := make([]ComponentStatus, 0)
 	for _, condition := range installation.Status.Conditions {
 		componentStatus := ComponentStatus{
@@ -108,5 +187,5 @@ func (ic *InstallationClient) Status(ctx context.Context) ([]ComponentStatus, er
 		return statuses[i].Name < statuses[j].Name
 	})
 
-	return statuses, nil
+	return statuses
 }
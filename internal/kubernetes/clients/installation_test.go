@@ -0,0 +1,129 @@
+package clients
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestInstallationClient_Ready(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		installation *v1alpha1.Installation
+		wantReady    bool
+		wantErr      error
+	}{
+		"missing installation should produce an error": {
+			wantErr: ErrNoInstallation,
+		},
+		"installation without conditions should not be ready": {
+			installation: &v1alpha1.Installation{},
+		},
+		"installation with unready component should not be ready": {
+			installation: &v1alpha1.Installation{
+				Status: v1alpha1.InstallationStatus{
+					Conditions: []v1alpha1.InstallationCondition{
+						{Type: v1alpha1.InstallationConditionCertManagerReady, Status: v1alpha1.ConditionTrue},
+						{Type: v1alpha1.InstallationConditionIstioCSRReady, Status: v1alpha1.ConditionFalse, Message: "issuer not found"},
+					},
+				},
+			},
+		},
+		"installation with stale conditions should not be ready": {
+			installation: &v1alpha1.Installation{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: v1alpha1.InstallationStatus{
+					Conditions: []v1alpha1.InstallationCondition{
+						{Type: v1alpha1.InstallationConditionCertManagerReady, Status: v1alpha1.ConditionTrue, ObservedGeneration: 1},
+					},
+				},
+			},
+		},
+		"installation with ready components should be ready": {
+			installation: &v1alpha1.Installation{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: v1alpha1.InstallationStatus{
+					Conditions: []v1alpha1.InstallationCondition{
+						{Type: v1alpha1.InstallationConditionCertManagerReady, Status: v1alpha1.ConditionTrue, ObservedGeneration: 2},
+						{Type: v1alpha1.InstallationConditionManifestsReady, Status: v1alpha1.ConditionTrue, ObservedGeneration: 2},
+					},
+				},
+			},
+			wantReady: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			ic := &InstallationClient{
+				client: &FakeGeneric[*v1alpha1.Installation, *v1alpha1.InstallationList]{
+					FakeGet: func(_ context.Context, options *GenericRequestOptions, installation *v1alpha1.Installation) error {
+						require.Equal(t, "jetstack-secure", options.Name)
+						if scenario.installation == nil {
+							return apiErrors.NewNotFound(schema.GroupResource{Resource: "installations"}, options.Name)
+						}
+						*installation = *scenario.installation
+						return nil
+					},
+				},
+			}
+
+			ready, _, err := ic.Ready(ctx, "jetstack-secure")
+			if scenario.wantErr != nil {
+				assert.ErrorIs(t, err, scenario.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, scenario.wantReady, ready)
+		})
+	}
+}
+
+func TestInstallationClient_Patch(t *testing.T) {
+	var gotOptions *GenericRequestOptions
+	var gotPatch []byte
+
+	ic := &InstallationClient{
+		client: &FakeGeneric[*v1alpha1.Installation, *v1alpha1.InstallationList]{
+			FakePatch: func(_ context.Context, options *GenericRequestOptions, patch []byte) error {
+				gotOptions = options
+				gotPatch = patch
+				return nil
+			},
+		},
+	}
+
+	patch := []byte(`{"spec":{"istioCSR":null}}`)
+	require.NoError(t, ic.Patch(context.Background(), "jetstack-secure", patch))
+
+	assert.Equal(t, "jetstack-secure", gotOptions.Name)
+	assert.Equal(t, types.MergePatchType, gotOptions.PatchType)
+	assert.Equal(t, patch, gotPatch)
+}
+
+func TestInstallationClient_Status(t *testing.T) {
+	ic := &InstallationClient{
+		client: &FakeGeneric[*v1alpha1.Installation, *v1alpha1.InstallationList]{
+			FakeGet: func(_ context.Context, options *GenericRequestOptions, installation *v1alpha1.Installation) error {
+				if options.Name != InstallationName {
+					return apiErrors.NewNotFound(schema.GroupResource{Resource: "installations"}, options.Name)
+				}
+				installation.Status.Conditions = []v1alpha1.InstallationCondition{
+					{Type: v1alpha1.InstallationConditionCertManagerReady, Status: v1alpha1.ConditionTrue},
+				}
+				return nil
+			},
+		},
+	}
+
+	statuses, err := ic.Status(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, statuses)
+}
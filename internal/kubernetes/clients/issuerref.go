@@ -0,0 +1,91 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+type (
+	// The IssuerRefClient is used to check that the issuer referenced by a component exists within a Kubernetes
+	// cluster. It supports the cert-manager issuers and any external issuer whose CRD is installed.
+	IssuerRefClient struct {
+		client dynamic.Interface
+		mapper meta.RESTMapper
+	}
+)
+
+var (
+	// ErrNoIssuer is the error given when a referenced issuer does not exist.
+	ErrNoIssuer = errors.New("no issuer")
+
+	// ErrNoIssuerKind is the error given when the kind of a referenced issuer is not installed in the cluster.
+	ErrNoIssuerKind = errors.New("no issuer kind")
+)
+
+// NewIssuerRefClient returns a new instance of the IssuerRefClient that will interact with the Kubernetes cluster
+// specified in the rest.Config.
+func NewIssuerRefClient(config *rest.Config) (*IssuerRefClient, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating discovery client: %w", err)
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(discoveryClient)
+	if err != nil {
+		return nil, fmt.Errorf("error discovering API resources: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	return &IssuerRefClient{
+		client: client,
+		mapper: restmapper.NewDiscoveryRESTMapper(groupResources),
+	}, nil
+}
+
+// Check returns nil if the referenced issuer exists. Namespaced issuers are looked up in the given namespace, while
+// the namespace is ignored for cluster scoped issuers. Returns ErrNoIssuerKind if the kind of issuer is not installed
+// in the cluster, and ErrNoIssuer if the issuer does not exist.
+func (c *IssuerRefClient) Check(ctx context.Context, ref *certmanagermetav1.ObjectReference, namespace string) error {
+	mapping, err := c.mapper.RESTMapping(schema.GroupKind{Group: ref.Group, Kind: ref.Kind})
+	switch {
+	case meta.IsNoMatchError(err):
+		return fmt.Errorf("%w: %s.%s is not installed", ErrNoIssuerKind, ref.Kind, ref.Group)
+	case err != nil:
+		return fmt.Errorf("error creating REST mapping for %s.%s: %w", ref.Kind, ref.Group, err)
+	}
+
+	resource := c.client.Resource(mapping.Resource)
+	description := fmt.Sprintf("%s %s", ref.Kind, ref.Name)
+
+	var getErr error
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		description = fmt.Sprintf("%s %s/%s", ref.Kind, namespace, ref.Name)
+		_, getErr = resource.Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	} else {
+		_, getErr = resource.Get(ctx, ref.Name, metav1.GetOptions{})
+	}
+
+	switch {
+	case apiErrors.IsNotFound(getErr):
+		return fmt.Errorf("%w: %s not found", ErrNoIssuer, description)
+	case getErr != nil:
+		return fmt.Errorf("error getting %s: %w", description, getErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+func TestIssuerRefClient_Check(t *testing.T) {
+	ctx := context.Background()
+
+	issuerGVK := schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"}
+	clusterIssuerGVK := schema.GroupVersionKind{Group: "cas-issuer.jetstack.io", Version: "v1beta1", Kind: "GoogleCASClusterIssuer"}
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{issuerGVK.GroupVersion(), clusterIssuerGVK.GroupVersion()})
+	mapper.Add(issuerGVK, meta.RESTScopeNamespace)
+	mapper.Add(clusterIssuerGVK, meta.RESTScopeRoot)
+
+	// the API server only knows the istio-system/istio-ca Issuer and the google-cas GoogleCASClusterIssuer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apis/cert-manager.io/v1/namespaces/istio-system/issuers/istio-ca",
+			"/apis/cas-issuer.jetstack.io/v1beta1/googlecasclusterissuers/google-cas":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"apiVersion":"v1","kind":"Unknown","metadata":{"name":"found"}}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+		}
+	}))
+	defer server.Close()
+
+	dynamicClient, err := dynamic.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	client := &IssuerRefClient{
+		client: dynamicClient,
+		mapper: mapper,
+	}
+
+	tests := map[string]struct {
+		ref       *certmanagermetav1.ObjectReference
+		namespace string
+		wantErr   error
+	}{
+		"namespaced issuer should be found in its namespace": {
+			ref:       &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "Issuer", Group: "cert-manager.io"},
+			namespace: "istio-system",
+		},
+		"namespaced issuer in another namespace should not be found": {
+			ref:       &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "Issuer", Group: "cert-manager.io"},
+			namespace: "default",
+			wantErr:   ErrNoIssuer,
+		},
+		"external cluster issuer should be found in any namespace": {
+			ref:       &certmanagermetav1.ObjectReference{Name: "google-cas", Kind: "GoogleCASClusterIssuer", Group: "cas-issuer.jetstack.io"},
+			namespace: "istio-system",
+		},
+		"issuer of a kind that is not installed should produce an error": {
+			ref:     &certmanagermetav1.ObjectReference{Name: "istio-ca", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+			wantErr: ErrNoIssuerKind,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := client.Check(ctx, scenario.ref, scenario.namespace)
+			if scenario.wantErr != nil {
+				assert.ErrorIs(t, err, scenario.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package ownerrefs removes cert-manager Certificate owner references from
+// secrets, recording them in a journal so that they can be restored later.
+package ownerrefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// DefaultJournalPath is the default location of the journal of owner
+// references removed from secrets
+const DefaultJournalPath = "certificate-owner-refs-journal.json"
+
+// Journal records Certificate owner references removed from secrets so that
+// they can be restored later
+type Journal struct {
+	Entries []JournalEntry `json:"entries"`
+}
+
+// JournalEntry is a single Certificate owner reference removed from a secret
+type JournalEntry struct {
+	Namespace       string                `json:"namespace"`
+	SecretName      string                `json:"secretName"`
+	CertificateName string                `json:"certificateName"`
+	CertificateUID  k8stypes.UID          `json:"certificateUID"`
+	OwnerReference  metav1.OwnerReference `json:"ownerReference"`
+}
+
+// Add records the owner references removed from secret
+func (j *Journal) Add(secret *corev1.Secret, removed []metav1.OwnerReference) {
+	for _, ownerRef := range removed {
+		j.Entries = append(j.Entries, JournalEntry{
+			Namespace:       secret.Namespace,
+			SecretName:      secret.Name,
+			CertificateName: ownerRef.Name,
+			CertificateUID:  ownerRef.UID,
+			OwnerReference:  ownerRef,
+		})
+	}
+}
+
+// BySecret groups the journal entries by secret, in the order that the secrets
+// first appear in the journal
+func (j Journal) BySecret() [][]JournalEntry {
+	var grouped [][]JournalEntry
+	index := map[string]int{}
+	for _, entry := range j.Entries {
+		key := entry.Namespace + "/" + entry.SecretName
+		i, ok := index[key]
+		if !ok {
+			i = len(grouped)
+			index[key] = i
+			grouped = append(grouped, nil)
+		}
+		grouped[i] = append(grouped[i], entry)
+	}
+	return grouped
+}
+
+// ReadJournal reads a journal written by AppendJournal
+func ReadJournal(path string) (Journal, error) {
+	var journal Journal
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return journal, fmt.Errorf("error reading journal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return journal, fmt.Errorf("error parsing journal %s: %w", path, err)
+	}
+
+	return journal, nil
+}
+
+// AppendJournal adds the entries of journal to the journal at path, creating
+// it if it does not exist
+func AppendJournal(path string, journal Journal) error {
+	existing, err := ReadJournal(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	}
+
+	existing.Entries = append(existing.Entries, journal.Entries...)
+
+	data, err := json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling journal: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+
+	return nil
+}
+
+// WithoutCertificateOwnerRefs returns a copy of the secret without any
+// Certificate owner references and the owner references that were removed
+func WithoutCertificateOwnerRefs(secret *corev1.Secret) (*corev1.Secret, []metav1.OwnerReference) {
+	var removed []metav1.OwnerReference
+	newSecret := secret.DeepCopy()
+	newSecret.OwnerReferences = []metav1.OwnerReference{}
+
+	for _, ownerRef := range secret.OwnerReferences {
+		if ownerRef.Kind == cmapi.CertificateKind {
+			removed = append(removed, ownerRef)
+			continue
+		}
+		newSecret.OwnerReferences = append(newSecret.OwnerReferences, ownerRef)
+	}
+
+	return newSecret, removed
+}
+
+// SecretPatch returns a strategic merge patch to update secret to newSecret
+func SecretPatch(secret, newSecret *corev1.Secret) ([]byte, error) {
+	secretData, err := json.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling secret: %s", err)
+	}
+	newSecretData, err := json.Marshal(newSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling new secret: %s", err)
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(secretData, newSecretData, corev1.Secret{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating patch for secret %s: %s", secret.Name, err)
+	}
+
+	return patch, nil
+}
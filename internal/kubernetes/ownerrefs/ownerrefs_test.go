@@ -0,0 +1,102 @@
+package ownerrefs
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithoutCertificateOwnerRefs(t *testing.T) {
+	certificateRef := metav1.OwnerReference{Kind: cmapi.CertificateKind, Name: "foo", UID: "1"}
+	deploymentRef := metav1.OwnerReference{Kind: "Deployment", Name: "bar", UID: "2"}
+	configMapRef := metav1.OwnerReference{Kind: "ConfigMap", Name: "baz", UID: "3"}
+
+	tests := map[string]struct {
+		ownerRefs     []metav1.OwnerReference
+		wantOwnerRefs []metav1.OwnerReference
+		wantRemoved   []metav1.OwnerReference
+	}{
+		"secret without owner references should be unchanged": {
+			ownerRefs:     nil,
+			wantOwnerRefs: []metav1.OwnerReference{},
+		},
+		"certificate owner reference should be removed": {
+			ownerRefs:     []metav1.OwnerReference{certificateRef},
+			wantOwnerRefs: []metav1.OwnerReference{},
+			wantRemoved:   []metav1.OwnerReference{certificateRef},
+		},
+		"all other owner references should be kept": {
+			ownerRefs:     []metav1.OwnerReference{deploymentRef, certificateRef, configMapRef},
+			wantOwnerRefs: []metav1.OwnerReference{deploymentRef, configMapRef},
+			wantRemoved:   []metav1.OwnerReference{certificateRef},
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "foo",
+					Namespace:       "foo",
+					OwnerReferences: scenario.ownerRefs,
+				},
+			}
+
+			got, removed := WithoutCertificateOwnerRefs(secret)
+			if !reflect.DeepEqual(got.OwnerReferences, scenario.wantOwnerRefs) {
+				t.Errorf("WithoutCertificateOwnerRefs() owner references = %v, want %v", got.OwnerReferences, scenario.wantOwnerRefs)
+			}
+			if !reflect.DeepEqual(removed, scenario.wantRemoved) {
+				t.Errorf("WithoutCertificateOwnerRefs() removed = %v, want %v", removed, scenario.wantRemoved)
+			}
+			if !reflect.DeepEqual(secret.OwnerReferences, scenario.ownerRefs) {
+				t.Errorf("WithoutCertificateOwnerRefs() modified the original secret")
+			}
+		})
+	}
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+
+	first := Journal{Entries: []JournalEntry{
+		{Namespace: "foo", SecretName: "a", CertificateName: "a", CertificateUID: "1"},
+		{Namespace: "bar", SecretName: "b", CertificateName: "b", CertificateUID: "2"},
+	}}
+	second := Journal{Entries: []JournalEntry{
+		{Namespace: "foo", SecretName: "a", CertificateName: "c", CertificateUID: "3"},
+	}}
+
+	if _, err := ReadJournal(path); err == nil {
+		t.Fatalf("ReadJournal() expected error for missing journal")
+	}
+
+	if err := AppendJournal(path, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendJournal(path, second); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(append([]JournalEntry{}, first.Entries...), second.Entries...)
+	if !reflect.DeepEqual(got.Entries, want) {
+		t.Fatalf("ReadJournal() = %v, want %v", got.Entries, want)
+	}
+
+	grouped := got.BySecret()
+	wantGrouped := [][]JournalEntry{
+		{first.Entries[0], second.Entries[0]},
+		{first.Entries[1]},
+	}
+	if !reflect.DeepEqual(grouped, wantGrouped) {
+		t.Errorf("BySecret() = %v, want %v", grouped, wantGrouped)
+	}
+}
@@ -0,0 +1,264 @@
+package restore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/backup"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// operatorClusterResourceNamespace is the namespace the operator's cert-manager
+// reads the Secrets of ClusterIssuers from
+const operatorClusterResourceNamespace = "jetstack-secure"
+
+// CertManagerConfig is the configuration of a cert-manager installation read
+// from its Deployments, in the form used by an Installation.
+type CertManagerConfig struct {
+	// Namespace is the namespace cert-manager is installed in
+	Namespace string
+	// Version is the version of the controller image, it is empty if the
+	// image is referenced by digest
+	Version string
+	// Replicas is the replica count of the controller
+	Replicas int
+	// WebhookReplicas is the replica count of the webhook, it is zero if
+	// there is no webhook Deployment
+	WebhookReplicas int
+
+	// Unsupported lists the settings that cannot be represented in an
+	// Installation, and so are lost when moving to an operator managed
+	// installation.
+	Unsupported []string
+}
+
+var (
+	// ignoredArgs are the arguments that describe an installation rather than
+	// how cert-manager behaves, these are set by the operator for its own
+	// installation. Arguments with a value are only ignored with that value.
+	ignoredArgs = map[string]map[string]string{
+		"cert-manager-controller": {
+			"v":                         "",
+			"logging-format":            "",
+			"leader-election-namespace": "",
+			"acme-http01-solver-image":  "",
+			"max-concurrent-challenges": "60",
+		},
+		"cert-manager-webhook": {
+			"v":                                   "",
+			"logging-format":                      "",
+			"secure-port":                         "",
+			"dynamic-serving-ca-secret-namespace": "",
+			"dynamic-serving-ca-secret-name":      "",
+			"dynamic-serving-dns-names":           "",
+		},
+	}
+
+	// argDescriptions describe the arguments commonly used to configure
+	// cert-manager when reporting them as unsupported
+	argDescriptions = map[string]string{
+		"feature-gates":                    "feature gates",
+		"dns01-recursive-nameservers":      "DNS01 recursive nameservers",
+		"dns01-recursive-nameservers-only": "DNS01 recursive nameservers",
+		"default-issuer-name":              "ingress-shim default issuer",
+		"default-issuer-kind":              "ingress-shim default issuer",
+		"default-issuer-group":             "ingress-shim default issuer",
+		"auto-certificate-annotations":     "ingress-shim annotations",
+		"enable-certificate-owner-ref":     "certificate owner references",
+	}
+)
+
+// ExtractOperatorManageableIssuersFromCluster reads the issuers in the cluster,
+// sorting them as ExtractOperatorManageableIssuers does for a backup file.
+func ExtractOperatorManageableIssuersFromCluster(ctx context.Context, cfg *rest.Config) (*RestoredIssuers, error) {
+	clusterBackup, err := backup.FetchClusterBackup(ctx, backup.ClusterBackupOptions{
+		RestConfig:      cfg,
+		FormatResources: true,
+		IncludeIssuers:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*unstructured.Unstructured
+	for _, item := range *clusterBackup {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal issuer: %w", err)
+		}
+
+		var resource unstructured.Unstructured
+		if err := json.Unmarshal(data, &resource); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal issuer: %w", err)
+		}
+		resources = append(resources, &resource)
+	}
+
+	return ExtractOperatorManageableIssuers(resources)
+}
+
+// FetchCertManagerConfig finds the cert-manager Deployments in the cluster and
+// reads their configuration.
+func FetchCertManagerConfig(ctx context.Context, cfg *rest.Config) (*CertManagerConfig, error) {
+	client, err := clients.NewDeploymentClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var deployments appsv1.DeploymentList
+	if err := client.List(ctx, &clients.GenericRequestOptions{}, &deployments); err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	return ExtractCertManagerConfig(deployments.Items)
+}
+
+// ExtractCertManagerConfig reads the configuration of cert-manager from its
+// controller and webhook Deployments, which are found by their images.
+func ExtractCertManagerConfig(deployments []appsv1.Deployment) (*CertManagerConfig, error) {
+	controller, controllerContainer, err := findCertManagerDeployment(deployments, "cert-manager-controller")
+	if err != nil {
+		return nil, err
+	}
+	if controller == nil {
+		return nil, fmt.Errorf("no cert-manager controller deployment found")
+	}
+
+	config := &CertManagerConfig{
+		Namespace: controller.Namespace,
+		Version:   imageTag(controllerContainer.Image),
+		Replicas:  deploymentReplicas(controller),
+	}
+	if config.Version == "" {
+		config.Unsupported = append(config.Unsupported, fmt.Sprintf("controller image %s: the version cannot be determined from an image digest", controllerContainer.Image))
+	}
+
+	args := parseArgs(controllerContainer)
+
+	// the Secrets of ClusterIssuers are read from the cluster resource
+	// namespace, which defaults to kube-system
+	clusterResourceNamespace, ok := args["cluster-resource-namespace"]
+	if !ok {
+		clusterResourceNamespace = "kube-system"
+	}
+	clusterResourceNamespace = strings.ReplaceAll(clusterResourceNamespace, "$(POD_NAMESPACE)", controller.Namespace)
+	if clusterResourceNamespace != operatorClusterResourceNamespace {
+		config.Unsupported = append(config.Unsupported, fmt.Sprintf(
+			"cluster resource namespace %s: the Secrets used by ClusterIssuers must be copied to %s",
+			clusterResourceNamespace, operatorClusterResourceNamespace,
+		))
+	}
+	delete(args, "cluster-resource-namespace")
+
+	config.Unsupported = append(config.Unsupported, unsupportedArgs("cert-manager-controller", "controller", args)...)
+
+	webhook, webhookContainer, err := findCertManagerDeployment(deployments, "cert-manager-webhook")
+	if err != nil {
+		return nil, err
+	}
+	if webhook != nil {
+		config.WebhookReplicas = deploymentReplicas(webhook)
+		config.Unsupported = append(config.Unsupported, unsupportedArgs("cert-manager-webhook", "webhook", parseArgs(webhookContainer))...)
+	}
+
+	return config, nil
+}
+
+// findCertManagerDeployment returns the Deployment and container running the
+// named cert-manager image, or nil if there is none. It is an error for more
+// than one Deployment to run the image.
+func findCertManagerDeployment(deployments []appsv1.Deployment, image string) (*appsv1.Deployment, *corev1.Container, error) {
+	var found *appsv1.Deployment
+	var foundContainer *corev1.Container
+	for i, deployment := range deployments {
+		for j, container := range deployment.Spec.Template.Spec.Containers {
+			if imageName(container.Image) != image {
+				continue
+			}
+			if found != nil {
+				return nil, nil, fmt.Errorf("found more than one %s deployment: %s/%s and %s/%s", image, found.Namespace, found.Name, deployment.Namespace, deployment.Name)
+			}
+			found = &deployments[i]
+			foundContainer = &deployments[i].Spec.Template.Spec.Containers[j]
+		}
+	}
+	return found, foundContainer, nil
+}
+
+// parseArgs returns the value of each --name=value argument of a container,
+// flags without a value have an empty value
+func parseArgs(container *corev1.Container) map[string]string {
+	args := make(map[string]string)
+	for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		args[name] = value
+	}
+	return args
+}
+
+// unsupportedArgs describes each argument that is not ignored for the image,
+// sorted by name so that the report is stable
+func unsupportedArgs(image, component string, args map[string]string) []string {
+	var names []string
+	for name, value := range args {
+		if ignoredValue, ok := ignoredArgs[image][name]; ok && (ignoredValue == "" || ignoredValue == value) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unsupported []string
+	for _, name := range names {
+		description, ok := argDescriptions[name]
+		if !ok {
+			description = component + " argument"
+		}
+
+		arg := "--" + name
+		if args[name] != "" {
+			arg += "=" + args[name]
+		}
+		unsupported = append(unsupported, fmt.Sprintf("%s: %s", description, arg))
+	}
+	return unsupported
+}
+
+// imageName returns the last path component of an image reference without
+// its tag or digest
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	image = image[strings.LastIndex(image, "/")+1:]
+	name, _, _ := strings.Cut(image, ":")
+	return name
+}
+
+// imageTag returns the tag of an image reference, or an empty string if the
+// image is referenced by digest or has no tag
+func imageTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	image = image[strings.LastIndex(image, "/")+1:]
+	_, tag, _ := strings.Cut(image, ":")
+	return tag
+}
+
+func deploymentReplicas(deployment *appsv1.Deployment) int {
+	if deployment.Spec.Replicas == nil {
+		// Kubernetes defaults to a single replica
+		return 1
+	}
+	return int(*deployment.Spec.Replicas)
+}
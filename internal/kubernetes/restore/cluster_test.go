@@ -0,0 +1,110 @@
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExtractCertManagerConfig(t *testing.T) {
+	deployment := func(name string, replicas int32, image string, args ...string) appsv1.Deployment {
+		return appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cert-manager"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: name, Image: image, Args: args},
+				}}},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		deployments    []appsv1.Deployment
+		expectedConfig *CertManagerConfig
+		expectedErr    string
+	}{
+		"default helm installation": {
+			deployments: []appsv1.Deployment{
+				deployment("cert-manager", 2, "quay.io/jetstack/cert-manager-controller:v1.10.1",
+					"--v=2",
+					"--cluster-resource-namespace=$(POD_NAMESPACE)",
+					"--leader-election-namespace=kube-system",
+					"--acme-http01-solver-image=quay.io/jetstack/cert-manager-acmesolver:v1.10.1",
+					"--max-concurrent-challenges=60",
+				),
+				deployment("cert-manager-webhook", 2, "quay.io/jetstack/cert-manager-webhook:v1.10.1",
+					"--v=2",
+					"--secure-port=10250",
+					"--dynamic-serving-ca-secret-namespace=$(POD_NAMESPACE)",
+				),
+				deployment("nginx", 1, "nginx:1.23"),
+			},
+			expectedConfig: &CertManagerConfig{
+				Namespace:       "cert-manager",
+				Version:         "v1.10.1",
+				Replicas:        2,
+				WebhookReplicas: 2,
+				Unsupported: []string{
+					"cluster resource namespace cert-manager: the Secrets used by ClusterIssuers must be copied to jetstack-secure",
+				},
+			},
+		},
+		"customised installation": {
+			deployments: []appsv1.Deployment{
+				deployment("cert-manager", 3, "registry.example.com:5000/cert-manager-controller@sha256:abc",
+					"--cluster-resource-namespace=jetstack-secure",
+					"--feature-gates=AdditionalCertificateOutputFormats=true",
+					"--dns01-recursive-nameservers=8.8.8.8:53",
+					"--dns01-recursive-nameservers-only",
+					"--default-issuer-name=letsencrypt",
+					"--max-concurrent-challenges=100",
+				),
+				deployment("cert-manager-webhook", 1, "quay.io/jetstack/cert-manager-webhook:v1.10.1",
+					"--feature-gates=AdditionalCertificateOutputFormats=true",
+				),
+			},
+			expectedConfig: &CertManagerConfig{
+				Namespace:       "cert-manager",
+				Replicas:        3,
+				WebhookReplicas: 1,
+				Unsupported: []string{
+					"controller image registry.example.com:5000/cert-manager-controller@sha256:abc: the version cannot be determined from an image digest",
+					"ingress-shim default issuer: --default-issuer-name=letsencrypt",
+					"DNS01 recursive nameservers: --dns01-recursive-nameservers=8.8.8.8:53",
+					"DNS01 recursive nameservers: --dns01-recursive-nameservers-only",
+					"feature gates: --feature-gates=AdditionalCertificateOutputFormats=true",
+					"controller argument: --max-concurrent-challenges=100",
+					"feature gates: --feature-gates=AdditionalCertificateOutputFormats=true",
+				},
+			},
+		},
+		"no cert-manager installation": {
+			deployments: []appsv1.Deployment{deployment("nginx", 1, "nginx:1.23")},
+			expectedErr: "no cert-manager controller deployment found",
+		},
+		"multiple cert-manager installations": {
+			deployments: []appsv1.Deployment{
+				deployment("cert-manager", 1, "quay.io/jetstack/cert-manager-controller:v1.10.1"),
+				deployment("cert-manager-2", 1, "quay.io/jetstack/cert-manager-controller:v1.9.1"),
+			},
+			expectedErr: "found more than one cert-manager-controller deployment: cert-manager/cert-manager and cert-manager/cert-manager-2",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config, err := ExtractCertManagerConfig(tc.deployments)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedConfig, config)
+		})
+	}
+}
@@ -33,9 +33,9 @@ type RestoredIssuers struct {
 	NeedsConversion []string
 }
 
+// ExtractOperatorManageableIssuersFromBackupFile reads the issuers in a JSON or
+// YAML backup file, as created by 'jsctl clusters backup'.
 func ExtractOperatorManageableIssuersFromBackupFile(backupFilePath string) (*RestoredIssuers, error) {
-	var restoredIssuers RestoredIssuers
-
 	file, err := os.Open(backupFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open backup file: %w", err)
@@ -79,6 +79,17 @@ func ExtractOperatorManageableIssuersFromBackupFile(backupFilePath string) (*Res
 		return nil, fmt.Errorf("unsupported backup format for: %q, must be JSON or YAML file", backupFilePath)
 	}
 
+	return ExtractOperatorManageableIssuers(resources)
+}
+
+// ExtractOperatorManageableIssuers sorts the issuers in resources into those
+// that can be managed by the operator, those that need converting to
+// cert-manager.io/v1 first and those that are not supported. Resources that
+// are not issuers are ignored.
+func ExtractOperatorManageableIssuers(resources []*unstructured.Unstructured) (*RestoredIssuers, error) {
+	var restoredIssuers RestoredIssuers
+	var err error
+
 	for _, resource := range resources {
 		switch resource.GroupVersionKind().Group {
 		case "cert-manager.io":
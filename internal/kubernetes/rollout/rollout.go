@@ -0,0 +1,220 @@
+// Package rollout contains functions for waiting on resources to become ready after they have been applied.
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// DefaultInterval is the interval between checks used when waiting for resources.
+const DefaultInterval = 2 * time.Second
+
+// DeploymentStatus returns true if the Deployment's latest spec has been fully rolled out. Otherwise, a message
+// describing the progress of the rollout is returned. An error is returned if the rollout has failed.
+func DeploymentStatus(deployment *appsv1.Deployment) (bool, string, error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, "waiting for deployment spec update to be observed", nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, "", fmt.Errorf("deployment %s has exceeded its progress deadline: %s", deployment.Name, condition.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("%d out of %d new replicas have been updated", status.UpdatedReplicas, replicas), nil
+	case status.Replicas > status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d old replicas are pending termination", status.Replicas-status.UpdatedReplicas), nil
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas, status.UpdatedReplicas), nil
+	}
+
+	return true, "successfully rolled out", nil
+}
+
+// WaitForDeployments polls each of the Deployments in turn until it has been rolled out, the timeout expires or the
+// context is cancelled. Progress is written to out whenever the status of a Deployment changes.
+func WaitForDeployments(
+	ctx context.Context,
+	client clients.Generic[*appsv1.Deployment, *appsv1.DeploymentList],
+	deployments []types.NamespacedName,
+	interval, timeout time.Duration,
+	out io.Writer,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, name := range deployments {
+		var lastMessage string
+
+		err := wait.PollImmediateUntilWithContext(ctx, interval, func(ctx context.Context) (bool, error) {
+			var deployment appsv1.Deployment
+			err := client.Get(ctx, &clients.GenericRequestOptions{Name: name.Name, Namespace: name.Namespace}, &deployment)
+			switch {
+			case apierrors.IsNotFound(err):
+				return false, nil
+			case err != nil:
+				return false, err
+			}
+
+			done, message, err := DeploymentStatus(&deployment)
+			if err != nil {
+				return false, err
+			}
+			if message != lastMessage {
+				fmt.Fprintf(out, "deployment %s: %s\n", name, message)
+				lastMessage = message
+			}
+
+			return done, nil
+		})
+		if errors.Is(err, wait.ErrWaitTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			if lastMessage == "" {
+				lastMessage = "deployment not found"
+			}
+			return fmt.Errorf("timed out after %s waiting for deployment %s: %s", timeout, name, lastMessage)
+		}
+		if err != nil {
+			return fmt.Errorf("error waiting for deployment %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// The InstallationReadiness interface describes types that report the readiness of an operator Installation resource,
+// such as the clients.InstallationClient.
+type InstallationReadiness interface {
+	Ready(ctx context.Context, name string) (bool, []clients.ComponentStatus, error)
+}
+
+// WaitForInstallation polls the named Installation until all of its components are ready, the timeout expires or the
+// context is cancelled. Progress is written to out whenever the status of a component changes. If the timeout expires,
+// the returned error contains the condition message of each component that is not ready.
+func WaitForInstallation(
+	ctx context.Context,
+	client InstallationReadiness,
+	name string,
+	interval, timeout time.Duration,
+	out io.Writer,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	lastStatuses := make(map[string]clients.ComponentStatus)
+	var statuses []clients.ComponentStatus
+
+	err := wait.PollImmediateUntilWithContext(ctx, interval, func(ctx context.Context) (bool, error) {
+		var ready bool
+		var err error
+
+		ready, statuses, err = client.Ready(ctx, name)
+		switch {
+		case errors.Is(err, clients.ErrNoInstallation):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+
+		for _, status := range statuses {
+			if last, ok := lastStatuses[status.Name]; ok && last == status {
+				continue
+			}
+			lastStatuses[status.Name] = status
+
+			if status.Ready {
+				fmt.Fprintf(out, "installation %s: %s is ready\n", name, status.Name)
+			} else {
+				fmt.Fprintf(out, "installation %s: %s is not ready: %s\n", name, status.Name, status.Message)
+			}
+		}
+
+		return ready, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		var messages []string
+		for _, status := range statuses {
+			if !status.Ready {
+				messages = append(messages, fmt.Sprintf("%s: %s", status.Name, status.Message))
+			}
+		}
+		if len(messages) == 0 {
+			messages = append(messages, "no up to date component status reported")
+		}
+		return fmt.Errorf("timed out after %s waiting for installation %s: %s", timeout, name, strings.Join(messages, "; "))
+	}
+	if err != nil {
+		return fmt.Errorf("error waiting for installation %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// WaitForCRD polls the named CustomResourceDefinition until it has been established, so that resources of its kind
+// can be created, the timeout expires or the context is cancelled.
+func WaitForCRD(
+	ctx context.Context,
+	client clients.Generic[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList],
+	name string,
+	interval, timeout time.Duration,
+	out io.Writer,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var found bool
+	err := wait.PollImmediateUntilWithContext(ctx, interval, func(ctx context.Context) (bool, error) {
+		var crd apiextensionsv1.CustomResourceDefinition
+		err := client.Get(ctx, &clients.GenericRequestOptions{Name: name}, &crd)
+		switch {
+		case apierrors.IsNotFound(err):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+
+		if !found {
+			fmt.Fprintf(out, "crd %s: found, waiting for it to be established\n", name)
+			found = true
+		}
+
+		for _, condition := range crd.Status.Conditions {
+			if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		message := "crd not found"
+		if found {
+			message = "crd not established"
+		}
+		return fmt.Errorf("timed out after %s waiting for crd %s: %s", timeout, name, message)
+	}
+	if err != nil {
+		return fmt.Errorf("error waiting for crd %s: %w", name, err)
+	}
+
+	return nil
+}
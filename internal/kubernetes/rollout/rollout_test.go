@@ -0,0 +1,223 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestDeploymentStatus(t *testing.T) {
+	tests := map[string]struct {
+		deployment appsv1.Deployment
+		wantDone   bool
+		wantErr    bool
+	}{
+		"unobserved generation should not be done": {
+			deployment: appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+			},
+		},
+		"partially updated deployment should not be done": {
+			deployment: appsv1.Deployment{
+				Spec:   appsv1.DeploymentSpec{Replicas: pointer.Int32(2)},
+				Status: appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+			},
+		},
+		"deployment with old replicas should not be done": {
+			deployment: appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+		},
+		"deployment with unavailable replicas should not be done": {
+			deployment: appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1},
+			},
+		},
+		"rolled out deployment should be done": {
+			deployment: appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+			wantDone: true,
+		},
+		"deployment past its progress deadline should produce an error": {
+			deployment: appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Conditions: []appsv1.DeploymentCondition{
+						{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			done, message, err := DeploymentStatus(&scenario.deployment)
+			if (err != nil) != scenario.wantErr {
+				t.Fatalf("DeploymentStatus() error = %v, wantErr %v", err, scenario.wantErr)
+			}
+			if done != scenario.wantDone {
+				t.Errorf("DeploymentStatus() done = %v (%s), want %v", done, message, scenario.wantDone)
+			}
+		})
+	}
+}
+
+func TestWaitForDeployments(t *testing.T) {
+	name := types.NamespacedName{Namespace: "jetstack-secure", Name: "js-operator-operator"}
+
+	t.Run("deployment should be waited for until rolled out", func(t *testing.T) {
+		var calls int
+		client := &clients.FakeGeneric[*appsv1.Deployment, *appsv1.DeploymentList]{
+			FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, deployment *appsv1.Deployment) error {
+				calls++
+				switch calls {
+				case 1:
+					return apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, name.Name)
+				case 2:
+					deployment.Status = appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1}
+				default:
+					deployment.Status = appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+				}
+				return nil
+			},
+		}
+
+		var out bytes.Buffer
+		err := WaitForDeployments(context.Background(), client, []types.NamespacedName{name}, time.Millisecond, time.Second, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls != 3 {
+			t.Errorf("WaitForDeployments() got %d calls, want 3", calls)
+		}
+	})
+
+	t.Run("timeout should produce an error with the last status", func(t *testing.T) {
+		client := &clients.FakeGeneric[*appsv1.Deployment, *appsv1.DeploymentList]{
+			FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, deployment *appsv1.Deployment) error {
+				deployment.Status = appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1}
+				return nil
+			},
+		}
+
+		var out bytes.Buffer
+		err := WaitForDeployments(context.Background(), client, []types.NamespacedName{name}, time.Millisecond, 20*time.Millisecond, &out)
+		want := "timed out after 20ms waiting for deployment jetstack-secure/js-operator-operator: 0 of 1 updated replicas are available"
+		if err == nil || err.Error() != want {
+			t.Fatalf("WaitForDeployments() error = %v, want %s", err, want)
+		}
+	})
+}
+
+type fakeInstallationReadiness func(ctx context.Context, name string) (bool, []clients.ComponentStatus, error)
+
+func (f fakeInstallationReadiness) Ready(ctx context.Context, name string) (bool, []clients.ComponentStatus, error) {
+	return f(ctx, name)
+}
+
+func TestWaitForInstallation(t *testing.T) {
+	t.Run("installation should be waited for until ready", func(t *testing.T) {
+		var calls int
+		client := fakeInstallationReadiness(func(_ context.Context, _ string) (bool, []clients.ComponentStatus, error) {
+			calls++
+			switch calls {
+			case 1:
+				return false, nil, clients.ErrNoInstallation
+			case 2:
+				return false, []clients.ComponentStatus{{Name: "cert-manager", Message: "deploying"}}, nil
+			default:
+				return true, []clients.ComponentStatus{{Name: "cert-manager", Ready: true}}, nil
+			}
+		})
+
+		var out bytes.Buffer
+		err := WaitForInstallation(context.Background(), client, "jetstack-secure", time.Millisecond, time.Second, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "installation jetstack-secure: cert-manager is not ready: deploying\ninstallation jetstack-secure: cert-manager is ready\n"
+		if out.String() != want {
+			t.Errorf("WaitForInstallation() output = %q, want %q", out.String(), want)
+		}
+	})
+
+	t.Run("timeout should produce an error with the component messages", func(t *testing.T) {
+		client := fakeInstallationReadiness(func(_ context.Context, _ string) (bool, []clients.ComponentStatus, error) {
+			return false, []clients.ComponentStatus{
+				{Name: "cert-manager", Ready: true},
+				{Name: "istio-csr", Message: "issuer not found"},
+			}, nil
+		})
+
+		var out bytes.Buffer
+		err := WaitForInstallation(context.Background(), client, "jetstack-secure", time.Millisecond, 20*time.Millisecond, &out)
+		want := "timed out after 20ms waiting for installation jetstack-secure: istio-csr: issuer not found"
+		if err == nil || err.Error() != want {
+			t.Fatalf("WaitForInstallation() error = %v, want %s", err, want)
+		}
+	})
+}
+
+func TestWaitForCRD(t *testing.T) {
+	name := "bundles.trust.cert-manager.io"
+
+	t.Run("crd should be waited for until established", func(t *testing.T) {
+		var calls int
+		client := &clients.FakeGeneric[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList]{
+			FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, crd *apiextensionsv1.CustomResourceDefinition) error {
+				calls++
+				switch calls {
+				case 1:
+					return apierrors.NewNotFound(schema.GroupResource{Resource: "customresourcedefinitions"}, name)
+				case 2:
+					crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{
+						{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionTrue},
+					}
+				default:
+					crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{
+						{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+					}
+				}
+				return nil
+			},
+		}
+
+		var out bytes.Buffer
+		err := WaitForCRD(context.Background(), client, name, time.Millisecond, time.Second, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls != 3 {
+			t.Errorf("WaitForCRD() got %d calls, want 3", calls)
+		}
+	})
+
+	t.Run("timeout should produce an error if the crd is not found", func(t *testing.T) {
+		client := &clients.FakeGeneric[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList]{
+			FakeGet: func(_ context.Context, _ *clients.GenericRequestOptions, crd *apiextensionsv1.CustomResourceDefinition) error {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "customresourcedefinitions"}, name)
+			},
+		}
+
+		var out bytes.Buffer
+		err := WaitForCRD(context.Background(), client, name, time.Millisecond, 20*time.Millisecond, &out)
+		want := "timed out after 20ms waiting for crd bundles.trust.cert-manager.io: crd not found"
+		if err == nil || err.Error() != want {
+			t.Fatalf("WaitForCRD() error = %v, want %s", err, want)
+		}
+	})
+}
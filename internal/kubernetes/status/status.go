@@ -40,7 +40,7 @@ type ClusterStatus struct {
 
 	// Issuers is a list of issuers of all kinds found in the cluster. Including
 	// external issuers.
-	Issuers []summaryIssuer `yaml:"issuers"`
+	Issuers []SummaryIssuer `yaml:"issuers"`
 }
 
 // crdGroup is a list of custom resource definitions that are all part of the
@@ -58,8 +58,8 @@ type summaryIngress struct {
 	CertManagerAnnotations map[string]string `yaml:"certManagerAnnotations"`
 }
 
-// summaryIssuer is a wrapper of some summary information about an issuer
-type summaryIssuer struct {
+// SummaryIssuer is a wrapper of some summary information about an issuer
+type SummaryIssuer struct {
 	// APIVersion is the API group name and the version
 	APIVersion string `yaml:"apiVersion"`
 
@@ -221,7 +221,7 @@ func GatherClusterStatus(ctx context.Context, cfg *rest.Config) (*ClusterStatus,
 	}
 
 	// gather issuers and find each issuer of each kind
-	status.Issuers, err = findIssuers(ctx, cfg)
+	status.Issuers, err = FindIssuers(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed while finding issuers in the cluster: %s", err)
 	}
@@ -229,7 +229,9 @@ func GatherClusterStatus(ctx context.Context, cfg *rest.Config) (*ClusterStatus,
 	return &status, nil
 }
 
-func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error) {
+// FindIssuers returns a summary of every issuer in the cluster, including
+// external issuers whose CRDs are installed.
+func FindIssuers(ctx context.Context, cfg *rest.Config) ([]SummaryIssuer, error) {
 	issuerClient, err := clients.NewAllIssuers(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create issuer client: %s", err)
@@ -239,7 +241,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 		return nil, fmt.Errorf("failed to list issuer kinds: %s", err)
 	}
 
-	var summaryIssuers []summaryIssuer
+	var summaryIssuers []SummaryIssuer
 	for _, kind := range issuerKinds {
 		switch kind {
 		case clients.CertManagerIssuer:
@@ -253,7 +255,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list clusterissuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: cmapi.SchemeGroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -271,7 +273,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list clusterissuers: %s", err)
 			}
 			for _, issuer := range clusterIssuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: cmapi.SchemeGroupVersion.String(),
 					Name:       issuer.Name,
 					Kind:       issuer.Kind,
@@ -288,7 +290,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list cas issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: googlecasissuerv1beta1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -306,7 +308,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list cas cluster issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: googlecasissuerv1beta1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Kind:       issuer.Kind,
@@ -323,7 +325,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list pca issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: awspcaissuerv1beta1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -341,7 +343,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list pca cluster issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: awspcaissuerv1beta1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Kind:       issuer.Kind,
@@ -358,7 +360,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list kms issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: kmsissuerv1alpha1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -376,7 +378,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list venafi issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: veiv1alpha1.SchemeGroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -394,7 +396,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list venafi cluster issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: veiv1alpha1.SchemeGroupVersion.String(),
 					Name:       issuer.Name,
 					Kind:       issuer.Kind,
@@ -411,8 +413,8 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list origin ca issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
-					APIVersion: kmsissuerv1alpha1.GroupVersion.String(),
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
+					APIVersion: origincaissuerv1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
 					Kind:       issuer.Kind,
@@ -429,7 +431,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list smallstep issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: stepissuerv1beta1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -447,7 +449,7 @@ func findIssuers(ctx context.Context, cfg *rest.Config) ([]summaryIssuer, error)
 				return nil, fmt.Errorf("failed to list smallstep cluster issuers: %s", err)
 			}
 			for _, issuer := range issuers.Items {
-				summaryIssuers = append(summaryIssuers, summaryIssuer{
+				summaryIssuers = append(summaryIssuers, SummaryIssuer{
 					APIVersion: stepissuerv1beta1.GroupVersion.String(),
 					Name:       issuer.Name,
 					Namespace:  issuer.Namespace,
@@ -112,7 +112,7 @@ func TestGatherClusterPreInstallStatus(t *testing.T) {
 			}),
 			"cert-manager-approver-policy": components.NewCertManagerApproverPolicyStatus("jetstack-secure", "v0.4.0"),
 		},
-		Issuers: []summaryIssuer{
+		Issuers: []SummaryIssuer{
 			{
 				Name:       "pca-sample",
 				Namespace:  "jetstack-secure",
@@ -140,3 +140,34 @@ func TestGatherClusterPreInstallStatus(t *testing.T) {
 		},
 	}, status)
 }
+
+func TestFindIssuers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var data string
+		switch r.URL.Path {
+		case "/apis/apiextensions.k8s.io/v1/customresourcedefinitions":
+			data = `{"items": [{"metadata": {"name": "originissuers.cert-manager.k8s.cloudflare.com"}}]}`
+		case "/apis/cert-manager.k8s.cloudflare.com/v1/originissuers":
+			data = `{"items": [{"kind": "OriginIssuer", "metadata": {"name": "origin", "namespace": "default"}}]}`
+		default:
+			t.Fatalf("unexpected request: %s", r.URL.Path)
+		}
+
+		w.Write([]byte(data))
+	}))
+	defer server.Close()
+
+	issuers, err := FindIssuers(context.Background(), &rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	assert.Equal(t, []SummaryIssuer{
+		{
+			APIVersion: "cert-manager.k8s.cloudflare.com/v1",
+			Name:       "origin",
+			Namespace:  "default",
+			Kind:       "OriginIssuer",
+		},
+	}, issuers)
+}
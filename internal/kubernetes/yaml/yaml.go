@@ -2,6 +2,7 @@ package yaml
 
 import (
 	"io"
+	"sort"
 	"strings"
 
 	goyaml "github.com/go-yaml/yaml"
@@ -47,3 +48,45 @@ func Load(input io.Reader) ([]*unstructured.Unstructured, error) {
 
 	return resources, nil
 }
+
+// Images returns the container images used by the workloads in resources,
+// sorted and without duplicates. Containers are found at any depth so that
+// pod templates nested in Jobs and CronJobs are included.
+func Images(resources []*unstructured.Unstructured) []string {
+	found := make(map[string]struct{})
+	for _, resource := range resources {
+		collectImages(resource.Object, found)
+	}
+
+	images := make([]string, 0, len(found))
+	for image := range found {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	return images
+}
+
+func collectImages(value interface{}, found map[string]struct{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "containers" || key == "initContainers" {
+				containers, _ := child.([]interface{})
+				for _, container := range containers {
+					if c, ok := container.(map[string]interface{}); ok {
+						if image, ok := c["image"].(string); ok && image != "" {
+							found[image] = struct{}{}
+						}
+					}
+				}
+				continue
+			}
+			collectImages(child, found)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectImages(child, found)
+		}
+	}
+}
@@ -149,3 +149,45 @@ no_kind_set: 1`,
 		})
 	}
 }
+
+func TestImages(t *testing.T) {
+	resources, err := Load(strings.NewReader(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: example.com/init:v1
+      containers:
+      - name: foo
+        image: example.com/foo:v1
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: bar
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: bar
+            image: example.com/bar:v1
+          - name: foo
+            image: example.com/foo:v1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: baz
+data:
+  image: example.com/baz:v1
+`))
+	td.CmpNoError(t, err)
+
+	td.Cmp(t, Images(resources), []string{"example.com/bar:v1", "example.com/foo:v1", "example.com/init:v1"})
+}
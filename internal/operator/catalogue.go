@@ -0,0 +1,371 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CatalogueIndexFile is the name of the index file within an installer directory.
+	CatalogueIndexFile = "index.yaml"
+	// CatalogueDirectory is the name of the installer directory within the jsctl config directory, which installers
+	// downloaded by 'jsctl operator versions refresh' are written to.
+	CatalogueDirectory = "installers"
+)
+
+type (
+	// The CatalogueIndex type describes a set of operator installers and their checksums. It is read from the
+	// index.yaml file of an installer directory, or from a remote index URL.
+	CatalogueIndex struct {
+		Installers []CatalogueEntry `json:"installers"`
+	}
+
+	// The CatalogueEntry type describes a single operator installer within a CatalogueIndex.
+	CatalogueEntry struct {
+		// Version is the operator version the installer deploys, for example v0.0.1-alpha.21
+		Version string `json:"version"`
+		// Path is the location of the installer, relative to the index
+		Path string `json:"path"`
+		// SHA256 is the hex-encoded SHA-256 checksum of the installer
+		SHA256 string `json:"sha256"`
+	}
+
+	// The RefreshInstallersOptions type contains fields used to download installers from a remote index.
+	RefreshInstallersOptions struct {
+		// IndexURL is the location of the index. Its detached signature is read from the same URL with a .sig suffix.
+		IndexURL string
+		// PublicKey is the key used to verify the signature of the index
+		PublicKey ed25519.PublicKey
+		// Directory is the installer directory that downloaded installers are written to
+		Directory string
+		// HTTPClient is used to download the index and installers, http.DefaultClient is used if nil
+		HTTPClient *http.Client
+	}
+)
+
+// catalogue holds installers loaded at runtime, in addition to the installers embedded in jsctl
+type catalogue struct {
+	mu         sync.RWMutex
+	installers map[string][]byte
+}
+
+var defaultCatalogue = &catalogue{}
+
+// releasePublicKey is the base64-encoded ed25519 public key that signs the installer indexes published for jsctl
+// releases. It is built in to release builds with -ldflags, see .goreleaser.yml.
+var releasePublicKey string
+
+// ErrNoReleasePublicKey is the error given when jsctl was built without the release signing key.
+var ErrNoReleasePublicKey = errors.New("no release public key built in to jsctl")
+
+// ErrChecksumMismatch is the error given when an installer does not match the checksum in its index.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrInvalidSignature is the error given when the signature of a remote index cannot be verified.
+var ErrInvalidSignature = errors.New("invalid index signature")
+
+// LoadInstallers adds the installers listed in the index.yaml file of dir to the versions available to Versions and
+// the other functions in this package. Each installer is verified against the checksum in the index before it is
+// added. Installers for versions that are embedded in jsctl are ignored, so the embedded installers cannot be replaced.
+func LoadInstallers(dir string) error {
+	return defaultCatalogue.load(dir)
+}
+
+// RefreshInstallers downloads the index at options.IndexURL, verifies its signature and downloads each installer that
+// it lists, verifying their checksums. The installers are written to options.Directory along with an updated index, so
+// that they can be loaded with LoadInstallers. Installers already in the directory that are not in the remote index are
+// kept. The versions listed in the remote index are returned.
+func RefreshInstallers(ctx context.Context, options RefreshInstallersOptions) ([]string, error) {
+	client := options.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	indexURL, err := url.Parse(options.IndexURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index URL: %w", err)
+	}
+
+	indexData, err := download(ctx, client, indexURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to download index: %w", err)
+	}
+	signatureData, err := download(ctx, client, indexURL.String()+".sig")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download index signature: %w", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signatureData)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	if len(options.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(options.PublicKey, indexData, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	remote, err := parseCatalogueIndex(indexData)
+	if err != nil {
+		return nil, err
+	}
+
+	downloaded := make(map[string][]byte, len(remote.Installers))
+	versions := make([]string, 0, len(remote.Installers))
+	for _, entry := range remote.Installers {
+		entryURL, err := url.Parse(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for installer %s: %w", entry.Version, err)
+		}
+
+		data, err := download(ctx, client, indexURL.ResolveReference(entryURL).String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to download installer %s: %w", entry.Version, err)
+		}
+		if err := verifyChecksum(entry, data); err != nil {
+			return nil, err
+		}
+
+		downloaded[entry.Version] = data
+		versions = append(versions, entry.Version)
+	}
+
+	if err := os.MkdirAll(options.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create installer directory: %w", err)
+	}
+
+	// a local index that cannot be read is replaced, and installers that cannot be loaded are dropped from it, so that
+	// refreshing repairs a broken installer directory
+	local, err := readCatalogueIndex(options.Directory)
+	if err != nil {
+		local = &CatalogueIndex{}
+	}
+
+	entries := make(map[string]CatalogueEntry)
+	for _, entry := range local.Installers {
+		if _, err := readInstaller(options.Directory, entry); err != nil {
+			continue
+		}
+		entries[entry.Version] = entry
+	}
+	for version, data := range downloaded {
+		name := version + ".yaml"
+		if err := os.WriteFile(filepath.Join(options.Directory, name), data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write installer %s: %w", version, err)
+		}
+		entries[version] = CatalogueEntry{Version: version, Path: name, SHA256: checksum(data)}
+	}
+
+	updated := &CatalogueIndex{}
+	for _, entry := range entries {
+		updated.Installers = append(updated.Installers, entry)
+	}
+	// every version was checked by parseCatalogueIndex, so they can be parsed here without error
+	sort.Slice(updated.Installers, func(i, j int) bool {
+		return semver.MustParse(updated.Installers[i].Version).LessThan(semver.MustParse(updated.Installers[j].Version))
+	})
+
+	data, err := yaml.Marshal(updated)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(options.Directory, CatalogueIndexFile), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+
+	return versions, nil
+}
+
+// ParsePublicKey parses a PEM-encoded ed25519 public key used to verify the signature of a remote index.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T, must be ed25519", key)
+	}
+	return publicKey, nil
+}
+
+// ReleasePublicKey returns the ed25519 public key built in to jsctl that signs the installer indexes published for
+// its releases. ErrNoReleasePublicKey is returned for builds without one, such as those made with 'go build'.
+func ReleasePublicKey() (ed25519.PublicKey, error) {
+	return parseReleasePublicKey(releasePublicKey)
+}
+
+func parseReleasePublicKey(encoded string) (ed25519.PublicKey, error) {
+	if encoded == "" {
+		return nil, ErrNoReleasePublicKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid release public key: must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+func (c *catalogue) load(dir string) error {
+	index, err := readCatalogueIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string][]byte, len(index.Installers))
+	for _, entry := range index.Installers {
+		data, err := readInstaller(dir, entry)
+		if err != nil {
+			return err
+		}
+		loaded[entry.Version] = data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.installers == nil {
+		c.installers = make(map[string][]byte)
+	}
+	for version, data := range loaded {
+		// embed.FS paths are always slash-separated
+		if _, err := fs.Stat(installers, path.Join("installers", version+".yaml")); err == nil {
+			continue
+		}
+		c.installers[version] = data
+	}
+
+	return nil
+}
+
+// get returns the installer for version, or nil if the catalogue does not contain it
+func (c *catalogue) get(version string) []byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.installers[version]
+}
+
+func (c *catalogue) versions() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	versions := make([]string, 0, len(c.installers))
+	for version := range c.installers {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// readInstaller reads the installer for an index entry from dir and verifies it against the entry's checksum
+func readInstaller(dir string, entry CatalogueEntry) ([]byte, error) {
+	name := filepath.Clean(entry.Path)
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path for installer %s must be within %s", entry.Version, dir)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installer %s: %w", entry.Version, err)
+	}
+	if err := verifyChecksum(entry, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func readCatalogueIndex(dir string) (*CatalogueIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, CatalogueIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	return parseCatalogueIndex(data)
+}
+
+func parseCatalogueIndex(data []byte) (*CatalogueIndex, error) {
+	var index CatalogueIndex
+	if err := yaml.UnmarshalStrict(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+
+	for i, entry := range index.Installers {
+		version, err := semver.NewVersion(entry.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q in index: %w", entry.Version, err)
+		}
+		// versions are normalised to the form returned by Versions so that they can be looked up consistently
+		index.Installers[i].Version = "v" + version.String()
+
+		if entry.Path == "" {
+			return nil, fmt.Errorf("no path given for installer %s", entry.Version)
+		}
+		if entry.SHA256 == "" {
+			return nil, fmt.Errorf("no checksum given for installer %s", entry.Version)
+		}
+	}
+
+	return &index, nil
+}
+
+func verifyChecksum(entry CatalogueEntry, data []byte) error {
+	if !strings.EqualFold(checksum(data), entry.SHA256) {
+		return fmt.Errorf("%w for installer %s: expected %s, got %s", ErrChecksumMismatch, entry.Version, entry.SHA256, checksum(data))
+	}
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func download(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if _, err := io.Copy(buf, resp.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
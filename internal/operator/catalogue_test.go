@@ -0,0 +1,246 @@
+package operator
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func testInstaller(t *testing.T) []byte {
+	data, err := installers.ReadFile("installers/v0.0.1-alpha.20.yaml")
+	require.NoError(t, err)
+	return data
+}
+
+func writeTestIndex(t *testing.T, dir string, index CatalogueIndex) {
+	data, err := yaml.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, CatalogueIndexFile), data, 0600))
+}
+
+func TestCatalogue_Load(t *testing.T) {
+	t.Parallel()
+	installer := testInstaller(t)
+
+	tests := map[string]struct {
+		entry        CatalogueEntry
+		wantErr      error
+		wantErrText  string
+		wantVersions []string
+	}{
+		"installer with a valid checksum should be loaded": {
+			entry:        CatalogueEntry{Version: "v0.0.1-alpha.21", Path: "installer.yaml", SHA256: checksum(installer)},
+			wantVersions: []string{"v0.0.1-alpha.21"},
+		},
+		"versions should be normalised": {
+			entry:        CatalogueEntry{Version: "0.0.1-alpha.21", Path: "installer.yaml", SHA256: checksum(installer)},
+			wantVersions: []string{"v0.0.1-alpha.21"},
+		},
+		"embedded versions should not be replaced": {
+			entry:        CatalogueEntry{Version: "v0.0.1-alpha.20", Path: "installer.yaml", SHA256: checksum(installer)},
+			wantVersions: []string{},
+		},
+		"installer with an invalid checksum should fail": {
+			entry:   CatalogueEntry{Version: "v0.0.1-alpha.21", Path: "installer.yaml", SHA256: checksum([]byte("foo"))},
+			wantErr: ErrChecksumMismatch,
+		},
+		"installer outside of the directory should fail": {
+			entry:       CatalogueEntry{Version: "v0.0.1-alpha.21", Path: "../installer.yaml", SHA256: checksum(installer)},
+			wantErrText: "must be within",
+		},
+		"invalid version should fail": {
+			entry:       CatalogueEntry{Version: "latest", Path: "installer.yaml", SHA256: checksum(installer)},
+			wantErrText: "invalid version",
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "installer.yaml"), installer, 0600))
+			writeTestIndex(t, dir, CatalogueIndex{Installers: []CatalogueEntry{scenario.entry}})
+
+			c := &catalogue{}
+			err := c.load(dir)
+			switch {
+			case scenario.wantErr != nil:
+				assert.ErrorIs(t, err, scenario.wantErr)
+			case scenario.wantErrText != "":
+				assert.ErrorContains(t, err, scenario.wantErrText)
+			default:
+				require.NoError(t, err)
+				assert.ElementsMatch(t, scenario.wantVersions, c.versions())
+				for _, version := range scenario.wantVersions {
+					assert.Equal(t, installer, c.get(version))
+				}
+			}
+		})
+	}
+
+	t.Run("It should return an error if there is no index", func(t *testing.T) {
+		c := &catalogue{}
+		assert.ErrorIs(t, c.load(t.TempDir()), os.ErrNotExist)
+	})
+}
+
+func TestRefreshInstallers(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	installer := testInstaller(t)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	index, err := yaml.Marshal(CatalogueIndex{Installers: []CatalogueEntry{
+		{Version: "v0.0.1-alpha.21", Path: "manifests/v0.0.1-alpha.21.yaml", SHA256: checksum(installer)},
+	}})
+	require.NoError(t, err)
+
+	serve := func(t *testing.T, index, signature, installer []byte) string {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, r *http.Request) { w.Write(index) })
+		mux.HandleFunc("/index.yaml.sig", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(base64.StdEncoding.EncodeToString(signature)))
+		})
+		mux.HandleFunc("/manifests/v0.0.1-alpha.21.yaml", func(w http.ResponseWriter, r *http.Request) { w.Write(installer) })
+
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		return server.URL + "/index.yaml"
+	}
+
+	t.Run("It should download and verify the installers in the index", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), CatalogueDirectory)
+		versions, err := RefreshInstallers(ctx, RefreshInstallersOptions{
+			IndexURL:  serve(t, index, ed25519.Sign(privateKey, index), installer),
+			PublicKey: publicKey,
+			Directory: dir,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v0.0.1-alpha.21"}, versions)
+
+		c := &catalogue{}
+		require.NoError(t, c.load(dir))
+		assert.Equal(t, installer, c.get("v0.0.1-alpha.21"))
+	})
+
+	t.Run("It should order the written index by semantic version", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "v0.0.1-alpha.100.yaml"), installer, 0600))
+		writeTestIndex(t, dir, CatalogueIndex{Installers: []CatalogueEntry{
+			{Version: "v0.0.1-alpha.100", Path: "v0.0.1-alpha.100.yaml", SHA256: checksum(installer)},
+		}})
+
+		_, err := RefreshInstallers(ctx, RefreshInstallersOptions{
+			IndexURL:  serve(t, index, ed25519.Sign(privateKey, index), installer),
+			PublicKey: publicKey,
+			Directory: dir,
+		})
+		require.NoError(t, err)
+
+		written, err := readCatalogueIndex(dir)
+		require.NoError(t, err)
+		require.Len(t, written.Installers, 2)
+		assert.Equal(t, "v0.0.1-alpha.21", written.Installers[0].Version)
+		assert.Equal(t, "v0.0.1-alpha.100", written.Installers[1].Version)
+	})
+
+	t.Run("It should rebuild a local index that cannot be loaded", func(t *testing.T) {
+		for name, local := range map[string][]byte{
+			"corrupt index": []byte("installers: {"),
+			"checksum mismatch": func() []byte {
+				data, err := yaml.Marshal(CatalogueIndex{Installers: []CatalogueEntry{
+					{Version: "v0.0.1-alpha.100", Path: "v0.0.1-alpha.100.yaml", SHA256: checksum([]byte("foo"))},
+				}})
+				require.NoError(t, err)
+				return data
+			}(),
+		} {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "v0.0.1-alpha.100.yaml"), installer, 0600))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, CatalogueIndexFile), local, 0600))
+
+				_, err := RefreshInstallers(ctx, RefreshInstallersOptions{
+					IndexURL:  serve(t, index, ed25519.Sign(privateKey, index), installer),
+					PublicKey: publicKey,
+					Directory: dir,
+				})
+				require.NoError(t, err)
+
+				c := &catalogue{}
+				require.NoError(t, c.load(dir))
+				assert.Equal(t, []string{"v0.0.1-alpha.21"}, c.versions())
+			})
+		}
+	})
+
+	t.Run("It should reject an index with an invalid signature", func(t *testing.T) {
+		otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		_, err = RefreshInstallers(ctx, RefreshInstallersOptions{
+			IndexURL:  serve(t, index, ed25519.Sign(privateKey, index), installer),
+			PublicKey: otherPublicKey,
+			Directory: dir,
+		})
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+		assert.NoFileExists(t, filepath.Join(dir, CatalogueIndexFile))
+	})
+
+	t.Run("It should reject an installer that does not match its checksum", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := RefreshInstallers(ctx, RefreshInstallersOptions{
+			IndexURL:  serve(t, index, ed25519.Sign(privateKey, index), []byte("foo")),
+			PublicKey: publicKey,
+			Directory: dir,
+		})
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		assert.NoFileExists(t, filepath.Join(dir, CatalogueIndexFile))
+	})
+}
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	parsed, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, parsed)
+
+	_, err = ParsePublicKey([]byte("foo"))
+	assert.Error(t, err)
+}
+
+func TestParseReleasePublicKey(t *testing.T) {
+	t.Parallel()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	parsed, err := parseReleasePublicKey(base64.StdEncoding.EncodeToString(publicKey))
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, parsed)
+
+	_, err = parseReleasePublicKey("")
+	assert.ErrorIs(t, err, ErrNoReleasePublicKey)
+
+	_, err = parseReleasePublicKey(base64.StdEncoding.EncodeToString([]byte("foo")))
+	assert.Error(t, err)
+}
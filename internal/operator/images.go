@@ -0,0 +1,270 @@
+package operator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	k8syaml "github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+// DefaultImageRegistry is the registry that the images of the operator and the components it installs are pulled from
+// by default.
+const DefaultImageRegistry = "eu.gcr.io/jetstack-secure-enterprise"
+
+// The ComponentImage type describes an image used by a component of an Installation.
+type ComponentImage struct {
+	// Component is the name of the component, as shown by 'operator installations status'
+	Component string
+	// Image is the image reference, tagged with the version of the component
+	Image string
+	// Version is the version of the component, either set in the Installation or chosen by the operator
+	Version string
+}
+
+// componentVersionFields are the paths of the version field of each component within the Installation spec, keyed by
+// component name. The descriptions of these fields in the Installation CRD give the version the operator installs by
+// default.
+var componentVersionFields = map[string][]string{
+	"approver-policy":            {"approverPolicy", "version"},
+	"approver-policy-enterprise": {"approverPolicyEnterprise", "version"},
+	"cert-discovery-venafi":      {"certDiscoveryVenafi", "version"},
+	"cert-manager":               {"certManager", "version"},
+	"csi-driver":                 {"csiDrivers", "certManager", "version"},
+	"csi-driver-spiffe":          {"csiDrivers", "certManagerSpiffe", "version"},
+	"istio-csr":                  {"istioCSR", "version"},
+	"trust-manager":              {"trustManager", "version"},
+	"venafi-enhanced-issuer":     {"venafiEnhancedIssuer", "version"},
+	"venafi-oauth-helper":        {"venafiOauthHelper", "version"},
+}
+
+// defaultComponentVersionOverrides replace the default component versions documented in the Installation CRD of an
+// operator version where they are wrong, keyed by operator version and then by component name.
+var defaultComponentVersionOverrides = map[string]map[string]string{
+	"v0.0.1-alpha.20": {
+		// documented as v0.50
+		"csi-driver": "v0.5.0",
+	},
+}
+
+// componentDefaultVersions returns the versions that the operator installs for components that have no version in
+// the Installation, keyed by component name. They are read from the Installation CRD in the installer for
+// operatorVersion, so that installers added with LoadInstallers are supported, and replaced by any overrides in
+// defaultComponentVersionOverrides.
+func componentDefaultVersions(operatorVersion string) (map[string]string, error) {
+	spec, err := installationSpecSchema(operatorVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(componentVersionFields))
+	for name, fieldPath := range componentVersionFields {
+		schema := *spec
+		for _, f := range fieldPath {
+			schema = schema.Properties[f]
+		}
+		if version := defaultVersion(schema.Description); version != "" {
+			versions[name] = version
+		}
+	}
+	for name, version := range defaultComponentVersionOverrides[operatorVersion] {
+		versions[name] = version
+	}
+
+	return versions, nil
+}
+
+// component is the images deployed for an Installation component, along with the version set for the component in
+// the Installation, if any
+type component struct {
+	images  []string
+	version string
+}
+
+// componentImages returns the images deployed for each component enabled in the Installation, keyed by component name
+func componentImages(spec operatorv1alpha1.InstallationSpec) map[string]component {
+	components := make(map[string]component)
+	if spec.CertManager != nil {
+		components["cert-manager"] = component{
+			images:  []string{"cert-manager-controller", "cert-manager-webhook", "cert-manager-cainjector", "cert-manager-acmesolver"},
+			version: spec.CertManager.Version,
+		}
+	}
+	if spec.ApproverPolicy != nil {
+		components["approver-policy"] = component{images: []string{"cert-manager-approver-policy"}, version: spec.ApproverPolicy.Version}
+	}
+	if spec.ApproverPolicyEnterprise != nil {
+		components["approver-policy-enterprise"] = component{images: []string{"approver-policy-enterprise"}, version: spec.ApproverPolicyEnterprise.Version}
+	}
+	if spec.CSIDrivers != nil && spec.CSIDrivers.CertManager != nil {
+		components["csi-driver"] = component{images: []string{"cert-manager-csi-driver"}, version: spec.CSIDrivers.CertManager.Version}
+	}
+	if spec.CSIDrivers != nil && spec.CSIDrivers.CertManagerSpiffe != nil {
+		components["csi-driver-spiffe"] = component{
+			images:  []string{"cert-manager-csi-driver-spiffe", "cert-manager-csi-driver-spiffe-approver"},
+			version: spec.CSIDrivers.CertManagerSpiffe.Version,
+		}
+	}
+	if spec.IstioCSR != nil {
+		components["istio-csr"] = component{images: []string{"cert-manager-istio-csr"}, version: spec.IstioCSR.Version}
+	}
+	if spec.TrustManager != nil {
+		components["trust-manager"] = component{images: []string{"trust-manager"}, version: spec.TrustManager.Version}
+	}
+	if spec.VenafiOauthHelper != nil {
+		components["venafi-oauth-helper"] = component{images: []string{"venafi-oauth-helper"}, version: spec.VenafiOauthHelper.Version}
+	}
+	if spec.VenafiEnhancedIssuer != nil {
+		components["venafi-enhanced-issuer"] = component{images: []string{"venafi-enhanced-issuer"}, version: spec.VenafiEnhancedIssuer.Version}
+	}
+	if spec.CertDiscoveryVenafi != nil {
+		components["cert-discovery-venafi"] = component{images: []string{"cert-discovery-venafi"}, version: spec.CertDiscoveryVenafi.Version}
+	}
+
+	return components
+}
+
+// InstallerImages returns the images deployed by the operator installer for version, or the latest installer if
+// version is empty. Images templated by the installer are pulled from imageRegistry.
+func InstallerImages(version, imageRegistry string) ([]string, error) {
+	file, err := operatorManifest(version)
+	if err != nil {
+		return nil, fmt.Errorf("error determining manifest version: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest contents: %w", err)
+	}
+
+	tpl, err := template.New("install").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error creating new template: %w", err)
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	err = tpl.Execute(output, map[string]interface{}{
+		"ImageRegistry": imageRegistry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest template: %w", err)
+	}
+
+	resources, err := k8syaml.Load(output)
+	if err != nil {
+		return nil, fmt.Errorf("error loading manifest: %w", err)
+	}
+
+	return k8syaml.Images(resources), nil
+}
+
+// LoadInstallation returns the first Installation in a stream of YAML-encoded Kubernetes resources, such as the
+// output of 'jsctl operator installations apply --stdout'.
+func LoadInstallation(r io.Reader) (*operatorv1alpha1.Installation, error) {
+	objects, err := k8syaml.Load(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, object := range objects {
+		if object.GroupVersionKind().GroupKind() != operatorv1alpha1.InstallationGVK.GroupKind() {
+			continue
+		}
+
+		var installation operatorv1alpha1.Installation
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &installation); err != nil {
+			return nil, fmt.Errorf("error decoding Installation %s: %w", object.GetName(), err)
+		}
+		return &installation, nil
+	}
+
+	return nil, errors.New("no Installation resources found")
+}
+
+// InstallationImages returns the images of the components enabled in the Installation, sorted by component. Images
+// are pulled from the Installation's registry, or DefaultImageRegistry if it has none.
+//
+// Components without a version in the Installation use the default version documented in the Installation CRD of
+// operatorVersion, or the latest operator if it is empty. The operator installs the cainjector of its default
+// cert-manager version alongside itself, so if the CRD does not document the default cert-manager version it is read
+// from the installer. An error is returned if the version of any other component cannot be determined.
+func InstallationImages(installation *operatorv1alpha1.Installation, operatorVersion string) ([]ComponentImage, error) {
+	imageRegistry := DefaultImageRegistry
+	if installation.Spec.Images != nil && installation.Spec.Images.Registry != "" {
+		imageRegistry = installation.Spec.Images.Registry
+	}
+
+	if operatorVersion == "" {
+		versions, err := Versions()
+		if err != nil {
+			return nil, fmt.Errorf("error determining operator versions: %w", err)
+		}
+		operatorVersion = versions[len(versions)-1]
+	}
+	defaults, err := componentDefaultVersions(operatorVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error determining default component versions: %w", err)
+	}
+
+	components := componentImages(installation.Spec)
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var images []ComponentImage
+	var unknown []string
+	for _, name := range names {
+		c := components[name]
+
+		version := c.version
+		if version == "" {
+			version = defaults[name]
+		}
+		if version == "" && name == "cert-manager" {
+			version, err = installerCertManagerVersion(operatorVersion)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if version == "" {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		for _, image := range c.images {
+			images = append(images, ComponentImage{Component: name, Image: imageRegistry + "/" + image + ":" + version, Version: version})
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("the default versions of %s are not known for operator %s, set their versions in the Installation", strings.Join(unknown, ", "), operatorVersion)
+	}
+
+	return images, nil
+}
+
+// installerCertManagerVersion returns the version of the cert-manager cainjector deployed by the installer for the
+// operator version, or an empty string if there is none
+func installerCertManagerVersion(operatorVersion string) (string, error) {
+	installerImages, err := InstallerImages(operatorVersion, DefaultImageRegistry)
+	if err != nil {
+		return "", err
+	}
+
+	for _, image := range installerImages {
+		name, tag, _ := strings.Cut(path.Base(image), ":")
+		if name == "cert-manager-cainjector" {
+			return tag, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,173 @@
+package operator_test
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func TestInstallerImages(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should return the images of the installer from the registry", func(t *testing.T) {
+		images, err := operator.InstallerImages("v0.0.1-alpha.20", "registry.example.com/jse")
+		assert.NoError(t, err)
+		assert.EqualValues(t, []string{
+			"registry.example.com/jse/cert-manager-cainjector:v1.10.1",
+			"registry.example.com/jse/js-operator:v0.0.1-alpha.20",
+		}, images)
+	})
+
+	t.Run("It should return an error for an unknown version", func(t *testing.T) {
+		_, err := operator.InstallerImages("v0.0.0", "registry.example.com/jse")
+		assert.Error(t, err)
+	})
+}
+
+func TestInstallationImages(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		spec     operatorv1alpha1.InstallationSpec
+		expected []operator.ComponentImage
+	}{
+		"defaults versions to those of the operator": {
+			spec: operatorv1alpha1.InstallationSpec{
+				CertManager:    &operatorv1alpha1.CertManager{},
+				ApproverPolicy: &operatorv1alpha1.ApproverPolicy{},
+			},
+			expected: []operator.ComponentImage{
+				{Component: "approver-policy", Image: "eu.gcr.io/jetstack-secure-enterprise/cert-manager-approver-policy:v0.4.0", Version: "v0.4.0"},
+				{Component: "cert-manager", Image: "eu.gcr.io/jetstack-secure-enterprise/cert-manager-controller:v1.10.1", Version: "v1.10.1"},
+				{Component: "cert-manager", Image: "eu.gcr.io/jetstack-secure-enterprise/cert-manager-webhook:v1.10.1", Version: "v1.10.1"},
+				{Component: "cert-manager", Image: "eu.gcr.io/jetstack-secure-enterprise/cert-manager-cainjector:v1.10.1", Version: "v1.10.1"},
+				{Component: "cert-manager", Image: "eu.gcr.io/jetstack-secure-enterprise/cert-manager-acmesolver:v1.10.1", Version: "v1.10.1"},
+			},
+		},
+		"uses the versions and registry of the installation": {
+			spec: operatorv1alpha1.InstallationSpec{
+				Images:       &operatorv1alpha1.Images{Registry: "registry.example.com/jse"},
+				CertManager:  &operatorv1alpha1.CertManager{Version: "v1.9.1"},
+				TrustManager: &operatorv1alpha1.TrustManager{Version: "v0.3.0"},
+			},
+			expected: []operator.ComponentImage{
+				{Component: "cert-manager", Image: "registry.example.com/jse/cert-manager-controller:v1.9.1", Version: "v1.9.1"},
+				{Component: "cert-manager", Image: "registry.example.com/jse/cert-manager-webhook:v1.9.1", Version: "v1.9.1"},
+				{Component: "cert-manager", Image: "registry.example.com/jse/cert-manager-cainjector:v1.9.1", Version: "v1.9.1"},
+				{Component: "cert-manager", Image: "registry.example.com/jse/cert-manager-acmesolver:v1.9.1", Version: "v1.9.1"},
+				{Component: "trust-manager", Image: "registry.example.com/jse/trust-manager:v0.3.0", Version: "v0.3.0"},
+			},
+		},
+	}
+
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			installation := &operatorv1alpha1.Installation{Spec: scenario.spec}
+			images, err := operator.InstallationImages(installation, "v0.0.1-alpha.20")
+			require.NoError(t, err)
+			assert.EqualValues(t, scenario.expected, images)
+		})
+	}
+
+	t.Run("It should know the default version of every component for each embedded installer", func(t *testing.T) {
+		installation := &operatorv1alpha1.Installation{Spec: operatorv1alpha1.InstallationSpec{
+			CertManager:              &operatorv1alpha1.CertManager{},
+			ApproverPolicyEnterprise: &operatorv1alpha1.ApproverPolicyEnterprise{},
+			CSIDrivers: &operatorv1alpha1.CSIDrivers{
+				CertManager:       &operatorv1alpha1.CSIDriverCertManager{},
+				CertManagerSpiffe: &operatorv1alpha1.CSIDriverCertManagerSpiffe{},
+			},
+			IstioCSR:             &operatorv1alpha1.IstioCSR{},
+			TrustManager:         &operatorv1alpha1.TrustManager{},
+			VenafiOauthHelper:    &operatorv1alpha1.VenafiOauthHelper{},
+			VenafiEnhancedIssuer: &operatorv1alpha1.VenafiEnhancedIssuer{},
+			CertDiscoveryVenafi:  &operatorv1alpha1.CertDiscoveryVenafi{},
+		}}
+
+		versions, err := operator.Versions()
+		require.NoError(t, err)
+		for _, version := range versions {
+			images, err := operator.InstallationImages(installation, version)
+			require.NoError(t, err, version)
+			for _, image := range images {
+				assert.NotEmpty(t, image.Version, "%s %s", version, image.Component)
+			}
+		}
+	})
+
+	t.Run("It should read the default versions from the Installation CRD of the installer", func(t *testing.T) {
+		installation := &operatorv1alpha1.Installation{Spec: operatorv1alpha1.InstallationSpec{
+			ApproverPolicyEnterprise: &operatorv1alpha1.ApproverPolicyEnterprise{},
+			CSIDrivers: &operatorv1alpha1.CSIDrivers{
+				CertManager:       &operatorv1alpha1.CSIDriverCertManager{},
+				CertManagerSpiffe: &operatorv1alpha1.CSIDriverCertManagerSpiffe{},
+			},
+			IstioCSR:             &operatorv1alpha1.IstioCSR{},
+			TrustManager:         &operatorv1alpha1.TrustManager{},
+			VenafiOauthHelper:    &operatorv1alpha1.VenafiOauthHelper{},
+			VenafiEnhancedIssuer: &operatorv1alpha1.VenafiEnhancedIssuer{},
+			CertDiscoveryVenafi:  &operatorv1alpha1.CertDiscoveryVenafi{},
+		}}
+
+		images, err := operator.InstallationImages(installation, "v0.0.1-alpha.20")
+		require.NoError(t, err)
+
+		versions := make(map[string]string)
+		for _, image := range images {
+			versions[image.Component] = image.Version
+		}
+		assert.Equal(t, map[string]string{
+			"approver-policy-enterprise": "v0.4.0-0",
+			"cert-discovery-venafi":      "v0.2.0",
+			"csi-driver":                 "v0.5.0",
+			"csi-driver-spiffe":          "v0.2.0",
+			"istio-csr":                  "v0.5.0",
+			"trust-manager":              "v0.3.0",
+			"venafi-enhanced-issuer":     "v0.1.4",
+			"venafi-oauth-helper":        "v0.3.0",
+		}, versions)
+	})
+
+	t.Run("It should return an error for an unknown operator version", func(t *testing.T) {
+		installation := &operatorv1alpha1.Installation{Spec: operatorv1alpha1.InstallationSpec{CertManager: &operatorv1alpha1.CertManager{}}}
+		_, err := operator.InstallationImages(installation, "v0.0.0")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadInstallation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should load the Installation from a manifest", func(t *testing.T) {
+		manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: jse-gcr-creds
+  namespace: jetstack-secure
+---
+apiVersion: operator.jetstack.io/v1alpha1
+kind: Installation
+metadata:
+  name: jetstack-secure
+spec:
+  images:
+    registry: registry.example.com/jse
+  certManager:
+    version: v1.9.1
+`
+		installation, err := operator.LoadInstallation(strings.NewReader(manifest))
+		assert.NoError(t, err)
+		assert.EqualValues(t, "registry.example.com/jse", installation.Spec.Images.Registry)
+		assert.EqualValues(t, "v1.9.1", installation.Spec.CertManager.Version)
+	})
+
+	t.Run("It should return an error if there is no Installation", func(t *testing.T) {
+		_, err := operator.LoadInstallation(strings.NewReader("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: jetstack-secure\n"))
+		assert.EqualError(t, err, "no Installation resources found")
+	})
+}
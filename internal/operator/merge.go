@@ -0,0 +1,364 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/pmezard/go-difflib/difflib"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/yaml"
+)
+
+// The InstallationMerge type contains the result of merging the configuration in ApplyInstallationYAMLOptions onto an
+// Installation that already exists in the cluster.
+type InstallationMerge struct {
+	// Installation is the existing Installation with the requested configuration merged in
+	Installation *operatorv1alpha1.Installation
+	// Secrets are the Secrets generated for the requested configuration, such as image pull secrets and Venafi
+	// credentials
+	Secrets []*corev1.Secret
+	// Diff is a unified diff of the existing and merged Installation specs, it is empty if the spec is unchanged
+	Diff string
+	// Patch is a JSON merge patch that updates the existing Installation to the merged Installation, it is nil if
+	// the Installation is unchanged
+	Patch []byte
+}
+
+// MergeInstallationYAML generates an Installation from the ApplyInstallationYAMLOptions in the same way as
+// ApplyInstallationYAML, and merges it onto the existing Installation. Optional components that are not requested are
+// left as they are in the existing Installation rather than being removed, and issuers are merged by name, namespace
+// and scope. The existing versions, replica counts and other settings of each component are kept unless the options
+// set them.
+func MergeInstallationYAML(existing *operatorv1alpha1.Installation, options ApplyInstallationYAMLOptions) (*InstallationMerge, error) {
+	mf, err := generateManifests(options)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeInstallations(existing, mf.installation)
+	if err := ValidateInstallation(merged, options.OperatorVersion); err != nil {
+		return nil, fmt.Errorf("merged Installation is invalid: %w", err)
+	}
+
+	existingSpec, err := yaml.Marshal(existing.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling existing Installation spec: %w", err)
+	}
+	mergedSpec, err := yaml.Marshal(merged.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling merged Installation spec: %w", err)
+	}
+
+	result := &InstallationMerge{
+		Installation: merged,
+		Secrets:      mf.secrets,
+	}
+	if string(existingSpec) == string(mergedSpec) {
+		return result, nil
+	}
+
+	result.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(existingSpec)),
+		B:        difflib.SplitLines(string(mergedSpec)),
+		FromFile: "existing",
+		ToFile:   "requested",
+		Context:  3,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error generating Installation diff: %w", err)
+	}
+
+	result.Patch, err = specMergePatch(existing.Spec, merged.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Installation patch: %w", err)
+	}
+
+	return result, nil
+}
+
+// ApplySecrets applies the Secrets generated for the requested configuration. These must be applied before the
+// Installation is patched, so that any components using them can start.
+func (m *InstallationMerge) ApplySecrets(ctx context.Context, applier Applier) error {
+	if len(m.Secrets) == 0 {
+		return nil
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	for _, secret := range m.Secrets {
+		secretYAML, err := yaml.Marshal(secret)
+		if err != nil {
+			return fmt.Errorf("failed to marshal Secret data: %w", err)
+		}
+		buf.Write(secretYAML)
+		buf.WriteString("---\n")
+	}
+
+	return applier.Apply(ctx, buf)
+}
+
+// specMergePatch returns a JSON merge patch (RFC 7386) that updates the spec of an Installation from existing to
+// merged. Only the fields that differ are included, and fields that have been removed are set to null.
+func specMergePatch(existing, merged operatorv1alpha1.InstallationSpec) ([]byte, error) {
+	from, err := jsonObject(existing)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonObject(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]interface{}{"spec": mergePatch(from, to)})
+}
+
+// mergePatch returns the changes needed to turn from into to. Nested objects are diffed recursively, while any other
+// changed value, including lists, is replaced wholesale as required by JSON merge patch.
+func mergePatch(from, to map[string]interface{}) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for key, value := range to {
+		existing, ok := from[key]
+		if !ok {
+			patch[key] = value
+			continue
+		}
+		if equality.Semantic.DeepEqual(existing, value) {
+			continue
+		}
+
+		existingObject, existingIsObject := existing.(map[string]interface{})
+		object, isObject := value.(map[string]interface{})
+		if existingIsObject && isObject {
+			patch[key] = mergePatch(existingObject, object)
+			continue
+		}
+		patch[key] = value
+	}
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// mergeInstallations returns a copy of existing with the configuration in requested applied on top. Fields are
+// replaced rather than modified in place so that existing is left unchanged.
+func mergeInstallations(existing, requested *operatorv1alpha1.Installation) *operatorv1alpha1.Installation {
+	merged := existing.DeepCopy()
+	spec := &merged.Spec
+	req := requested.Spec
+
+	// cert-manager is always configured by ApplyInstallationYAMLOptions, so
+	// only the version and replica counts that were requested replace those
+	// in the existing Installation
+	switch {
+	case req.CertManager == nil:
+	case spec.CertManager == nil:
+		certManager := *req.CertManager
+		spec.CertManager = &certManager
+	default:
+		certManager := *spec.CertManager
+		if req.CertManager.Version != "" {
+			certManager.Version = req.CertManager.Version
+		}
+		if req.CertManager.Controller != nil && req.CertManager.Controller.ReplicaCount != nil {
+			var controller operatorv1alpha1.CertManagerControllerConfig
+			if certManager.Controller != nil {
+				controller = *certManager.Controller
+			}
+			controller.ReplicaCount = req.CertManager.Controller.ReplicaCount
+			certManager.Controller = &controller
+		}
+		if req.CertManager.Webhook != nil && req.CertManager.Webhook.ReplicaCount != nil {
+			var webhook operatorv1alpha1.CertManagerWebhookConfig
+			if certManager.Webhook != nil {
+				webhook = *certManager.Webhook
+			}
+			webhook.ReplicaCount = req.CertManager.Webhook.ReplicaCount
+			certManager.Webhook = &webhook
+		}
+		spec.CertManager = &certManager
+	}
+
+	if req.ApproverPolicyEnterprise != nil {
+		spec.ApproverPolicy = nil
+		spec.ApproverPolicyEnterprise = req.ApproverPolicyEnterprise
+	}
+	if spec.ApproverPolicy == nil && spec.ApproverPolicyEnterprise == nil {
+		spec.ApproverPolicy = req.ApproverPolicy
+	}
+
+	if req.CSIDrivers != nil {
+		var drivers operatorv1alpha1.CSIDrivers
+		if spec.CSIDrivers != nil {
+			drivers = *spec.CSIDrivers
+		}
+		if req.CSIDrivers.CertManager != nil {
+			drivers.CertManager = req.CSIDrivers.CertManager
+		}
+		if req.CSIDrivers.CertManagerSpiffe != nil {
+			drivers.CertManagerSpiffe = mergeSpiffeCSIDriver(drivers.CertManagerSpiffe, req.CSIDrivers.CertManagerSpiffe)
+		}
+		spec.CSIDrivers = &drivers
+	}
+
+	if req.IstioCSR != nil {
+		spec.IstioCSR = mergeIstioCSR(spec.IstioCSR, req.IstioCSR)
+	}
+	if req.VenafiOauthHelper != nil {
+		spec.VenafiOauthHelper = mergeVenafiOauthHelper(spec.VenafiOauthHelper, req.VenafiOauthHelper)
+	}
+	// trust-manager has no options set by ApplyInstallationYAMLOptions, so an
+	// existing configuration is kept as it is
+	if req.TrustManager != nil && spec.TrustManager == nil {
+		spec.TrustManager = req.TrustManager
+	}
+	if req.CertDiscoveryVenafi != nil {
+		spec.CertDiscoveryVenafi = mergeCertDiscoveryVenafi(spec.CertDiscoveryVenafi, req.CertDiscoveryVenafi)
+	}
+
+	if req.Images != nil {
+		var images operatorv1alpha1.Images
+		if spec.Images != nil {
+			images = *spec.Images
+		}
+		if req.Images.Registry != "" {
+			images.Registry = req.Images.Registry
+		}
+		if req.Images.Secret != "" {
+			images.Secret = req.Images.Secret
+		}
+		spec.Images = &images
+	}
+
+	spec.Issuers = mergeIssuers(spec.Issuers, req.Issuers)
+
+	return merged
+}
+
+// The merge functions for optional components return a copy of existing with the fields set in requested applied on
+// top, so that settings made outside of jsctl, such as a replica count, are kept unless they are requested. A
+// component that does not exist yet is added as requested.
+
+func mergeSpiffeCSIDriver(existing, requested *operatorv1alpha1.CSIDriverCertManagerSpiffe) *operatorv1alpha1.CSIDriverCertManagerSpiffe {
+	var merged operatorv1alpha1.CSIDriverCertManagerSpiffe
+	if existing != nil {
+		merged = *existing
+	}
+	if requested.ReplicaCount != nil {
+		merged.ReplicaCount = requested.ReplicaCount
+	}
+	if requested.Version != "" {
+		merged.Version = requested.Version
+	}
+	if requested.IssuerRef != nil {
+		merged.IssuerRef = requested.IssuerRef
+	}
+	return &merged
+}
+
+func mergeIstioCSR(existing, requested *operatorv1alpha1.IstioCSR) *operatorv1alpha1.IstioCSR {
+	var merged operatorv1alpha1.IstioCSR
+	if existing != nil {
+		merged = *existing
+	}
+	if requested.ReplicaCount != nil {
+		merged.ReplicaCount = requested.ReplicaCount
+	}
+	if requested.Version != "" {
+		merged.Version = requested.Version
+	}
+	if requested.IssuerRef != nil {
+		merged.IssuerRef = requested.IssuerRef
+	}
+	if requested.IstioNamespace != "" {
+		merged.IstioNamespace = requested.IstioNamespace
+	}
+	return &merged
+}
+
+func mergeVenafiOauthHelper(existing, requested *operatorv1alpha1.VenafiOauthHelper) *operatorv1alpha1.VenafiOauthHelper {
+	var merged operatorv1alpha1.VenafiOauthHelper
+	if existing != nil {
+		merged = *existing
+	}
+	if requested.ReplicaCount != nil {
+		merged.ReplicaCount = requested.ReplicaCount
+	}
+	if requested.Version != "" {
+		merged.Version = requested.Version
+	}
+	return &merged
+}
+
+func mergeCertDiscoveryVenafi(existing, requested *operatorv1alpha1.CertDiscoveryVenafi) *operatorv1alpha1.CertDiscoveryVenafi {
+	var merged operatorv1alpha1.CertDiscoveryVenafi
+	if existing != nil {
+		merged = *existing
+	}
+	if requested.ReplicaCount != nil {
+		merged.ReplicaCount = requested.ReplicaCount
+	}
+	if requested.Version != "" {
+		merged.Version = requested.Version
+	}
+	if requested.TPP != nil {
+		var tpp operatorv1alpha1.TPP
+		if merged.TPP != nil {
+			tpp = *merged.TPP
+		}
+		if requested.TPP.URL != "" {
+			tpp.URL = requested.TPP.URL
+		}
+		if requested.TPP.Zone != "" {
+			tpp.Zone = requested.TPP.Zone
+		}
+		if requested.TPP.TokenSecretRef != nil {
+			tpp.TokenSecretRef = requested.TPP.TokenSecretRef
+		}
+		merged.TPP = &tpp
+	}
+	return &merged
+}
+
+// mergeIssuers returns the existing issuers with any requested issuers of the same name, namespace and scope replaced,
+// followed by the requested issuers that do not already exist
+func mergeIssuers(existing, requested []*operatorv1alpha1.Issuer) []*operatorv1alpha1.Issuer {
+	if len(requested) == 0 {
+		return existing
+	}
+
+	type issuerKey struct {
+		name, namespace string
+		clusterScope    bool
+	}
+	key := func(issuer *operatorv1alpha1.Issuer) issuerKey {
+		return issuerKey{name: issuer.Name, namespace: issuer.Namespace, clusterScope: issuer.ClusterScope}
+	}
+
+	requestedByKey := make(map[issuerKey]*operatorv1alpha1.Issuer)
+	for _, issuer := range requested {
+		requestedByKey[key(issuer)] = issuer
+	}
+
+	merged := make([]*operatorv1alpha1.Issuer, 0, len(existing)+len(requested))
+	for _, issuer := range existing {
+		if replacement, ok := requestedByKey[key(issuer)]; ok {
+			merged = append(merged, replacement)
+			delete(requestedByKey, key(issuer))
+			continue
+		}
+		merged = append(merged, issuer)
+	}
+	for _, issuer := range requested {
+		if _, ok := requestedByKey[key(issuer)]; ok {
+			merged = append(merged, issuer)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,219 @@
+package operator
+
+import (
+	"encoding/json"
+	"testing"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeInstallationYAML(t *testing.T) {
+	t.Parallel()
+
+	existingInstallation := func(t *testing.T, options ApplyInstallationYAMLOptions) *operatorv1alpha1.Installation {
+		mf, err := generateManifests(options)
+		require.NoError(t, err)
+		return mf.installation
+	}
+	patchSpec := func(t *testing.T, patch []byte) map[string]interface{} {
+		var decoded map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(patch, &decoded))
+		return decoded["spec"]
+	}
+
+	t.Run("It should not produce a patch when nothing has changed", func(t *testing.T) {
+		options := ApplyInstallationYAMLOptions{InstallCSIDriver: true}
+		existing := existingInstallation(t, options)
+
+		merged, err := MergeInstallationYAML(existing, options)
+		require.NoError(t, err)
+
+		assert.Empty(t, merged.Diff)
+		assert.Nil(t, merged.Patch)
+	})
+
+	t.Run("It should keep components that were not requested", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{
+			InstallIstioCSR: true,
+			IstioCSRIssuer:  "istio-ca",
+		})
+
+		merged, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{InstallCSIDriver: true})
+		require.NoError(t, err)
+
+		assert.NotNil(t, merged.Installation.Spec.IstioCSR)
+		if assert.NotNil(t, merged.Installation.Spec.CSIDrivers) {
+			assert.NotNil(t, merged.Installation.Spec.CSIDrivers.CertManager)
+		}
+		assert.Nil(t, existing.Spec.CSIDrivers, "existing installation should not be modified")
+
+		assert.Contains(t, merged.Diff, "+  certManager: {}")
+		spec := patchSpec(t, merged.Patch)
+		assert.Contains(t, spec, "csiDrivers")
+		assert.NotContains(t, spec, "istioCSR")
+	})
+
+	t.Run("It should remove approver-policy when enabling approver-policy-enterprise", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{})
+
+		merged, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{InstallApproverPolicyEnterprise: true})
+		require.NoError(t, err)
+
+		assert.Nil(t, merged.Installation.Spec.ApproverPolicy)
+		assert.NotNil(t, merged.Installation.Spec.ApproverPolicyEnterprise)
+
+		spec := patchSpec(t, merged.Patch)
+		if assert.Contains(t, spec, "approverPolicy") {
+			assert.Nil(t, spec["approverPolicy"])
+		}
+		assert.Contains(t, spec, "approverPolicyEnterprise")
+	})
+
+	t.Run("It should keep a pinned cert-manager version when no version is requested", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{CertManagerVersion: "v1.9.1"})
+
+		merged, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "v1.9.1", merged.Installation.Spec.CertManager.Version)
+	})
+
+	t.Run("It should keep customised cert-manager replicas when enabling another component", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{CertManagerReplicas: 3, CertManagerVersion: "v1.9.1"})
+
+		merged, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{
+			InstallIstioCSR: true,
+			IstioCSRIssuer:  "istio-ca",
+		})
+		require.NoError(t, err)
+
+		certManager := merged.Installation.Spec.CertManager
+		if assert.NotNil(t, certManager.Controller.ReplicaCount) {
+			assert.Equal(t, 3, *certManager.Controller.ReplicaCount)
+		}
+		if assert.NotNil(t, certManager.Webhook.ReplicaCount) {
+			assert.Equal(t, 3, *certManager.Webhook.ReplicaCount)
+		}
+		assert.Equal(t, "v1.9.1", certManager.Version)
+		assert.NotContains(t, patchSpec(t, merged.Patch), "certManager")
+	})
+
+	t.Run("It should update cert-manager replicas when they are requested", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{CertManagerReplicas: 3})
+
+		merged, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{CertManagerReplicas: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, *merged.Installation.Spec.CertManager.Controller.ReplicaCount)
+		assert.Equal(t, 3, *existing.Spec.CertManager.Controller.ReplicaCount, "existing installation should not be modified")
+	})
+
+	t.Run("It should only update the istio-csr fields that are requested", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{
+			InstallIstioCSR:        true,
+			IstioCSRIssuer:         "istio-ca",
+			IstioCSRIstioNamespace: "istio-system",
+			IstioCSRReplicas:       3,
+		})
+		existing.Spec.IstioCSR.Version = "v0.5.0"
+
+		merged, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{
+			InstallIstioCSR: true,
+			IstioCSRIssuer:  "new-ca",
+		})
+		require.NoError(t, err)
+
+		istioCSR := merged.Installation.Spec.IstioCSR
+		if assert.NotNil(t, istioCSR.ReplicaCount) {
+			assert.Equal(t, 3, *istioCSR.ReplicaCount)
+		}
+		assert.Equal(t, "v0.5.0", istioCSR.Version)
+		assert.Equal(t, "istio-system", istioCSR.IstioNamespace)
+		assert.Equal(t, "new-ca", istioCSR.IssuerRef.Name)
+		assert.Equal(t, "istio-ca", existing.Spec.IstioCSR.IssuerRef.Name, "existing installation should not be modified")
+	})
+
+	t.Run("It should keep the existing settings of venafi-oauth-helper and cert-discovery-venafi", func(t *testing.T) {
+		replicas := 2
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{})
+		existing.Spec.VenafiOauthHelper = &operatorv1alpha1.VenafiOauthHelper{ReplicaCount: &replicas, Version: "v0.1.0"}
+		existing.Spec.CertDiscoveryVenafi = &operatorv1alpha1.CertDiscoveryVenafi{
+			ReplicaCount: &replicas,
+			TPP: &operatorv1alpha1.TPP{
+				URL:            "https://tpp.example.com",
+				Zone:           "old",
+				TokenSecretRef: &operatorv1alpha1.SecretRef{Name: "tpp", Key: "token"},
+			},
+		}
+
+		merged := mergeInstallations(existing, &operatorv1alpha1.Installation{Spec: operatorv1alpha1.InstallationSpec{
+			VenafiOauthHelper:   &operatorv1alpha1.VenafiOauthHelper{},
+			CertDiscoveryVenafi: &operatorv1alpha1.CertDiscoveryVenafi{TPP: &operatorv1alpha1.TPP{Zone: "new"}},
+		}})
+
+		assert.Equal(t, existing.Spec.VenafiOauthHelper, merged.Spec.VenafiOauthHelper)
+
+		certDiscovery := merged.Spec.CertDiscoveryVenafi
+		assert.Equal(t, &replicas, certDiscovery.ReplicaCount)
+		assert.Equal(t, &operatorv1alpha1.TPP{
+			URL:            "https://tpp.example.com",
+			Zone:           "new",
+			TokenSecretRef: &operatorv1alpha1.SecretRef{Name: "tpp", Key: "token"},
+		}, certDiscovery.TPP)
+		assert.Equal(t, "old", existing.Spec.CertDiscoveryVenafi.TPP.Zone, "existing installation should not be modified")
+	})
+
+	t.Run("It should validate against the installer of the requested operator version", func(t *testing.T) {
+		existing := existingInstallation(t, ApplyInstallationYAMLOptions{})
+
+		_, err := MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{OperatorVersion: "v0.0.1-alpha.20"})
+		require.NoError(t, err)
+
+		_, err = MergeInstallationYAML(existing, ApplyInstallationYAMLOptions{OperatorVersion: "v0.0.0-unknown"})
+		assert.Error(t, err)
+	})
+}
+
+func TestMergeIssuers(t *testing.T) {
+	t.Parallel()
+
+	issuer := func(name, namespace string, clusterScope bool, labels map[string]string) *operatorv1alpha1.Issuer {
+		return &operatorv1alpha1.Issuer{Name: name, Namespace: namespace, ClusterScope: clusterScope, Labels: labels}
+	}
+	updated := map[string]string{"updated": "true"}
+
+	tests := map[string]struct {
+		existing  []*operatorv1alpha1.Issuer
+		requested []*operatorv1alpha1.Issuer
+		want      []*operatorv1alpha1.Issuer
+	}{
+		"no requested issuers should keep existing issuers": {
+			existing: []*operatorv1alpha1.Issuer{issuer("a", "ns", false, nil)},
+			want:     []*operatorv1alpha1.Issuer{issuer("a", "ns", false, nil)},
+		},
+		"matching issuers should be replaced in place": {
+			existing:  []*operatorv1alpha1.Issuer{issuer("a", "ns", false, nil), issuer("b", "", true, nil)},
+			requested: []*operatorv1alpha1.Issuer{issuer("a", "ns", false, updated)},
+			want:      []*operatorv1alpha1.Issuer{issuer("a", "ns", false, updated), issuer("b", "", true, nil)},
+		},
+		"issuers in other namespaces or scopes should be added": {
+			existing: []*operatorv1alpha1.Issuer{issuer("a", "ns", false, nil)},
+			requested: []*operatorv1alpha1.Issuer{
+				issuer("a", "other", false, nil),
+				issuer("a", "", true, nil),
+			},
+			want: []*operatorv1alpha1.Issuer{
+				issuer("a", "ns", false, nil),
+				issuer("a", "other", false, nil),
+				issuer("a", "", true, nil),
+			},
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, scenario.want, mergeIssuers(scenario.existing, scenario.requested))
+		})
+	}
+}
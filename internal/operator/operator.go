@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
 	"github.com/jetstack/jsctl/internal/prompt"
 	"github.com/jetstack/jsctl/internal/registry"
 	"github.com/jetstack/jsctl/internal/venafi"
@@ -81,15 +82,7 @@ func ApplyOperatorYAML(ctx context.Context, applier Applier, options ApplyOperat
 		buf.WriteString("---\n")
 	}
 
-	var file io.Reader
-	var err error
-
-	if options.Version == "" {
-		file, err = latestManifest()
-	} else {
-		file, err = manifestVersion(options.Version)
-	}
-
+	file, err := operatorManifest(options.Version)
 	if err != nil {
 		return fmt.Errorf("error determining manifest version: %w", err)
 	}
@@ -114,16 +107,23 @@ func ApplyOperatorYAML(ctx context.Context, applier Applier, options ApplyOperat
 	return applier.Apply(ctx, output)
 }
 
+// operatorManifest returns the installer for version, or the latest installer
+// if version is empty. Embedded installers are used in preference to those
+// loaded with LoadInstallers.
+func operatorManifest(version string) (io.Reader, error) {
+	if version == "" {
+		return latestManifest()
+	}
+	return manifestVersion(version)
+}
+
 func latestManifest() (io.Reader, error) {
 	versions, err := Versions()
 	if err != nil {
 		return nil, err
 	}
 
-	latest := versions[len(versions)-1]
-	name := latest + ".yaml"
-
-	return installers.Open(filepath.Join("installers", name))
+	return manifestVersion(versions[len(versions)-1])
 }
 
 // ErrNoManifest is the error given when querying a kubernetes manifest that doesn't exit.
@@ -134,6 +134,9 @@ func manifestVersion(version string) (io.Reader, error) {
 	file, err := installers.Open(filepath.Join("installers", name))
 	switch {
 	case errors.Is(err, os.ErrNotExist):
+		if data := defaultCatalogue.get(version); data != nil {
+			return bytes.NewReader(data), nil
+		}
 		return nil, ErrNoManifest
 	case err != nil:
 		return nil, err
@@ -142,7 +145,8 @@ func manifestVersion(version string) (io.Reader, error) {
 	}
 }
 
-// Versions returns all available versions of the jetstack operator ordered semantically.
+// Versions returns all available versions of the jetstack operator ordered semantically, including those loaded with
+// LoadInstallers.
 func Versions() ([]string, error) {
 	entries, err := installers.ReadDir("installers")
 	if err != nil {
@@ -158,6 +162,7 @@ func Versions() ([]string, error) {
 		rawVersion := strings.TrimSuffix(filepath.Base(entry.Name()), ".yaml")
 		rawVersions = append(rawVersions, rawVersion)
 	}
+	rawVersions = append(rawVersions, defaultCatalogue.versions()...)
 
 	parsedVersions := make([]*semver.Version, len(rawVersions))
 	for i, rawVersion := range rawVersions {
@@ -192,18 +197,39 @@ type (
 		// InstallApproverPolicyEnterprise, if true, will swap the default open
 		// source policy approver for the enterprise one
 		InstallApproverPolicyEnterprise bool
-		CertDiscoveryVenafi             *venafi.VenafiConnection // If not nil, cert-discovery-venafi resources will be added to manifests
-		InstallVenafiOauthHelper        bool                     // If true, the Installation manifest will have the venafi-oauth-helper.
-		VenafiIssuers                   []*venafi.VenafiIssuer
-		IstioCSRIssuer                  string // The issuer name to use for the Istio CSR installation.
-		ImageRegistry                   string // A custom image registry to use for operator components.
-		RegistryCredentialsPath         string // Path to a credentials file containing registry credentials for image pull secrets
+		// GenerateApproverPolicies, if true, will generate a CertificateRequestPolicy for each issuer in the
+		// Installation and in PolicyIssuers, which are applied by ApplyApproverPoliciesYAML.
+		GenerateApproverPolicies bool
+		// PolicyIssuers are issuers that are not managed by the Installation, such as those already in the cluster,
+		// that CertificateRequestPolicies are generated for when GenerateApproverPolicies is set.
+		PolicyIssuers            []PolicyIssuer
+		CertDiscoveryVenafi      *venafi.VenafiConnection // If not nil, cert-discovery-venafi resources will be added to manifests
+		InstallVenafiOauthHelper bool                     // If true, the Installation manifest will have the venafi-oauth-helper.
+		InstallTrustManager      bool                     // If true, the Installation manifest will have trust-manager.
+		// TrustNamespace is the namespace that trust-manager reads Bundle sources from. The Installation does not
+		// configure it, it is used to suggest where the Secrets and ConfigMaps used by TrustBundle are created.
+		TrustNamespace string
+		// TrustBundle, if not nil, is a trust-manager Bundle applied by ApplyTrustBundleYAML.
+		TrustBundle             *TrustBundle
+		VenafiIssuers           []*venafi.VenafiIssuer
+		IstioCSRIssuer          string // The issuer name to use for the Istio CSR installation.
+		IstioCSRIssuerKind      string // The kind of the Istio CSR issuer, defaults to Issuer.
+		IstioCSRIssuerGroup     string // The API group of the Istio CSR issuer, defaults to cert-manager.io.
+		IstioCSRIstioNamespace  string // The namespace Istio is installed in, the operator's default is used if blank.
+		ImageRegistry           string // A custom image registry to use for operator components.
+		RegistryCredentialsPath string // Path to a credentials file containing registry credentials for image pull secrets
 		// RegistryCredentials is a string containing a GCP service account key to access the Jetstack Secure image registry.
 		RegistryCredentials     string
-		CertManagerReplicas     int    // The replica count for cert-manager and its components.
+		CertManagerReplicas     int    // The replica count for cert-manager and its components, zero uses the operator's default.
 		CertManagerVersion      string // The version of cert-manager to deploy
-		IstioCSRReplicas        int    // The replica count for the istio-csr component.
-		SpiffeCSIDriverReplicas int    // The replica count for the csi-driver-spiffe component.
+		IstioCSRReplicas        int    // The replica count for the istio-csr component, zero uses the operator's default.
+		SpiffeCSIDriverReplicas int    // The replica count for the csi-driver-spiffe component, zero uses the operator's default.
+		// OperatorVersion is the version of the operator the Installation is applied to, the Installation is validated
+		// against the CRD in its installer. The latest installer is used if it is blank.
+		OperatorVersion string
+		// CertManagerWebhookReplicas is the replica count for the cert-manager webhook, CertManagerReplicas is used if it
+		// is zero.
+		CertManagerWebhookReplicas int
 
 		// ImportedCertManagerIssuers is a list of cert-manager issuers to include in
 		// the generated installation file
@@ -221,32 +247,64 @@ type (
 	}
 )
 
+// InstallationName is the name of the Installation resource applied by ApplyInstallationYAML.
+const InstallationName = clients.InstallationName
+
 // ApplyInstallationYAML generates a YAML bundle that describes the kubernetes manifest for the operator's Installation
 // custom resource. The ApplyInstallationYAMLOptions specify additional options used to configure the installation.
 func ApplyInstallationYAML(ctx context.Context, applier Applier, options ApplyInstallationYAMLOptions) error {
+	manifestTemplates, err := generateManifests(options)
+	if err != nil {
+		return err
+	}
 
-	const (
-		// TODO: what should this be?
-		installationName = "jetstack-secure"
-	)
+	if err := ValidateInstallation(manifestTemplates.installation, options.OperatorVersion); err != nil {
+		return fmt.Errorf("generated Installation is invalid: %w", err)
+	}
 
+	buf, err := marshalManifests(manifestTemplates)
+	if err != nil {
+		return fmt.Errorf("error marshalling manifests: %w", err)
+	}
+
+	return applier.Apply(ctx, buf)
+}
+
+// GenerateInstallation returns the Installation resource that ApplyInstallationYAML would apply for the
+// ApplyInstallationYAMLOptions, without validating or applying it.
+func GenerateInstallation(options ApplyInstallationYAMLOptions) (*operatorv1alpha1.Installation, error) {
+	mf, err := generateManifests(options)
+	if err != nil {
+		return nil, err
+	}
+	return mf.installation, nil
+}
+
+// generateManifests builds the Installation resource and any Secrets it
+// references from the ApplyInstallationYAMLOptions
+func generateManifests(options ApplyInstallationYAMLOptions) (*manifests, error) {
 	apiVersion, kind := operatorv1alpha1.InstallationGVK.ToAPIVersionAndKind()
 
+	webhookReplicas := options.CertManagerWebhookReplicas
+	if webhookReplicas == 0 {
+		webhookReplicas = options.CertManagerReplicas
+	}
+
 	installation := &operatorv1alpha1.Installation{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       kind,
 			APIVersion: apiVersion,
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: installationName,
+			Name: InstallationName,
 		},
 		Spec: operatorv1alpha1.InstallationSpec{
 			CertManager: &operatorv1alpha1.CertManager{
 				Controller: &operatorv1alpha1.CertManagerControllerConfig{
-					ReplicaCount: &options.CertManagerReplicas,
+					ReplicaCount: replicaCount(options.CertManagerReplicas),
 				},
 				Webhook: &operatorv1alpha1.CertManagerWebhookConfig{
-					ReplicaCount: &options.CertManagerReplicas,
+					ReplicaCount: replicaCount(webhookReplicas),
 				},
 			},
 			ApproverPolicy: &operatorv1alpha1.ApproverPolicy{},
@@ -258,7 +316,7 @@ func ApplyInstallationYAML(ctx context.Context, applier Applier, options ApplyIn
 	}
 
 	if err := applyIstioCSRToInstallation(manifestTemplates, options); err != nil {
-		return fmt.Errorf("failed to configure istio csr: %w", err)
+		return nil, fmt.Errorf("failed to configure istio csr: %w", err)
 	}
 
 	applyApproverPolicyEnterpriseToInstallation(manifestTemplates, options)
@@ -269,16 +327,18 @@ func ApplyInstallationYAML(ctx context.Context, applier Applier, options ApplyIn
 
 	applyVenafiOauthHelperToInstallation(manifestTemplates, options)
 
+	applyTrustManagerToInstallation(manifestTemplates, options)
+
 	applyCertDiscoveryVenafiManifests(manifestTemplates, options)
 
 	applyRegistryToManifests(manifestTemplates, options)
 
 	if err := applyImagePullSecretToManifests(manifestTemplates, options); err != nil {
-		return fmt.Errorf("failed to configure component image pull secret: %w", err)
+		return nil, fmt.Errorf("failed to configure component image pull secret: %w", err)
 	}
 
 	if err := generateVenafiIssuerManifests(manifestTemplates, options); err != nil {
-		return fmt.Errorf("error building manifests for Venafi issuers: %w", err)
+		return nil, fmt.Errorf("error building manifests for Venafi issuers: %w", err)
 	}
 
 	err := addIssuersToInstallation(
@@ -289,15 +349,10 @@ func ApplyInstallationYAML(ctx context.Context, applier Applier, options ApplyIn
 		options.ImportedVenafiClusterIssuers,
 	)
 	if err != nil {
-		return fmt.Errorf("error adding issuers to installation: %w", err)
-	}
-
-	buf, err := marshalManifests(manifestTemplates)
-	if err != nil {
-		return fmt.Errorf("error marshalling manifests: %w", err)
+		return nil, fmt.Errorf("error adding issuers to installation: %w", err)
 	}
 
-	return applier.Apply(ctx, buf)
+	return manifestTemplates, nil
 }
 
 func addIssuersToInstallation(
@@ -488,6 +543,15 @@ func applyRegistryToManifests(mf *manifests, options ApplyInstallationYAMLOption
 	}
 }
 
+// replicaCount returns a replica count for components that must have at least one replica. A count of zero is left
+// unset so that the operator's default is used.
+func replicaCount(replicas int) *int {
+	if replicas == 0 {
+		return nil
+	}
+	return &replicas
+}
+
 type manifests struct {
 	installation *operatorv1alpha1.Installation
 	secrets      []*corev1.Secret
@@ -546,7 +610,7 @@ func applyCSIDriversToInstallation(manifests *manifests, options ApplyInstallati
 	if options.InstallSpiffeCSIDriver {
 		assign = true
 		drivers.CertManagerSpiffe = &operatorv1alpha1.CSIDriverCertManagerSpiffe{
-			ReplicaCount: &options.SpiffeCSIDriverReplicas,
+			ReplicaCount: replicaCount(options.SpiffeCSIDriverReplicas),
 		}
 	}
 
@@ -561,22 +625,36 @@ func applyIstioCSRToInstallation(manifests *manifests, options ApplyInstallation
 	}
 
 	manifests.installation.Spec.IstioCSR = &operatorv1alpha1.IstioCSR{
-		ReplicaCount: &options.IstioCSRReplicas,
+		ReplicaCount:   replicaCount(options.IstioCSRReplicas),
+		IstioNamespace: options.IstioCSRIstioNamespace,
 	}
 
 	if options.IstioCSRIssuer == "" {
 		return nil
 	}
 
-	manifests.installation.Spec.IstioCSR.IssuerRef = &certmanagermetav1.ObjectReference{
-		Name:  options.IstioCSRIssuer,
-		Kind:  cmapi.IssuerKind,
-		Group: cmapi.SchemeGroupVersion.Group,
-	}
+	manifests.installation.Spec.IstioCSR.IssuerRef = IstioCSRIssuerRef(options)
 
 	return nil
 }
 
+// IstioCSRIssuerRef returns the reference to the issuer used by istio-csr, filling in the cert-manager Issuer kind and
+// group if they are not set.
+func IstioCSRIssuerRef(options ApplyInstallationYAMLOptions) *certmanagermetav1.ObjectReference {
+	ref := &certmanagermetav1.ObjectReference{
+		Name:  options.IstioCSRIssuer,
+		Kind:  options.IstioCSRIssuerKind,
+		Group: options.IstioCSRIssuerGroup,
+	}
+	if ref.Kind == "" {
+		ref.Kind = cmapi.IssuerKind
+	}
+	if ref.Group == "" {
+		ref.Group = cmapi.SchemeGroupVersion.Group
+	}
+	return ref
+}
+
 func applyVenafiOauthHelperToInstallation(manifests *manifests, options ApplyInstallationYAMLOptions) error {
 	if !options.InstallVenafiOauthHelper {
 		return nil
@@ -584,6 +662,14 @@ func applyVenafiOauthHelperToInstallation(manifests *manifests, options ApplyIns
 	manifests.installation.Spec.VenafiOauthHelper = &operatorv1alpha1.VenafiOauthHelper{}
 	return nil
 }
+
+func applyTrustManagerToInstallation(manifests *manifests, options ApplyInstallationYAMLOptions) {
+	if !options.InstallTrustManager {
+		return
+	}
+	manifests.installation.Spec.TrustManager = &operatorv1alpha1.TrustManager{}
+}
+
 func applyApproverPolicyEnterpriseToInstallation(manifests *manifests, options ApplyInstallationYAMLOptions) error {
 	if !options.InstallApproverPolicyEnterprise {
 		return nil
@@ -609,5 +695,31 @@ func SuggestedActions(options ApplyInstallationYAMLOptions) []prompt.Suggestion
 			))
 	}
 
+	if options.TrustBundle != nil {
+		trustNamespace := options.TrustNamespace
+		if trustNamespace == "" {
+			trustNamespace = DefaultTrustNamespace
+		}
+
+		for _, source := range options.TrustBundle.Sources {
+			if source.Kind == TrustBundleSourceDefaultCAs {
+				continue
+			}
+			suggestions = append(suggestions,
+				prompt.NewSuggestion(
+					prompt.WithMessage("Ensure the %s %q with key %q exists in the trust namespace %q, so that trust-manager can include it in the Bundle %q", source.Kind, source.Name, source.Key, trustNamespace, options.TrustBundle.Name),
+					prompt.WithLink("https://cert-manager.io/docs/projects/trust-manager/"),
+				))
+		}
+	}
+
+	if options.GenerateApproverPolicies {
+		suggestions = append(suggestions,
+			prompt.NewSuggestion(
+				prompt.WithMessage("The generated CertificateRequestPolicies allow any request for their issuer, replace them with policies that only allow the certificates your workloads need"),
+				prompt.WithLink("https://cert-manager.io/docs/projects/approver-policy/"),
+			))
+	}
+
 	return suggestions
 }
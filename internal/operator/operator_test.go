@@ -219,7 +219,8 @@ func TestApplyInstallationYAML(t *testing.T) {
 		assert.NoError(t, yaml.Unmarshal(applier.data.Bytes(), &actual))
 
 		assert.NotNil(t, actual.Spec.IstioCSR)
-		assert.NotNil(t, actual.Spec.IstioCSR.ReplicaCount)
+		assert.Nil(t, actual.Spec.IstioCSR.ReplicaCount)
+		assert.Nil(t, actual.Spec.IstioCSR.IssuerRef)
 	})
 
 	t.Run("It should add the Istio CSR to the installation manifest", func(t *testing.T) {
@@ -244,6 +245,32 @@ func TestApplyInstallationYAML(t *testing.T) {
 		}
 	})
 
+	t.Run("It should add an external issuer and Istio namespace to the Istio CSR", func(t *testing.T) {
+		applier := &TestApplier{}
+		options := operator.ApplyInstallationYAMLOptions{
+			InstallIstioCSR:        true,
+			IstioCSRIssuer:         "google-cas",
+			IstioCSRIssuerKind:     "GoogleCASClusterIssuer",
+			IstioCSRIssuerGroup:    "cas-issuer.jetstack.io",
+			IstioCSRIstioNamespace: "istio",
+		}
+
+		err := operator.ApplyInstallationYAML(ctx, applier, options)
+		assert.NoError(t, err)
+
+		var actual operatorv1alpha1.Installation
+		assert.NoError(t, yaml.Unmarshal(applier.data.Bytes(), &actual))
+
+		if assert.NotNil(t, actual.Spec.IstioCSR) && assert.NotNil(t, actual.Spec.IstioCSR.IssuerRef) {
+			issuer := actual.Spec.IstioCSR.IssuerRef
+
+			assert.EqualValues(t, options.IstioCSRIssuerGroup, issuer.Group)
+			assert.EqualValues(t, options.IstioCSRIssuerKind, issuer.Kind)
+			assert.EqualValues(t, options.IstioCSRIssuer, issuer.Name)
+			assert.EqualValues(t, options.IstioCSRIstioNamespace, actual.Spec.IstioCSR.IstioNamespace)
+		}
+	})
+
 	t.Run("It should set the image registry for components", func(t *testing.T) {
 		applier := &TestApplier{}
 		options := operator.ApplyInstallationYAMLOptions{
@@ -295,6 +322,28 @@ func TestApplyInstallationYAML(t *testing.T) {
 		}
 	})
 
+	t.Run("It should specify a separate replica count for the cert-manager webhook", func(t *testing.T) {
+		applier := &TestApplier{}
+		options := operator.ApplyInstallationYAMLOptions{
+			CertManagerReplicas:        2,
+			CertManagerWebhookReplicas: 3,
+		}
+
+		err := operator.ApplyInstallationYAML(ctx, applier, options)
+		assert.NoError(t, err)
+
+		var actual operatorv1alpha1.Installation
+		assert.NoError(t, yaml.Unmarshal(applier.data.Bytes(), &actual))
+
+		if assert.NotNil(t, actual.Spec.CertManager.Webhook) {
+			assert.EqualValues(t, &options.CertManagerWebhookReplicas, actual.Spec.CertManager.Webhook.ReplicaCount)
+		}
+
+		if assert.NotNil(t, actual.Spec.CertManager.Controller) {
+			assert.EqualValues(t, &options.CertManagerReplicas, actual.Spec.CertManager.Controller.ReplicaCount)
+		}
+	})
+
 	t.Run("It should specify the replica count for csi-driver-spiffe", func(t *testing.T) {
 		applier := &TestApplier{}
 		options := operator.ApplyInstallationYAMLOptions{
@@ -413,7 +462,8 @@ func TestApplyInstallationYAML(t *testing.T) {
 					ApiKey: []veiv1alpha1.SecretSource{
 						{
 							Secret: &veiv1alpha1.Secret{
-								Name: "example",
+								Name:   "example",
+								Fields: []string{"api-key"},
 							},
 						},
 					},
@@ -476,7 +526,8 @@ func TestApplyInstallationYAML(t *testing.T) {
 						ApiKey: []veiv1alpha1.SecretSource{
 							{
 								Secret: &veiv1alpha1.Secret{
-									Name: "example",
+									Name:   "example",
+									Fields: []string{"api-key"},
 								},
 							},
 						},
@@ -0,0 +1,256 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	policyv1alpha1 "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CertificateRequestPolicyCRDName is the name of the approver-policy CertificateRequestPolicy CRD, which is
+	// installed by the operator along with approver-policy or approver-policy-enterprise.
+	CertificateRequestPolicyCRDName = "certificaterequestpolicies.policy.cert-manager.io"
+	// CertManagerServiceAccountNamespace is the namespace of the cert-manager controller's ServiceAccount when
+	// cert-manager is deployed by the operator.
+	CertManagerServiceAccountNamespace = "jetstack-secure"
+	// CertManagerServiceAccountName is the name of the cert-manager controller's ServiceAccount when cert-manager is
+	// deployed by the operator.
+	CertManagerServiceAccountName = "cert-manager"
+
+	// approverPolicyPrefix is prepended to the names of the resources generated by ApplyApproverPoliciesYAML
+	approverPolicyPrefix = "jsctl"
+	// approverPolicyHashLength is the length of the hash suffix added to generated names
+	approverPolicyHashLength = 8
+)
+
+// The PolicyIssuer type identifies an issuer that ApplyApproverPoliciesYAML generates a CertificateRequestPolicy for.
+type PolicyIssuer struct {
+	Name  string
+	Kind  string
+	Group string
+	// Namespace is the namespace of a namespaced issuer, it is blank for cluster scoped issuers
+	Namespace string
+}
+
+// String returns the issuer in the form 'Kind.group namespace/name'.
+func (i PolicyIssuer) String() string {
+	name := i.Name
+	if i.Namespace != "" {
+		name = i.Namespace + "/" + i.Name
+	}
+	return fmt.Sprintf("%s.%s %s", i.Kind, i.Group, name)
+}
+
+// ApplyApproverPoliciesYAML generates a CertificateRequestPolicy for each issuer in the Installation described by
+// the ApplyInstallationYAMLOptions and for each of options.PolicyIssuers, and applies them via the Applier
+// implementation. It does nothing unless options.GenerateApproverPolicies is set.
+//
+// Once approver-policy is installed, cert-manager's default approver is disabled and CertificateRequests for an issuer
+// are only approved if a CertificateRequestPolicy selects it. The generated policies allow any request for their
+// issuer, and are bound to the cert-manager controller's ServiceAccount so that they only apply to CertificateRequests
+// created for Certificate resources. This keeps existing issuers working until stricter policies are written. The
+// CertificateRequestPolicy CRD is installed by the operator once the Installation has been applied, so when applying
+// to a cluster the CRD must be established before this is called.
+func ApplyApproverPoliciesYAML(ctx context.Context, applier Applier, options ApplyInstallationYAMLOptions) error {
+	if !options.GenerateApproverPolicies {
+		return nil
+	}
+
+	issuers, err := ApproverPolicyIssuers(options)
+	if err != nil {
+		return err
+	}
+	if len(issuers) == 0 {
+		return nil
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	for _, issuer := range issuers {
+		for _, object := range generateApproverPolicy(issuer) {
+			data, err := yaml.Marshal(object)
+			if err != nil {
+				return fmt.Errorf("error marshalling approver policy for %s: %w", issuer, err)
+			}
+			if buf.Len() > 0 {
+				buf.WriteString("---\n")
+			}
+			buf.Write(data)
+		}
+	}
+
+	return applier.Apply(ctx, buf)
+}
+
+// ApproverPolicyIssuers returns the issuers that ApplyApproverPoliciesYAML generates policies for, which are the issuers
+// managed by the Installation and options.PolicyIssuers, sorted and without duplicates.
+func ApproverPolicyIssuers(options ApplyInstallationYAMLOptions) ([]PolicyIssuer, error) {
+	installation, err := GenerateInstallation(options)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[PolicyIssuer]bool)
+	var issuers []PolicyIssuer
+	add := func(issuer PolicyIssuer) {
+		if seen[issuer] {
+			return
+		}
+		seen[issuer] = true
+		issuers = append(issuers, issuer)
+	}
+
+	for _, issuer := range installation.Spec.Issuers {
+		policyIssuer := PolicyIssuer{
+			Name:      issuer.Name,
+			Kind:      cmapi.IssuerKind,
+			Group:     cmapi.SchemeGroupVersion.Group,
+			Namespace: issuer.Namespace,
+		}
+		if issuer.ClusterScope {
+			policyIssuer.Kind, policyIssuer.Namespace = cmapi.ClusterIssuerKind, ""
+		}
+		if issuer.VenafiEnhancedIssuer != nil {
+			policyIssuer.Kind, policyIssuer.Group = "VenafiIssuer", veiv1alpha1.SchemeGroupVersion.Group
+			if issuer.ClusterScope {
+				policyIssuer.Kind = "VenafiClusterIssuer"
+			}
+		}
+		add(policyIssuer)
+	}
+	for _, issuer := range options.PolicyIssuers {
+		add(issuer)
+	}
+
+	sort.Slice(issuers, func(i, j int) bool {
+		return issuers[i].String() < issuers[j].String()
+	})
+
+	return issuers, nil
+}
+
+// generateApproverPolicy returns a CertificateRequestPolicy that allows any request for the issuer, along with the
+// ClusterRole and ClusterRoleBinding that allow the cert-manager controller to use it
+func generateApproverPolicy(issuer PolicyIssuer) []interface{} {
+	name := approverPolicyName(issuer)
+	wildcard := []string{"*"}
+	allowAll := &policyv1alpha1.CertificateRequestPolicyAllowedStringSlice{Values: &wildcard}
+	anyValue := "*"
+	isCA := true
+	usages := []cmapi.KeyUsage{
+		cmapi.UsageSigning, cmapi.UsageDigitalSignature, cmapi.UsageContentCommitment, cmapi.UsageKeyEncipherment,
+		cmapi.UsageKeyAgreement, cmapi.UsageDataEncipherment, cmapi.UsageCertSign, cmapi.UsageCRLSign,
+		cmapi.UsageEncipherOnly, cmapi.UsageDecipherOnly, cmapi.UsageAny, cmapi.UsageServerAuth,
+		cmapi.UsageClientAuth, cmapi.UsageCodeSigning, cmapi.UsageEmailProtection, cmapi.UsageSMIME,
+		cmapi.UsageIPsecEndSystem, cmapi.UsageIPsecTunnel, cmapi.UsageIPsecUser, cmapi.UsageTimestamping,
+		cmapi.UsageOCSPSigning, cmapi.UsageMicrosoftSGC, cmapi.UsageNetscapeSGC,
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "jsctl",
+	}
+	annotations := map[string]string{
+		"jetstack.io/issuer": issuer.String(),
+	}
+
+	issuerName, issuerKind, issuerGroup := issuer.Name, issuer.Kind, issuer.Group
+	policy := &policyv1alpha1.CertificateRequestPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: policyv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "CertificateRequestPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+		Spec: policyv1alpha1.CertificateRequestPolicySpec{
+			Allowed: &policyv1alpha1.CertificateRequestPolicyAllowed{
+				CommonName:     &policyv1alpha1.CertificateRequestPolicyAllowedString{Value: &anyValue},
+				DNSNames:       allowAll,
+				IPAddresses:    allowAll,
+				URIs:           allowAll,
+				EmailAddresses: allowAll,
+				IsCA:           &isCA,
+				Usages:         &usages,
+				Subject: &policyv1alpha1.CertificateRequestPolicyAllowedX509Subject{
+					Organizations:       allowAll,
+					Countries:           allowAll,
+					OrganizationalUnits: allowAll,
+					Localities:          allowAll,
+					Provinces:           allowAll,
+					StreetAddresses:     allowAll,
+					PostalCodes:         allowAll,
+					SerialNumber:        &policyv1alpha1.CertificateRequestPolicyAllowedString{Value: &anyValue},
+				},
+			},
+			Selector: policyv1alpha1.CertificateRequestPolicySelector{
+				IssuerRef: &policyv1alpha1.CertificateRequestPolicySelectorIssuerRef{
+					Name:  &issuerName,
+					Kind:  &issuerKind,
+					Group: &issuerGroup,
+				},
+			},
+		},
+	}
+
+	role := &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{policyv1alpha1.SchemeGroupVersion.Group},
+				Resources:     []string{"certificaterequestpolicies"},
+				Verbs:         []string{"use"},
+				ResourceNames: []string{name},
+			},
+		},
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      CertManagerServiceAccountName,
+				Namespace: CertManagerServiceAccountNamespace,
+			},
+		},
+	}
+
+	return []interface{}{policy, role, binding}
+}
+
+// approverPolicyName returns the name of the resources generated for the issuer, which is a valid DNS subdomain made
+// from the kind, namespace and name of the issuer. A hash of the kind, group, namespace and name is always appended,
+// as the joined parts are ambiguous (ns-a/b and ns/a-b would otherwise share a name), and long names are truncated
+// before it.
+func approverPolicyName(issuer PolicyIssuer) string {
+	parts := []string{approverPolicyPrefix, strings.ToLower(issuer.Kind)}
+	if issuer.Namespace != "" {
+		parts = append(parts, issuer.Namespace)
+	}
+	parts = append(parts, issuer.Name)
+	name := strings.Join(parts, "-")
+
+	// none of the parts can contain a slash, so the hashed identity is unambiguous
+	sum := sha256.Sum256([]byte(strings.Join([]string{issuer.Kind, issuer.Group, issuer.Namespace, issuer.Name}, "/")))
+	suffix := hex.EncodeToString(sum[:])[:approverPolicyHashLength]
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(suffix) - 1; len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], "-.")
+	}
+	return name + "-" + suffix
+}
@@ -0,0 +1,149 @@
+package operator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	policyv1alpha1 "github.com/cert-manager/approver-policy/pkg/apis/policy/v1alpha1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	k8syaml "github.com/jetstack/jsctl/internal/kubernetes/yaml"
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func TestApproverPolicyIssuers(t *testing.T) {
+	t.Parallel()
+
+	externalIssuer := operator.PolicyIssuer{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"}
+
+	issuers, err := operator.ApproverPolicyIssuers(operator.ApplyInstallationYAMLOptions{
+		GenerateApproverPolicies: true,
+		ImportedCertManagerIssuers: []*cmapi.Issuer{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default"},
+				Spec:       cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{SelfSigned: &cmapi.SelfSignedIssuer{}}},
+			},
+		},
+		ImportedCertManagerClusterIssuers: []*cmapi.ClusterIssuer{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ca"},
+				Spec:       cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{SelfSigned: &cmapi.SelfSignedIssuer{}}},
+			},
+		},
+		PolicyIssuers: []operator.PolicyIssuer{
+			externalIssuer,
+			// issuers found in the cluster may also be managed by the Installation
+			{Name: "ca", Kind: "Issuer", Group: "cert-manager.io", Namespace: "default"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []operator.PolicyIssuer{
+		externalIssuer,
+		{Name: "ca", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+		{Name: "ca", Kind: "Issuer", Group: "cert-manager.io", Namespace: "default"},
+	}, issuers)
+}
+
+func TestApplyApproverPoliciesYAML(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("It should do nothing unless policies are requested", func(t *testing.T) {
+		applier := &TestApplier{}
+		err := operator.ApplyApproverPoliciesYAML(ctx, applier, operator.ApplyInstallationYAMLOptions{
+			PolicyIssuers: []operator.PolicyIssuer{{Name: "ca", Kind: "ClusterIssuer", Group: "cert-manager.io"}},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, applier.data)
+	})
+
+	t.Run("It should generate a scoped policy and RBAC for each issuer", func(t *testing.T) {
+		applier := &TestApplier{}
+		err := operator.ApplyApproverPoliciesYAML(ctx, applier, operator.ApplyInstallationYAMLOptions{
+			GenerateApproverPolicies: true,
+			PolicyIssuers: []operator.PolicyIssuer{
+				{Name: "ca", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+				{Name: "vault", Kind: "Issuer", Group: "cert-manager.io", Namespace: "team-a"},
+			},
+		})
+		require.NoError(t, err)
+
+		objects, err := k8syaml.Load(applier.data)
+		require.NoError(t, err)
+		require.Len(t, objects, 6)
+
+		var policy policyv1alpha1.CertificateRequestPolicy
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(objects[3].Object, &policy))
+		assert.Equal(t, "jsctl-issuer-team-a-vault-bf6c3f26", policy.Name)
+		assert.Equal(t, "vault", *policy.Spec.Selector.IssuerRef.Name)
+		assert.Equal(t, "Issuer", *policy.Spec.Selector.IssuerRef.Kind)
+		assert.Equal(t, "cert-manager.io", *policy.Spec.Selector.IssuerRef.Group)
+		assert.Equal(t, []string{"*"}, *policy.Spec.Allowed.DNSNames.Values)
+
+		var role rbacv1.ClusterRole
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(objects[4].Object, &role))
+		require.Len(t, role.Rules, 1)
+		assert.Equal(t, []string{"use"}, role.Rules[0].Verbs)
+		assert.Equal(t, []string{policy.Name}, role.Rules[0].ResourceNames)
+
+		var binding rbacv1.ClusterRoleBinding
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(objects[5].Object, &binding))
+		assert.Equal(t, role.Name, binding.RoleRef.Name)
+		assert.Equal(t, []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      operator.CertManagerServiceAccountName,
+			Namespace: operator.CertManagerServiceAccountNamespace,
+		}}, binding.Subjects)
+
+		assert.Equal(t, "jsctl-clusterissuer-ca-9f88f6e2", objects[0].GetName())
+	})
+
+	t.Run("It should give issuers with ambiguous names different policies", func(t *testing.T) {
+		applier := &TestApplier{}
+		err := operator.ApplyApproverPoliciesYAML(ctx, applier, operator.ApplyInstallationYAMLOptions{
+			GenerateApproverPolicies: true,
+			PolicyIssuers: []operator.PolicyIssuer{
+				{Name: "b", Kind: "Issuer", Group: "cert-manager.io", Namespace: "ns-a"},
+				{Name: "a-b", Kind: "Issuer", Group: "cert-manager.io", Namespace: "ns"},
+				{Name: "a-b", Kind: "Issuer", Group: "example.com", Namespace: "ns"},
+			},
+		})
+		require.NoError(t, err)
+
+		objects, err := k8syaml.Load(applier.data)
+		require.NoError(t, err)
+		require.Len(t, objects, 9)
+
+		names := map[string]bool{objects[0].GetName(): true, objects[3].GetName(): true, objects[6].GetName(): true}
+		assert.Len(t, names, 3)
+	})
+
+	t.Run("It should keep truncated names unique", func(t *testing.T) {
+		long := strings.Repeat("a", 250)
+		applier := &TestApplier{}
+		err := operator.ApplyApproverPoliciesYAML(ctx, applier, operator.ApplyInstallationYAMLOptions{
+			GenerateApproverPolicies: true,
+			PolicyIssuers: []operator.PolicyIssuer{
+				{Name: long + "-1", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+				{Name: long + "-2", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+			},
+		})
+		require.NoError(t, err)
+
+		objects, err := k8syaml.Load(applier.data)
+		require.NoError(t, err)
+		require.Len(t, objects, 6)
+
+		first, second := objects[0].GetName(), objects[3].GetName()
+		assert.NotEqual(t, first, second)
+		assert.LessOrEqual(t, len(first), 253)
+		assert.LessOrEqual(t, len(second), 253)
+	})
+}
@@ -0,0 +1,49 @@
+package operator
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The ManifestResource type identifies a single resource from an embedded operator installer.
+type ManifestResource struct {
+	// Resource is the group, version and resource used to address the resource in the Kubernetes API
+	Resource  schema.GroupVersionResource
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r ManifestResource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// RemovalOrder returns the resources in the embedded installer for version, or the latest installer if version is
+// empty, in the order they should be deleted. This is the reverse of the order they are applied in, so that the
+// operator's webhooks and Deployments are removed before the RBAC, CRD and Secrets they depend on.
+func RemovalOrder(version string) ([]ManifestResource, error) {
+	objects, err := manifestObjects(version)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]ManifestResource, 0, len(objects))
+	for i := len(objects) - 1; i >= 0; i-- {
+		object := objects[i]
+
+		gvr, _ := meta.UnsafeGuessKindToResource(object.GroupVersionKind())
+		resources = append(resources, ManifestResource{
+			Resource:  gvr,
+			Kind:      object.GetKind(),
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		})
+	}
+
+	return resources, nil
+}
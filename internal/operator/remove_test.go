@@ -0,0 +1,37 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRemovalOrder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should list the installer resources in reverse order", func(t *testing.T) {
+		resources, err := RemovalOrder("v0.0.1-alpha.20")
+		require.NoError(t, err)
+		require.NotEmpty(t, resources)
+
+		assert.Equal(t, ManifestResource{
+			Resource: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"},
+			Kind:     "ValidatingWebhookConfiguration",
+			Name:     "js-operator",
+		}, resources[0])
+		assert.Equal(t, ManifestResource{
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"},
+			Kind:      "ServiceAccount",
+			Namespace: "jetstack-secure",
+			Name:      "js-operator-cainjector",
+		}, resources[len(resources)-1])
+		assert.Equal(t, "ServiceAccount jetstack-secure/js-operator-cainjector", resources[len(resources)-1].String())
+	})
+
+	t.Run("It should return an error for a version that does not exist", func(t *testing.T) {
+		_, err := RemovalOrder("v99.99.99")
+		assert.ErrorIs(t, err, ErrNoManifest)
+	})
+}
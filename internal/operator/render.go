@@ -0,0 +1,285 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	k8syaml "github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+// The SecretFormat type describes how Secrets are written by Render.
+type SecretFormat string
+
+const (
+	// SecretFormatPlain writes Secrets as they would be applied, including their data
+	SecretFormatPlain SecretFormat = "plain"
+	// SecretFormatSealedSecret writes a Bitnami SealedSecret placeholder for each Secret, the encrypted values must
+	// be generated with kubeseal before the manifests are committed
+	SecretFormatSealedSecret SecretFormat = "sealed-secret"
+	// SecretFormatExternalSecret writes an External Secrets Operator ExternalSecret for each Secret, which reads each
+	// key from a secret store that must be configured separately
+	SecretFormatExternalSecret SecretFormat = "external-secret"
+)
+
+// SecretFormats lists the supported values of SecretFormat.
+var SecretFormats = []SecretFormat{SecretFormatPlain, SecretFormatSealedSecret, SecretFormatExternalSecret}
+
+// placeholderValue is written in place of values that must be filled in before the rendered manifests can be applied
+const placeholderValue = "REPLACE_ME"
+
+type (
+	// The RenderOptions type contains the options used to render the operator and Installation manifests.
+	RenderOptions struct {
+		Operator     ApplyOperatorYAMLOptions
+		Installation ApplyInstallationYAMLOptions
+		SecretFormat SecretFormat
+	}
+
+	// The RenderedFile type is a single file produced by Render.
+	RenderedFile struct {
+		// Path is the path of the file, relative to the output directory
+		Path string
+		Data []byte
+		// Sensitive is true if the file contains Secret data in plain text
+		Sensitive bool
+	}
+)
+
+// Render generates the manifests for the operator and its Installation, split into separate files that can be
+// committed to a GitOps repository. The operator manifests, each Secret, the Installation, any trust-manager Bundle
+// and any approver policies are written to their own files, along with a kustomization.yaml that references them all.
+func Render(ctx context.Context, options RenderOptions) ([]RenderedFile, error) {
+	secretFormat := options.SecretFormat
+	if secretFormat == "" {
+		secretFormat = SecretFormatPlain
+	}
+
+	var operatorManifests, installationManifests, trustBundleManifests, approverPolicyManifests bufferApplier
+	if err := ApplyOperatorYAML(ctx, &operatorManifests, options.Operator); err != nil {
+		return nil, err
+	}
+	if err := ApplyInstallationYAML(ctx, &installationManifests, options.Installation); err != nil {
+		return nil, err
+	}
+	if err := ApplyTrustBundleYAML(ctx, &trustBundleManifests, options.Installation); err != nil {
+		return nil, err
+	}
+	if err := ApplyApproverPoliciesYAML(ctx, &approverPolicyManifests, options.Installation); err != nil {
+		return nil, err
+	}
+
+	operatorObjects, err := k8syaml.Load(&operatorManifests)
+	if err != nil {
+		return nil, fmt.Errorf("error loading operator manifests: %w", err)
+	}
+	installationObjects, err := k8syaml.Load(&installationManifests)
+	if err != nil {
+		return nil, fmt.Errorf("error loading installation manifests: %w", err)
+	}
+
+	var operatorFile, installationFile bytes.Buffer
+	secrets := make(map[string]*unstructured.Unstructured)
+
+	for _, object := range operatorObjects {
+		// image pull secrets are generated by jsctl rather than being part of the operator's installer
+		if object.GetKind() == "Secret" && object.Object["type"] == string(corev1.SecretTypeDockerConfigJson) {
+			secrets[secretFileName(object)] = object
+			continue
+		}
+		if err := writeDocument(&operatorFile, object.Object); err != nil {
+			return nil, err
+		}
+	}
+	for _, object := range installationObjects {
+		if object.GetKind() == "Secret" {
+			secrets[secretFileName(object)] = object
+			continue
+		}
+		if err := writeDocument(&installationFile, object.Object); err != nil {
+			return nil, err
+		}
+	}
+
+	files := []RenderedFile{{Path: "operator.yaml", Data: operatorFile.Bytes()}}
+
+	secretFileNames := make([]string, 0, len(secrets))
+	for name := range secrets {
+		secretFileNames = append(secretFileNames, name)
+	}
+	sort.Strings(secretFileNames)
+
+	for _, name := range secretFileNames {
+		secret := secrets[name]
+
+		var rendered bytes.Buffer
+		var renderedObject map[string]interface{}
+		switch secretFormat {
+		case SecretFormatPlain:
+			renderedObject = secret.Object
+		case SecretFormatSealedSecret:
+			renderedObject = sealedSecretPlaceholder(secret)
+		case SecretFormatExternalSecret:
+			renderedObject = externalSecretPlaceholder(secret)
+		default:
+			return nil, fmt.Errorf("unsupported secret format %q", secretFormat)
+		}
+		if err := writeDocument(&rendered, renderedObject); err != nil {
+			return nil, err
+		}
+
+		files = append(files, RenderedFile{
+			Path:      name,
+			Data:      rendered.Bytes(),
+			Sensitive: secretFormat == SecretFormatPlain,
+		})
+	}
+
+	files = append(files, RenderedFile{Path: "installation.yaml", Data: installationFile.Bytes()})
+	if trustBundleManifests.Len() > 0 {
+		files = append(files, RenderedFile{Path: "trust-bundle.yaml", Data: trustBundleManifests.Bytes()})
+	}
+	if approverPolicyManifests.Len() > 0 {
+		files = append(files, RenderedFile{Path: "approver-policies.yaml", Data: approverPolicyManifests.Bytes()})
+	}
+
+	resources := make([]string, len(files))
+	for i, file := range files {
+		resources[i] = file.Path
+	}
+	kustomization, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kustomization: %w", err)
+	}
+
+	return append(files, RenderedFile{Path: "kustomization.yaml", Data: kustomization}), nil
+}
+
+type bufferApplier struct {
+	bytes.Buffer
+}
+
+func (b *bufferApplier) Apply(_ context.Context, r io.Reader) error {
+	_, err := b.ReadFrom(r)
+	return err
+}
+
+func secretFileName(secret *unstructured.Unstructured) string {
+	return path.Join("secrets", fmt.Sprintf("%s-%s.yaml", secret.GetNamespace(), secret.GetName()))
+}
+
+func writeDocument(w *bytes.Buffer, object map[string]interface{}) error {
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("error marshalling manifest: %w", err)
+	}
+	if w.Len() > 0 {
+		w.WriteString("---\n")
+	}
+	w.Write(data)
+	return nil
+}
+
+// secretKeys returns the sorted keys of both the data and stringData of a Secret
+func secretKeys(secret *unstructured.Unstructured) []string {
+	var keys []string
+	for _, field := range []string{"data", "stringData"} {
+		values, _, _ := unstructured.NestedMap(secret.Object, field)
+		for key := range values {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// secretTemplate returns the metadata and type of a Secret, used by SealedSecrets and ExternalSecrets to create the
+// Secret in the cluster
+func secretTemplate(secret *unstructured.Unstructured) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"name":      secret.GetName(),
+		"namespace": secret.GetNamespace(),
+	}
+	if labels := secret.GetLabels(); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	if annotations := secret.GetAnnotations(); len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+
+	template := map[string]interface{}{"metadata": metadata}
+	if secretType, ok := secret.Object["type"]; ok {
+		template["type"] = secretType
+	}
+	return template
+}
+
+func sealedSecretPlaceholder(secret *unstructured.Unstructured) map[string]interface{} {
+	encryptedData := make(map[string]interface{})
+	for _, key := range secretKeys(secret) {
+		encryptedData[key] = placeholderValue
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "bitnami.com/v1alpha1",
+		"kind":       "SealedSecret",
+		"metadata": map[string]interface{}{
+			"name":      secret.GetName(),
+			"namespace": secret.GetNamespace(),
+		},
+		"spec": map[string]interface{}{
+			"encryptedData": encryptedData,
+			"template":      secretTemplate(secret),
+		},
+	}
+}
+
+func externalSecretPlaceholder(secret *unstructured.Unstructured) map[string]interface{} {
+	var data []interface{}
+	for _, key := range secretKeys(secret) {
+		data = append(data, map[string]interface{}{
+			"secretKey": key,
+			"remoteRef": map[string]interface{}{
+				"key":      fmt.Sprintf("%s/%s", secret.GetNamespace(), secret.GetName()),
+				"property": key,
+			},
+		})
+	}
+
+	template := secretTemplate(secret)
+	delete(template, "metadata")
+	if labels := secret.GetLabels(); len(labels) > 0 {
+		template["metadata"] = map[string]interface{}{"labels": labels}
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "external-secrets.io/v1beta1",
+		"kind":       "ExternalSecret",
+		"metadata": map[string]interface{}{
+			"name":      secret.GetName(),
+			"namespace": secret.GetNamespace(),
+		},
+		"spec": map[string]interface{}{
+			"secretStoreRef": map[string]interface{}{
+				"name": placeholderValue,
+				"kind": "SecretStore",
+			},
+			"target": map[string]interface{}{
+				"name":     secret.GetName(),
+				"template": template,
+			},
+			"data": data,
+		},
+	}
+}
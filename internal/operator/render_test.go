@@ -0,0 +1,100 @@
+package operator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/jsctl/internal/operator"
+	"github.com/jetstack/jsctl/internal/venafi"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	options := func(format operator.SecretFormat) operator.RenderOptions {
+		return operator.RenderOptions{
+			Operator: operator.ApplyOperatorYAMLOptions{
+				Version:             "v0.0.1-alpha.20",
+				ImageRegistry:       "eu.gcr.io/jetstack-secure-enterprise",
+				RegistryCredentials: "{'foo': 'bar'}",
+			},
+			Installation: operator.ApplyInstallationYAMLOptions{
+				RegistryCredentials: "{'foo': 'bar'}",
+				CertDiscoveryVenafi: &venafi.VenafiConnection{
+					URL:         "foo",
+					Zone:        "foozone",
+					AccessToken: "footoken",
+				},
+			},
+			SecretFormat: format,
+		}
+	}
+
+	paths := func(files []operator.RenderedFile) []string {
+		out := make([]string, len(files))
+		for i, file := range files {
+			out[i] = file.Path
+		}
+		return out
+	}
+
+	t.Run("It should split the manifests into separate files", func(t *testing.T) {
+		files, err := operator.Render(ctx, options(operator.SecretFormatPlain))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"operator.yaml",
+			"secrets/jetstack-secure-access-token.yaml",
+			"secrets/jetstack-secure-jse-gcr-creds.yaml",
+			"installation.yaml",
+			"kustomization.yaml",
+		}, paths(files))
+
+		assert.NotContains(t, string(files[0].Data), "kubernetes.io/dockerconfigjson")
+		assert.True(t, files[1].Sensitive)
+		assert.Contains(t, string(files[1].Data), "Zm9vdG9rZW4=")
+		assert.Contains(t, string(files[3].Data), "kind: Installation")
+
+		var kustomization struct {
+			Resources []string `json:"resources"`
+		}
+		require.NoError(t, yaml.Unmarshal(files[4].Data, &kustomization))
+		assert.Equal(t, paths(files[:4]), kustomization.Resources)
+	})
+
+	tests := map[string]struct {
+		format   operator.SecretFormat
+		wantKind string
+	}{
+		"sealed secrets should not contain secret values": {
+			format:   operator.SecretFormatSealedSecret,
+			wantKind: "kind: SealedSecret",
+		},
+		"external secrets should not contain secret values": {
+			format:   operator.SecretFormatExternalSecret,
+			wantKind: "kind: ExternalSecret",
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			files, err := operator.Render(ctx, options(scenario.format))
+			require.NoError(t, err)
+
+			for _, file := range files {
+				if !strings.HasPrefix(file.Path, "secrets/") {
+					continue
+				}
+				assert.False(t, file.Sensitive)
+				assert.Contains(t, string(file.Data), scenario.wantKind)
+				assert.Contains(t, string(file.Data), "REPLACE_ME")
+				assert.NotContains(t, string(file.Data), "Zm9vdG9rZW4=")
+			}
+		})
+	}
+}
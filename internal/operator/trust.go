@@ -0,0 +1,141 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TrustBundleCRDName is the name of the trust-manager Bundle CRD, which is installed by the operator when
+	// trust-manager is enabled.
+	TrustBundleCRDName = "bundles.trust.cert-manager.io"
+	// DefaultTrustNamespace is the namespace that trust-manager reads Bundle sources from by default.
+	DefaultTrustNamespace = "cert-manager"
+	// TrustBundleTargetKey is the key of the ConfigMap that trust-manager writes to each namespace for Bundles
+	// generated by jsctl.
+	TrustBundleTargetKey = "ca-certificates.crt"
+)
+
+// The TrustBundleSourceKind type describes where trust-manager reads the certificates of a Bundle source from.
+type TrustBundleSourceKind string
+
+const (
+	// TrustBundleSourceSecret reads certificates from a key of a Secret in the trust namespace
+	TrustBundleSourceSecret TrustBundleSourceKind = "secret"
+	// TrustBundleSourceConfigMap reads certificates from a key of a ConfigMap in the trust namespace
+	TrustBundleSourceConfigMap TrustBundleSourceKind = "configmap"
+	// TrustBundleSourceDefaultCAs uses the default CA package shipped with trust-manager
+	TrustBundleSourceDefaultCAs TrustBundleSourceKind = "default-cas"
+)
+
+type (
+	// The TrustBundle type describes a trust-manager Bundle to create alongside the Installation.
+	TrustBundle struct {
+		Name    string
+		Sources []TrustBundleSource
+	}
+
+	// The TrustBundleSource type describes a single source of certificates for a TrustBundle.
+	TrustBundleSource struct {
+		Kind TrustBundleSourceKind
+		// Name is the name of the Secret or ConfigMap, it is unused for the default CAs
+		Name string
+		// Key is the key of the Secret or ConfigMap containing PEM-encoded certificates
+		Key string
+	}
+)
+
+// ParseTrustBundleSource parses a Bundle source in the form 'secret:name:key', 'configmap:name:key' or 'default-cas'.
+func ParseTrustBundleSource(source string) (TrustBundleSource, error) {
+	parts := strings.Split(source, ":")
+	switch kind := TrustBundleSourceKind(parts[0]); {
+	case kind == TrustBundleSourceDefaultCAs && len(parts) == 1:
+		return TrustBundleSource{Kind: kind}, nil
+	case (kind == TrustBundleSourceSecret || kind == TrustBundleSourceConfigMap) && len(parts) == 3 && parts[1] != "" && parts[2] != "":
+		return TrustBundleSource{Kind: kind, Name: parts[1], Key: parts[2]}, nil
+	}
+	return TrustBundleSource{}, fmt.Errorf("invalid trust bundle source %q, expected 'secret:name:key', 'configmap:name:key' or 'default-cas'", source)
+}
+
+// String returns the source in the form accepted by ParseTrustBundleSource.
+func (s TrustBundleSource) String() string {
+	if s.Kind == TrustBundleSourceDefaultCAs {
+		return string(s.Kind)
+	}
+	return strings.Join([]string{string(s.Kind), s.Name, s.Key}, ":")
+}
+
+// ApplyTrustBundleYAML generates the trust-manager Bundle described by the ApplyInstallationYAMLOptions and applies
+// it via the Applier implementation. It does nothing if no Bundle is configured. The Bundle CRD is installed by the
+// operator once the Installation has been applied, so when applying to a cluster the CRD must be established before
+// this is called.
+func ApplyTrustBundleYAML(ctx context.Context, applier Applier, options ApplyInstallationYAMLOptions) error {
+	if options.TrustBundle == nil {
+		return nil
+	}
+
+	bundle, err := generateTrustBundle(options.TrustBundle)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(bundle.Object)
+	if err != nil {
+		return fmt.Errorf("error marshalling Bundle resource: %w", err)
+	}
+
+	return applier.Apply(ctx, bytes.NewReader(data))
+}
+
+func generateTrustBundle(bundle *TrustBundle) (*unstructured.Unstructured, error) {
+	if bundle.Name == "" {
+		return nil, errors.New("trust bundle name must be specified")
+	}
+	if len(bundle.Sources) == 0 {
+		return nil, fmt.Errorf("trust bundle %s must have at least one source", bundle.Name)
+	}
+
+	var sources []interface{}
+	var useDefaultCAs bool
+	for _, source := range bundle.Sources {
+		switch source.Kind {
+		case TrustBundleSourceDefaultCAs:
+			// trust-manager only accepts a single default CAs source per Bundle
+			if useDefaultCAs {
+				continue
+			}
+			useDefaultCAs = true
+			sources = append(sources, map[string]interface{}{"useDefaultCAs": true})
+		case TrustBundleSourceSecret:
+			sources = append(sources, map[string]interface{}{
+				"secret": map[string]interface{}{"name": source.Name, "key": source.Key},
+			})
+		case TrustBundleSourceConfigMap:
+			sources = append(sources, map[string]interface{}{
+				"configMap": map[string]interface{}{"name": source.Name, "key": source.Key},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported trust bundle source %q", source.Kind)
+		}
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "trust.cert-manager.io/v1alpha1",
+		"kind":       "Bundle",
+		"metadata": map[string]interface{}{
+			"name": bundle.Name,
+		},
+		"spec": map[string]interface{}{
+			"sources": sources,
+			"target": map[string]interface{}{
+				"configMap": map[string]interface{}{"key": TrustBundleTargetKey},
+			},
+		},
+	}}, nil
+}
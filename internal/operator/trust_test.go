@@ -0,0 +1,110 @@
+package operator_test
+
+import (
+	"context"
+	"testing"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/jsctl/internal/operator"
+)
+
+func TestParseTrustBundleSource(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		source  string
+		want    operator.TrustBundleSource
+		wantErr bool
+	}{
+		"secret source should be parsed": {
+			source: "secret:ca:ca.crt",
+			want:   operator.TrustBundleSource{Kind: operator.TrustBundleSourceSecret, Name: "ca", Key: "ca.crt"},
+		},
+		"configmap source should be parsed": {
+			source: "configmap:ca:ca.crt",
+			want:   operator.TrustBundleSource{Kind: operator.TrustBundleSourceConfigMap, Name: "ca", Key: "ca.crt"},
+		},
+		"default CAs source should be parsed": {
+			source: "default-cas",
+			want:   operator.TrustBundleSource{Kind: operator.TrustBundleSourceDefaultCAs},
+		},
+		"source without a key should fail": {
+			source:  "secret:ca",
+			wantErr: true,
+		},
+		"unknown source should fail": {
+			source:  "file:ca:ca.crt",
+			wantErr: true,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			source, err := operator.ParseTrustBundleSource(scenario.source)
+			if scenario.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, scenario.want, source)
+			assert.Equal(t, scenario.source, source.String())
+		})
+	}
+}
+
+func TestApplyTrustBundleYAML(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("It should add trust-manager to the Installation", func(t *testing.T) {
+		installation, err := operator.GenerateInstallation(operator.ApplyInstallationYAMLOptions{InstallTrustManager: true})
+		require.NoError(t, err)
+		assert.Equal(t, &operatorv1alpha1.TrustManager{}, installation.Spec.TrustManager)
+	})
+
+	t.Run("It should not apply anything without a Bundle", func(t *testing.T) {
+		applier := &TestApplier{}
+		require.NoError(t, operator.ApplyTrustBundleYAML(ctx, applier, operator.ApplyInstallationYAMLOptions{InstallTrustManager: true}))
+		assert.Nil(t, applier.data)
+	})
+
+	t.Run("It should generate a Bundle from each source", func(t *testing.T) {
+		applier := &TestApplier{}
+		options := operator.ApplyInstallationYAMLOptions{
+			InstallTrustManager: true,
+			TrustBundle: &operator.TrustBundle{
+				Name: "ca-bundle",
+				Sources: []operator.TrustBundleSource{
+					{Kind: operator.TrustBundleSourceDefaultCAs},
+					{Kind: operator.TrustBundleSourceSecret, Name: "ca", Key: "ca.crt"},
+					{Kind: operator.TrustBundleSourceConfigMap, Name: "extra", Key: "root.pem"},
+				},
+			},
+		}
+		require.NoError(t, operator.ApplyTrustBundleYAML(ctx, applier, options))
+
+		expected := `apiVersion: trust.cert-manager.io/v1alpha1
+kind: Bundle
+metadata:
+  name: ca-bundle
+spec:
+  sources:
+  - useDefaultCAs: true
+  - secret:
+      key: ca.crt
+      name: ca
+  - configMap:
+      key: root.pem
+      name: extra
+  target:
+    configMap:
+      key: ca-certificates.crt
+`
+		assert.YAMLEq(t, expected, applier.data.String())
+
+		suggestions := operator.SuggestedActions(options)
+		require.Len(t, suggestions, 2)
+	})
+}
@@ -0,0 +1,212 @@
+package operator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+// The CRDChange type describes how a CRD differs between the installers of two
+// operator versions.
+type CRDChange struct {
+	Name string
+	// Added is true if the CRD is only present in the newer installer
+	Added bool
+	// Removed is true if the CRD is only present in the older installer
+	Removed bool
+	// AddedVersions and RemovedVersions list the API versions served by
+	// only one of the installers
+	AddedVersions   []string
+	RemovedVersions []string
+	// ChangedVersions lists the API versions served by both installers that
+	// have a different schema
+	ChangedVersions []string
+}
+
+func (c CRDChange) String() string {
+	switch {
+	case c.Added:
+		return fmt.Sprintf("%s: added", c.Name)
+	case c.Removed:
+		return fmt.Sprintf("%s: removed", c.Name)
+	}
+
+	var changes []string
+	if len(c.AddedVersions) > 0 {
+		changes = append(changes, fmt.Sprintf("added versions %s", strings.Join(c.AddedVersions, ", ")))
+	}
+	if len(c.RemovedVersions) > 0 {
+		changes = append(changes, fmt.Sprintf("removed versions %s", strings.Join(c.RemovedVersions, ", ")))
+	}
+	if len(c.ChangedVersions) > 0 {
+		changes = append(changes, fmt.Sprintf("schema changed in %s", strings.Join(c.ChangedVersions, ", ")))
+	}
+
+	return fmt.Sprintf("%s: %s", c.Name, strings.Join(changes, "; "))
+}
+
+// DiffCRDs compares the CRDs in the embedded installers of two operator
+// versions. ErrNoManifest is returned if either version is not embedded.
+func DiffCRDs(fromVersion, toVersion string) ([]CRDChange, error) {
+	from, err := manifestCRDs(fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := manifestCRDs(toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffCRDs(from, to), nil
+}
+
+// ManifestDeployments returns the namespaced names of the Deployments in the
+// embedded installer for the operator version, or the latest version if empty.
+func ManifestDeployments(version string) ([]types.NamespacedName, error) {
+	objects, err := manifestObjects(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var deployments []types.NamespacedName
+	for _, object := range objects {
+		if object.GetKind() != "Deployment" {
+			continue
+		}
+		deployments = append(deployments, types.NamespacedName{
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		})
+	}
+
+	return deployments, nil
+}
+
+func diffCRDs(from, to []*apiextensionsv1.CustomResourceDefinition) []CRDChange {
+	fromByName := make(map[string]*apiextensionsv1.CustomResourceDefinition)
+	for _, crd := range from {
+		fromByName[crd.Name] = crd
+	}
+	toByName := make(map[string]*apiextensionsv1.CustomResourceDefinition)
+	for _, crd := range to {
+		toByName[crd.Name] = crd
+	}
+
+	var changes []CRDChange
+	for name, fromCRD := range fromByName {
+		toCRD, ok := toByName[name]
+		if !ok {
+			changes = append(changes, CRDChange{Name: name, Removed: true})
+			continue
+		}
+
+		change := diffCRDVersions(fromCRD, toCRD)
+		if len(change.AddedVersions)+len(change.RemovedVersions)+len(change.ChangedVersions) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	for name := range toByName {
+		if _, ok := fromByName[name]; !ok {
+			changes = append(changes, CRDChange{Name: name, Added: true})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
+
+func diffCRDVersions(from, to *apiextensionsv1.CustomResourceDefinition) CRDChange {
+	change := CRDChange{Name: from.Name}
+
+	fromVersions := make(map[string]apiextensionsv1.CustomResourceDefinitionVersion)
+	for _, version := range from.Spec.Versions {
+		fromVersions[version.Name] = version
+	}
+
+	for _, toVersion := range to.Spec.Versions {
+		fromVersion, ok := fromVersions[toVersion.Name]
+		if !ok {
+			change.AddedVersions = append(change.AddedVersions, toVersion.Name)
+			continue
+		}
+		delete(fromVersions, toVersion.Name)
+
+		if !reflect.DeepEqual(fromVersion.Schema, toVersion.Schema) {
+			change.ChangedVersions = append(change.ChangedVersions, toVersion.Name)
+		}
+	}
+	for name := range fromVersions {
+		change.RemovedVersions = append(change.RemovedVersions, name)
+	}
+	sort.Strings(change.RemovedVersions)
+
+	return change
+}
+
+func manifestCRDs(version string) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	objects, err := manifestObjects(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	for _, object := range objects {
+		if object.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+
+		var crd apiextensionsv1.CustomResourceDefinition
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &crd)
+		if err != nil {
+			return nil, fmt.Errorf("error converting CRD %s: %w", object.GetName(), err)
+		}
+		crds = append(crds, &crd)
+	}
+
+	return crds, nil
+}
+
+// manifestObjects parses the embedded installer for version. Template fields
+// are filled with placeholder values since only the structure of the installer
+// is of interest.
+func manifestObjects(version string) ([]*unstructured.Unstructured, error) {
+	file, err := operatorManifest(version)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest contents: %w", err)
+	}
+
+	tpl, err := template.New("install").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error creating new template: %w", err)
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	err = tpl.Execute(output, map[string]interface{}{
+		"ImageRegistry": "registry",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest template: %w", err)
+	}
+
+	return yaml.Load(output)
+}
@@ -0,0 +1,77 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDiffCRDs(t *testing.T) {
+	t.Parallel()
+
+	crd := func(name string, versions ...apiextensionsv1.CustomResourceDefinitionVersion) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Versions: versions},
+		}
+	}
+	version := func(name, description string) apiextensionsv1.CustomResourceDefinitionVersion {
+		return apiextensionsv1.CustomResourceDefinitionVersion{
+			Name: name,
+			Schema: &apiextensionsv1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Description: description},
+			},
+		}
+	}
+
+	t.Run("It should find no changes between identical installers", func(t *testing.T) {
+		changes, err := DiffCRDs("v0.0.1-alpha.20", "v0.0.1-alpha.20")
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("It should return an error for a version that does not exist", func(t *testing.T) {
+		_, err := DiffCRDs("v99.99.99", "v0.0.1-alpha.20")
+		assert.ErrorIs(t, err, ErrNoManifest)
+	})
+
+	t.Run("It should find added, removed and changed CRDs", func(t *testing.T) {
+		from := []*apiextensionsv1.CustomResourceDefinition{
+			crd("removed.jetstack.io", version("v1", "")),
+			crd("unchanged.jetstack.io", version("v1", "")),
+			crd("changed.jetstack.io", version("v1alpha1", ""), version("v1alpha2", "old")),
+		}
+		to := []*apiextensionsv1.CustomResourceDefinition{
+			crd("added.jetstack.io", version("v1", "")),
+			crd("unchanged.jetstack.io", version("v1", "")),
+			crd("changed.jetstack.io", version("v1alpha2", "new"), version("v1", "")),
+		}
+
+		changes := diffCRDs(from, to)
+		assert.Equal(t, []CRDChange{
+			{Name: "added.jetstack.io", Added: true},
+			{
+				Name:            "changed.jetstack.io",
+				AddedVersions:   []string{"v1"},
+				RemovedVersions: []string{"v1alpha1"},
+				ChangedVersions: []string{"v1alpha2"},
+			},
+			{Name: "removed.jetstack.io", Removed: true},
+		}, changes)
+		assert.Equal(t, "changed.jetstack.io: added versions v1; removed versions v1alpha1; schema changed in v1alpha2", changes[1].String())
+	})
+}
+
+func TestManifestDeployments(t *testing.T) {
+	t.Parallel()
+
+	deployments, err := ManifestDeployments("v0.0.1-alpha.20")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.NamespacedName{
+		{Namespace: "jetstack-secure", Name: "js-operator-cainjector"},
+		{Namespace: "jetstack-secure", Name: "js-operator-operator"},
+	}, deployments)
+}
@@ -0,0 +1,444 @@
+package operator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/yaml"
+)
+
+// installationCRDName is the name of the Installation CRD in the operator's installer manifest
+const installationCRDName = "installations.operator.jetstack.io"
+
+// ValidateInstallation checks an Installation against the OpenAPI schema of the Installation CRD in the embedded
+// installer for the given operator version, or the latest installer if version is empty. The CRD's
+// x-kubernetes-validations rules are not evaluated, but those that jsctl knows about are checked along with the rules
+// enforced by the operator's validating webhook, so that problems are found without needing a cluster. The returned
+// error lists every problem found.
+func ValidateInstallation(installation *operatorv1alpha1.Installation, version string) error {
+	object, err := jsonObject(installation)
+	if err != nil {
+		return fmt.Errorf("error converting Installation: %w", err)
+	}
+
+	return validateInstallation(object, installation, version)
+}
+
+// ValidateInstallationManifest checks each Installation in a stream of YAML-encoded Kubernetes resources, such as the
+// output of 'jsctl operator installations apply --stdout', in the same way as ValidateInstallation. Other resources
+// are ignored, and an error is returned if there are no Installations.
+func ValidateInstallationManifest(r io.Reader, version string) error {
+	objects, err := yaml.Load(r)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for _, object := range objects {
+		if object.GroupVersionKind().GroupKind() != operatorv1alpha1.InstallationGVK.GroupKind() {
+			continue
+		}
+		found = true
+
+		var installation operatorv1alpha1.Installation
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &installation); err != nil {
+			return fmt.Errorf("error decoding Installation %s: %w", object.GetName(), err)
+		}
+
+		// convert the numbers decoded from YAML to the float64 values decoded from JSON
+		converted, err := jsonObject(object.Object)
+		if err != nil {
+			return fmt.Errorf("error converting Installation %s: %w", object.GetName(), err)
+		}
+
+		if err := validateInstallation(converted, &installation, version); err != nil {
+			return fmt.Errorf("Installation %s is invalid: %w", object.GetName(), err)
+		}
+	}
+	if !found {
+		return errors.New("no Installation resources found")
+	}
+
+	return nil
+}
+
+func validateInstallation(object map[string]interface{}, installation *operatorv1alpha1.Installation, version string) error {
+	crds, err := manifestCRDs(version)
+	if err != nil {
+		return err
+	}
+
+	var crd *apiextensionsv1.CustomResourceDefinition
+	for _, c := range crds {
+		if c.Name == installationCRDName {
+			crd = c
+			break
+		}
+	}
+	if crd == nil {
+		return fmt.Errorf("no %s CRD found in the operator installer", installationCRDName)
+	}
+
+	errs := validateInstallationSchema(object, crd)
+	errs = append(errs, validateInstallationRules(installation)...)
+
+	return errs.ToAggregate()
+}
+
+// validateInstallationSchema checks the decoded JSON form of an Installation against the schema of the matching
+// version of the Installation CRD
+func validateInstallationSchema(object map[string]interface{}, crd *apiextensionsv1.CustomResourceDefinition) field.ErrorList {
+	apiVersion, _ := object["apiVersion"].(string)
+	if apiVersion == "" {
+		apiVersion = operatorv1alpha1.SchemeGroupVersion.String()
+	}
+
+	var crdVersion *apiextensionsv1.CustomResourceDefinitionVersion
+	for i, v := range crd.Spec.Versions {
+		if crd.Spec.Group+"/"+v.Name == apiVersion {
+			crdVersion = &crd.Spec.Versions[i]
+			break
+		}
+	}
+	if crdVersion == nil || crdVersion.Schema == nil {
+		return field.ErrorList{field.NotSupported(field.NewPath("apiVersion"), apiVersion, nil)}
+	}
+
+	// status is not set by jsctl, and metadata is validated by the API server rather than the CRD schema
+	validated := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		if key != "status" && key != "metadata" {
+			validated[key] = value
+		}
+	}
+
+	return validateSchema(nil, validated, crdVersion.Schema.OpenAPIV3Schema)
+}
+
+func jsonObject(in interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// validateSchema checks a decoded JSON value against a structural CRD schema. It covers the parts of OpenAPI v3 used by
+// the operator's CRDs: types, declared properties, required fields, enums, and numeric, string and array bounds.
+func validateSchema(path *field.Path, value interface{}, schema *apiextensionsv1.JSONSchemaProps) field.ErrorList {
+	if schema == nil || value == nil {
+		return nil
+	}
+	if schema.XIntOrString {
+		switch value.(type) {
+		case string, float64:
+			return nil
+		}
+		return field.ErrorList{field.Invalid(path, value, "must be an integer or a string")}
+	}
+
+	var errs field.ErrorList
+	if len(schema.Enum) > 0 {
+		allowed := make([]string, 0, len(schema.Enum))
+		var found bool
+		for _, e := range schema.Enum {
+			var enumValue interface{}
+			if err := json.Unmarshal(e.Raw, &enumValue); err == nil && enumValue == value {
+				found = true
+			}
+			allowed = append(allowed, string(e.Raw))
+		}
+		if !found {
+			errs = append(errs, field.NotSupported(path, value, allowed))
+		}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, field.Invalid(path, value, "must be of type object"))
+		}
+		errs = append(errs, validateObject(path, object, schema)...)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(errs, field.Invalid(path, value, "must be of type array"))
+		}
+		if schema.MinItems != nil && int64(len(items)) < *schema.MinItems {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must have at least %d items", *schema.MinItems)))
+		}
+		if schema.MaxItems != nil && int64(len(items)) > *schema.MaxItems {
+			errs = append(errs, field.TooMany(path, len(items), int(*schema.MaxItems)))
+		}
+		if schema.Items != nil && schema.Items.Schema != nil {
+			for i, item := range items {
+				errs = append(errs, validateSchema(path.Index(i), item, schema.Items.Schema)...)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(errs, field.Invalid(path, value, "must be of type string"))
+		}
+		if schema.MinLength != nil && int64(len(str)) < *schema.MinLength {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must be at least %d characters long", *schema.MinLength)))
+		}
+		if schema.MaxLength != nil && int64(len(str)) > *schema.MaxLength {
+			errs = append(errs, field.TooLong(path, value, int(*schema.MaxLength)))
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && number != math.Trunc(number)) {
+			return append(errs, field.Invalid(path, value, "must be of type "+schema.Type))
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must be greater than or equal to %v", *schema.Minimum)))
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			errs = append(errs, field.Invalid(path, value, fmt.Sprintf("must be less than or equal to %v", *schema.Maximum)))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(errs, field.Invalid(path, value, "must be of type boolean"))
+		}
+	}
+
+	return errs
+}
+
+func validateObject(path *field.Path, object map[string]interface{}, schema *apiextensionsv1.JSONSchemaProps) field.ErrorList {
+	var errs field.ErrorList
+
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, field.Required(path.Child(name), ""))
+		}
+	}
+
+	// iterate in a stable order so that errors are reported consistently
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := object[name]
+		if property, ok := schema.Properties[name]; ok {
+			errs = append(errs, validateSchema(path.Child(name), value, &property)...)
+			continue
+		}
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			errs = append(errs, validateSchema(path.Key(name), value, schema.AdditionalProperties.Schema)...)
+			continue
+		}
+		if len(schema.Properties) > 0 && !preservesUnknownFields(schema) {
+			errs = append(errs, field.Forbidden(path.Child(name), "field is not declared in the schema"))
+		}
+	}
+
+	return errs
+}
+
+func preservesUnknownFields(schema *apiextensionsv1.JSONSchemaProps) bool {
+	return schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields ||
+		schema.AdditionalProperties != nil && schema.AdditionalProperties.Allows
+}
+
+// validateInstallationRules checks the rules enforced by the operator's validating webhook and controllers that are
+// not part of the CRD schema.
+func validateInstallationRules(installation *operatorv1alpha1.Installation) field.ErrorList {
+	var errs field.ErrorList
+	spec := installation.Spec
+	specPath := field.NewPath("spec")
+
+	if spec.CertManager == nil {
+		errs = append(errs, field.Required(specPath.Child("certManager"), "cert-manager must be configured"))
+	}
+
+	if spec.ApproverPolicy != nil && spec.ApproverPolicyEnterprise != nil {
+		errs = append(errs, field.Forbidden(specPath.Child("approverPolicyEnterprise"), "approverPolicy and approverPolicyEnterprise cannot both be set"))
+	}
+
+	if spec.CSIDrivers != nil && spec.CSIDrivers.CertManager == nil && spec.CSIDrivers.CertManagerSpiffe == nil {
+		errs = append(errs, field.Invalid(specPath.Child("csiDrivers"), "{}", "at least one CSI driver must be enabled when csiDrivers is set"))
+	}
+
+	type issuerKey struct {
+		name, namespace string
+		clusterScope    bool
+	}
+	seen := make(map[issuerKey]bool)
+	for i, issuer := range spec.Issuers {
+		issuerPath := specPath.Child("issuers").Index(i)
+
+		if issuer.Name == "" {
+			errs = append(errs, field.Required(issuerPath.Child("name"), ""))
+		}
+		if !issuer.ClusterScope && issuer.Namespace == "" {
+			errs = append(errs, field.Required(issuerPath.Child("namespace"), "namespaced issuers must specify a namespace"))
+		}
+
+		key := issuerKey{name: issuer.Name, namespace: issuer.Namespace, clusterScope: issuer.ClusterScope}
+		if seen[key] {
+			errs = append(errs, field.Duplicate(issuerPath, issuer.Name))
+		}
+		seen[key] = true
+
+		if n := issuerTypeCount(issuer); n != 1 {
+			errs = append(errs, field.Invalid(issuerPath, issuer.Name, fmt.Sprintf("exactly one issuer type must be configured, found %d", n)))
+		}
+
+		if issuer.VenafiEnhancedIssuer != nil {
+			errs = append(errs, validateVenafiEnhancedIssuer(issuerPath.Child("venafiEnhancedIssuer"), issuer)...)
+		}
+	}
+
+	return errs
+}
+
+// validateVenafiEnhancedIssuer checks the x-kubernetes-validations rules of the venafiEnhancedIssuer field in the
+// Installation CRD: exactly one of tpp or vaas must be set, and each step retrieving credentials must have exactly one
+// secret source set. The issuer is checked in its JSON form so that every secret source is counted, including those
+// added to the CRD after jsctl was built.
+func validateVenafiEnhancedIssuer(path *field.Path, issuer *operatorv1alpha1.Issuer) field.ErrorList {
+	source, err := jsonObject(issuer.VenafiEnhancedIssuer)
+	if err != nil {
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+
+	var errs field.ErrorList
+	tpp, hasTPP := source["tpp"].(map[string]interface{})
+	vaas, hasVaas := source["vaas"].(map[string]interface{})
+	if hasTPP == hasVaas {
+		errs = append(errs, field.Invalid(path, issuer.Name, "must have exactly ONE of the following fields set: tpp or vaas"))
+	}
+
+	errs = append(errs, validateSecretSources(path.Child("tpp", "accessToken"), tpp["accessToken"])...)
+	errs = append(errs, validateSecretSources(path.Child("vaas", "apiKey"), vaas["apiKey"])...)
+
+	return errs
+}
+
+// validateSecretSources checks that each step in a venafi-enhanced-issuer list of secret sources has exactly one
+// source set
+func validateSecretSources(path *field.Path, value interface{}) field.ErrorList {
+	steps, _ := value.([]interface{})
+
+	var errs field.ErrorList
+	for i, step := range steps {
+		sources, _ := step.(map[string]interface{})
+		if len(sources) != 1 {
+			errs = append(errs, field.Invalid(path.Index(i), len(sources), "must have exactly one field set"))
+		}
+	}
+	return errs
+}
+
+func issuerTypeCount(issuer *operatorv1alpha1.Issuer) int {
+	var count int
+	for _, set := range []bool{
+		issuer.ACME != nil,
+		issuer.CA != nil,
+		issuer.Vault != nil,
+		issuer.SelfSigned != nil,
+		issuer.Venafi != nil,
+		issuer.VenafiEnhancedIssuer != nil,
+	} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// SupportedCertManagerVersions returns the cert-manager versions that the Installation CRD in the embedded installer
+// for the given operator version, or the latest installer if version is empty, lists as supported. It returns nil if
+// the CRD does not list them.
+func SupportedCertManagerVersions(version string) ([]string, error) {
+	spec, err := installationSpecSchema(version)
+	if err != nil {
+		return nil, err
+	}
+
+	certManager := spec.Properties["certManager"]
+	return supportedVersions(certManager.Properties["version"].Description), nil
+}
+
+// installationSpecSchema returns the schema of the spec of the first version of the Installation CRD in the embedded
+// installer for the given operator version, or the latest installer if version is empty
+func installationSpecSchema(version string) (*apiextensionsv1.JSONSchemaProps, error) {
+	crds, err := manifestCRDs(version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, crd := range crds {
+		if crd.Name != installationCRDName {
+			continue
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+				continue
+			}
+			spec := v.Schema.OpenAPIV3Schema.Properties["spec"]
+			return &spec, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no %s CRD found in the operator installer", installationCRDName)
+}
+
+// defaultVersionPattern matches the default version in the description of a version field, which is given as
+// "Default: v1.10.1", "Default version: v0.4.0." or "Defaults to v0.2.0"
+var defaultVersionPattern = regexp.MustCompile(`Default(?:s to| version:|:)\s+(v\S+)`)
+
+// defaultVersion parses the default version in the description of a version field, it returns an empty string if
+// there is none
+func defaultVersion(description string) string {
+	match := defaultVersionPattern.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+
+	version := strings.TrimRight(match[1], ".")
+	if _, err := semver.NewVersion(version); err != nil {
+		return ""
+	}
+	return version
+}
+
+// supportedVersions parses the versions listed after "Supported Versions:" in the description of a version field
+func supportedVersions(description string) []string {
+	const marker = "Supported Versions:"
+	i := strings.Index(description, marker)
+	if i < 0 {
+		return nil
+	}
+
+	var versions []string
+	for _, v := range strings.Split(description[i+len(marker):], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
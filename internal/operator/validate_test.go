@@ -0,0 +1,284 @@
+package operator
+
+import (
+	"strings"
+	"testing"
+
+	operatorv1alpha1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	veiv1alpha1 "github.com/jetstack/venafi-enhanced-issuer/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestValidateInstallation(t *testing.T) {
+	t.Parallel()
+
+	generate := func(t *testing.T, options ApplyInstallationYAMLOptions) *operatorv1alpha1.Installation {
+		installation, err := GenerateInstallation(options)
+		require.NoError(t, err)
+		return installation
+	}
+
+	t.Run("It should accept a generated Installation", func(t *testing.T) {
+		installation := generate(t, ApplyInstallationYAMLOptions{
+			CertManagerReplicas:    2,
+			InstallCSIDriver:       true,
+			InstallSpiffeCSIDriver: true,
+			InstallIstioCSR:        true,
+			IstioCSRIssuer:         "istio-ca",
+		})
+		assert.NoError(t, ValidateInstallation(installation, ""))
+	})
+
+	t.Run("It should reject values outside the CRD schema", func(t *testing.T) {
+		installation := generate(t, ApplyInstallationYAMLOptions{})
+		installation.Spec.CertManager.Controller.ReplicaCount = pointer.Int(0)
+
+		err := ValidateInstallation(installation, "")
+		assert.ErrorContains(t, err, "spec.certManager.controller.replicas")
+	})
+
+	t.Run("It should reject an empty CSI drivers block", func(t *testing.T) {
+		installation := generate(t, ApplyInstallationYAMLOptions{})
+		installation.Spec.CSIDrivers = &operatorv1alpha1.CSIDrivers{}
+
+		err := ValidateInstallation(installation, "")
+		assert.ErrorContains(t, err, "at least one CSI driver must be enabled")
+	})
+
+	t.Run("It should reject both approver-policy and approver-policy-enterprise", func(t *testing.T) {
+		installation := generate(t, ApplyInstallationYAMLOptions{})
+		installation.Spec.ApproverPolicyEnterprise = &operatorv1alpha1.ApproverPolicyEnterprise{}
+
+		err := ValidateInstallation(installation, "")
+		assert.ErrorContains(t, err, "approverPolicy and approverPolicyEnterprise cannot both be set")
+	})
+
+	t.Run("It should reject duplicate and incomplete issuers", func(t *testing.T) {
+		installation := generate(t, ApplyInstallationYAMLOptions{})
+		installation.Spec.Issuers = []*operatorv1alpha1.Issuer{
+			{Name: "ca", Namespace: "foo", CA: &operatorv1alpha1.CAIssuer{SecretName: "ca"}},
+			{Name: "ca", Namespace: "foo", CA: &operatorv1alpha1.CAIssuer{SecretName: "ca"}},
+			{Name: "none", Namespace: "foo"},
+			{Name: "no-namespace", CA: &operatorv1alpha1.CAIssuer{SecretName: "ca"}},
+		}
+
+		err := ValidateInstallation(installation, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.issuers[1]: Duplicate value")
+		assert.Contains(t, err.Error(), "exactly one issuer type must be configured, found 0")
+		assert.Contains(t, err.Error(), "spec.issuers[3].namespace: Required value")
+	})
+
+	t.Run("It should reject venafi-enhanced-issuer issuers without exactly one of tpp or vaas", func(t *testing.T) {
+		installation := generate(t, ApplyInstallationYAMLOptions{})
+		installation.Spec.Issuers = []*operatorv1alpha1.Issuer{
+			{
+				Name:                 "none",
+				ClusterScope:         true,
+				VenafiEnhancedIssuer: &veiv1alpha1.VenafiCertificateSource{},
+			},
+			{
+				Name:         "both",
+				ClusterScope: true,
+				VenafiEnhancedIssuer: &veiv1alpha1.VenafiCertificateSource{
+					Tpp:  &veiv1alpha1.TppCertificateIssuer{PolicyDn: `\VED\Policy\Example`},
+					Vaas: &veiv1alpha1.VaasCertificateIssuer{Application: "example", Template: "example"},
+				},
+			},
+		}
+
+		err := ValidateInstallation(installation, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.issuers[0].venafiEnhancedIssuer: Invalid value: "none": must have exactly ONE of the following fields set: tpp or vaas`)
+		assert.Contains(t, err.Error(), `spec.issuers[1].venafiEnhancedIssuer: Invalid value: "both": must have exactly ONE of the following fields set: tpp or vaas`)
+	})
+}
+
+func TestValidateInstallationManifest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("It should ignore other resources in the manifest", func(t *testing.T) {
+		manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: jse-gcr-creds
+  namespace: jetstack-secure
+---
+apiVersion: operator.jetstack.io/v1alpha1
+kind: Installation
+metadata:
+  name: jetstack-secure
+spec:
+  approverPolicy: {}
+  certManager:
+    controller:
+      replicas: 2
+`
+		assert.NoError(t, ValidateInstallationManifest(strings.NewReader(manifest), ""))
+	})
+
+	t.Run("It should reject fields that are not in the schema", func(t *testing.T) {
+		manifest := `apiVersion: operator.jetstack.io/v1alpha1
+kind: Installation
+metadata:
+  name: jetstack-secure
+spec:
+  certManager:
+    replicas: 2
+`
+		err := ValidateInstallationManifest(strings.NewReader(manifest), "")
+		assert.ErrorContains(t, err, "spec.certManager.replicas: Forbidden")
+	})
+
+	t.Run("It should reject credential steps without exactly one secret source", func(t *testing.T) {
+		manifest := `apiVersion: operator.jetstack.io/v1alpha1
+kind: Installation
+metadata:
+  name: jetstack-secure
+spec:
+  certManager: {}
+  issuers:
+  - name: tpp
+    clusterScope: true
+    venafiEnhancedIssuer:
+      tpp:
+        policyDN: \VED\Policy\Example
+        accessToken:
+        - secret:
+            name: tpp-credentials
+            fields: ["username", "password"]
+          serviceAccountToken:
+            name: venafi
+            audiences: ["tpp"]
+        - tppOAuth:
+            authInputType: UsernamePassword
+  - name: vaas
+    clusterScope: true
+    venafiEnhancedIssuer:
+      vaas:
+        application: example
+        template: example
+        apiKey:
+        - {}
+`
+		err := ValidateInstallationManifest(strings.NewReader(manifest), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.issuers[0].venafiEnhancedIssuer.tpp.accessToken[0]: Invalid value: 2: must have exactly one field set")
+		assert.NotContains(t, err.Error(), "accessToken[1]")
+		assert.Contains(t, err.Error(), "spec.issuers[1].venafiEnhancedIssuer.vaas.apiKey[0]: Invalid value: 0: must have exactly one field set")
+	})
+
+	t.Run("It should return an error when there is no Installation", func(t *testing.T) {
+		manifest := `apiVersion: v1
+kind: Secret
+metadata:
+  name: jse-gcr-creds
+`
+		err := ValidateInstallationManifest(strings.NewReader(manifest), "")
+		assert.ErrorContains(t, err, "no Installation resources found")
+	})
+}
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"name"},
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"name":     {Type: "string", MaxLength: pointer.Int64(5)},
+			"replicas": {Type: "integer", Minimum: pointer.Float64(1)},
+			"mode":     {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"a"`)}, {Raw: []byte(`"b"`)}}},
+			"port":     {XIntOrString: true},
+			"labels": {
+				Type:                 "object",
+				AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+			},
+			"items": {
+				Type:  "array",
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "boolean"}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		value   map[string]interface{}
+		wantErr []string
+	}{
+		"valid object should pass": {
+			value: map[string]interface{}{
+				"name":     "foo",
+				"replicas": float64(2),
+				"mode":     "a",
+				"port":     "https",
+				"labels":   map[string]interface{}{"foo": "bar"},
+				"items":    []interface{}{true},
+			},
+		},
+		"missing required field should fail": {
+			value:   map[string]interface{}{},
+			wantErr: []string{"name: Required value"},
+		},
+		"invalid values should fail": {
+			value: map[string]interface{}{
+				"name":     "toolong",
+				"replicas": float64(1.5),
+				"mode":     "c",
+				"labels":   map[string]interface{}{"foo": float64(1)},
+				"items":    []interface{}{"true"},
+				"unknown":  "foo",
+			},
+			wantErr: []string{
+				"name: Too long",
+				"replicas: Invalid value: 1.5: must be of type integer",
+				"mode: Unsupported value",
+				"labels[foo]: Invalid value: 1: must be of type string",
+				"items[0]: Invalid value: \"true\": must be of type boolean",
+				"unknown: Forbidden",
+			},
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := validateSchema(nil, scenario.value, schema)
+			if len(scenario.wantErr) == 0 {
+				assert.Empty(t, errs)
+				return
+			}
+
+			require.Len(t, errs, len(scenario.wantErr))
+			for _, want := range scenario.wantErr {
+				assert.Contains(t, errs.ToAggregate().Error(), want)
+			}
+		})
+	}
+}
+
+func TestSupportedCertManagerVersions(t *testing.T) {
+	t.Parallel()
+
+	versions, err := SupportedCertManagerVersions("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.10.1", "v1.10.0", "v1.9.1"}, versions)
+
+	assert.Nil(t, supportedVersions("Version of cert-manager to install"))
+}
+
+func TestDefaultVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"Version of cert-manager. Default: v1.10.1 Supported Versions: v1.10.1, v1.10.0":  "v1.10.1",
+		"Version of approver-policy. Default version: v0.4.0. Supported Versions: v0.4.0": "v0.4.0",
+		"Version of approver-policy Default: v0.4.0-0 Supported Versions: v0.4.0-0":       "v0.4.0-0",
+		"Version of cert-discovery-venafi to install Defaults to v0.2.0":                  "v0.2.0",
+		"Version of cert-manager to install":                                              "",
+		"Version of cert-manager. Default: latest":                                        "",
+	}
+	for description, want := range tests {
+		assert.Equal(t, want, defaultVersion(description), description)
+	}
+}
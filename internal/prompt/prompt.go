@@ -9,7 +9,7 @@ import (
 )
 
 // YesNo writes a formatted message to output and waits for user input with either a y or n character. Any input other
-// than a y will return false.
+// than y or yes, in any case, will return false.
 func YesNo(input io.Reader, output io.Writer, format string, args ...interface{}) (bool, error) {
 	message := fmt.Sprintf(format, args...) + " (y/N): "
 
@@ -19,11 +19,12 @@ func YesNo(input io.Reader, output io.Writer, format string, args ...interface{}
 		return false, fmt.Errorf("failed to read input: %w", err)
 	}
 
-	if strings.TrimSpace(strings.ToLower(response)) != "y" {
+	switch strings.TrimSpace(strings.ToLower(response)) {
+	case "y", "yes":
+		return true, nil
+	default:
 		return false, nil
 	}
-
-	return true, nil
 }
 
 type (
@@ -20,6 +20,15 @@ func TestYesNo(t *testing.T) {
 		assert.True(t, ok)
 	})
 
+	t.Run("It should return true on a full yes in any case", func(t *testing.T) {
+		input := bytes.NewBufferString("Yes\n")
+		output := bytes.NewBuffer([]byte{})
+
+		ok, err := prompt.YesNo(input, output, "you sure about that %s?", "bob")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
 	t.Run("It should false true on no", func(t *testing.T) {
 		input := bytes.NewBufferString("n\n")
 		output := bytes.NewBuffer([]byte{})
@@ -0,0 +1,221 @@
+package venafi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// tppClientID is the client ID used to request access tokens from TPP. It is the client ID used by cert-manager,
+	// so a successful test also shows that the API integration used by cert-manager's issuers is configured.
+	tppClientID = "cert-manager.io"
+	tppScope    = "certificate:manage"
+
+	tppPolicyRoot = `\VED\Policy\`
+)
+
+var (
+	// ErrAuthenticationFailed is the error given when TPP rejects the credentials of a connection.
+	ErrAuthenticationFailed = errors.New("authentication failed")
+
+	// ErrZoneNotFound is the error given when the zone of a connection does not exist in TPP.
+	ErrZoneNotFound = errors.New("zone not found")
+)
+
+// LoadConnections reads Venafi connections keyed by name from the YAML file at path.
+func LoadConnections(path string) (map[string]*VenafiConnection, error) {
+	vcs := make(map[string]*VenafiConnection)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %w", err)
+	}
+	defer file.Close()
+
+	// unknown fields are rejected so that misspelt settings are not silently ignored
+	decoder := yaml.NewDecoder(file)
+	decoder.SetStrict(true)
+	if err = decoder.Decode(&vcs); err != nil {
+		return nil, fmt.Errorf("error decoding connection configuration: %w", err)
+	}
+	return vcs, nil
+}
+
+// IsVaas returns true if the connection is to Venafi as a Service rather than TPP.
+func (vc *VenafiConnection) IsVaas() bool {
+	return vc.APIKey != ""
+}
+
+// Validate checks that the connection has a zone, a valid URL and a usable combination of credentials. TPP
+// connections need a URL and either an access token or a username and password, while Venafi as a Service
+// connections need an API key and may omit the URL.
+func (vc *VenafiConnection) Validate() error {
+	var errs []string
+
+	if vc.Zone == "" {
+		errs = append(errs, "zone must be specified")
+	}
+
+	switch {
+	case vc.URL != "":
+		u, err := url.Parse(vc.URL)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("invalid url: %s", err))
+		case u.Scheme != "https" && u.Scheme != "http":
+			errs = append(errs, fmt.Sprintf("invalid url %q: scheme must be http or https", vc.URL))
+		case u.Host == "":
+			errs = append(errs, fmt.Sprintf("invalid url %q: no host", vc.URL))
+		}
+	case !vc.IsVaas():
+		errs = append(errs, "url must be specified")
+	}
+
+	hasTPPCreds := vc.AccessToken != "" || vc.Username != "" || vc.Password != ""
+	switch {
+	case vc.IsVaas() && hasTPPCreds:
+		errs = append(errs, "an api-key cannot be used with an access-token, username or password")
+	case vc.IsVaas():
+	case vc.AccessToken == "" && (vc.Username == "" || vc.Password == ""):
+		errs = append(errs, fmt.Sprintf(errMsgMissingConnectionCreds, redact(vc.AccessToken), vc.Username, redact(vc.Password)))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// CheckConnection authenticates with the TPP server of the connection and checks that its zone exists. Connections
+// using an access token have the token verified, while those using a username and password request a new token. The
+// connection should be validated with Validate first. Venafi as a Service connections are not supported.
+func CheckConnection(ctx context.Context, client *http.Client, vc *VenafiConnection) error {
+	if vc.IsVaas() {
+		return errors.New("connectivity checks are only supported for TPP connections")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	baseURL := tppBaseURL(vc.URL)
+
+	token := vc.AccessToken
+	if token != "" {
+		if err := tppRequest(ctx, client, http.MethodGet, baseURL+"/vedauth/authorize/verify", token, nil, nil); err != nil {
+			return err
+		}
+	} else {
+		request := map[string]string{
+			"client_id": tppClientID,
+			"username":  vc.Username,
+			"password":  vc.Password,
+			"scope":     tppScope,
+		}
+		var response struct {
+			AccessToken string `json:"access_token"`
+		}
+		if err := tppRequest(ctx, client, http.MethodPost, baseURL+"/vedauth/authorize/oauth", "", request, &response); err != nil {
+			return err
+		}
+		if response.AccessToken == "" {
+			return fmt.Errorf("%w: no access token returned", ErrAuthenticationFailed)
+		}
+		token = response.AccessToken
+	}
+
+	var response struct {
+		Result int `json:"Result"`
+	}
+	request := map[string]string{"ObjectDN": zoneDN(vc.Zone)}
+	if err := tppRequest(ctx, client, http.MethodPost, baseURL+"/vedsdk/config/isvalid", token, request, &response); err != nil {
+		return err
+	}
+	// TPP reports a missing object with a result code rather than an HTTP status
+	if response.Result != 1 {
+		return fmt.Errorf("%w: %s", ErrZoneNotFound, zoneDN(vc.Zone))
+	}
+
+	return nil
+}
+
+// tppRequest sends a JSON request to the TPP API, decoding the response into out if it is not nil
+func tppRequest(ctx context.Context, client *http.Client, method, endpoint, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusBadRequest && strings.Contains(endpoint, "/vedauth/"):
+		return fmt.Errorf("%w: unexpected status code %d from %s", ErrAuthenticationFailed, resp.StatusCode, endpoint)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, endpoint)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// tppBaseURL returns the root of a TPP server from the vedsdk URL used by cert-manager, tokens are managed by the
+// vedauth API alongside it
+func tppBaseURL(url string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), "/vedsdk")
+}
+
+// zoneDN returns the distinguished name of the policy folder for a zone, which may be relative to the policy root
+func zoneDN(zone string) string {
+	if strings.HasPrefix(strings.ToUpper(zone), `\VED\`) {
+		return zone
+	}
+	return tppPolicyRoot + strings.TrimPrefix(zone, `\`)
+}
+
+// Redacted returns a copy of the connection with its inline credentials hidden, so that it can be shown to the user.
+// Credential sources, such as AccessTokenFrom, are kept as they do not contain the credentials themselves.
+func (vc VenafiConnection) Redacted() *VenafiConnection {
+	vc.AccessToken = redact(vc.AccessToken)
+	vc.Password = redact(vc.Password)
+	vc.APIKey = redact(vc.APIKey)
+	return &vc
+}
+
+// redact hides a secret value while showing whether it was set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "<redacted>"
+}
@@ -0,0 +1,162 @@
+package venafi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVenafiConnection_Validate(t *testing.T) {
+	tests := map[string]struct {
+		vc          *VenafiConnection
+		expectedErr string
+	}{
+		"tpp connection with username and password is valid": {
+			vc: &VenafiConnection{URL: "https://tpp.example.com/vedsdk", Zone: "foo", Username: "foo", Password: "foo"},
+		},
+		"tpp connection with access token is valid": {
+			vc: &VenafiConnection{URL: "https://tpp.example.com/vedsdk", Zone: "foo", AccessToken: "foo"},
+		},
+		"vaas connection without url is valid": {
+			vc: &VenafiConnection{Zone: `app\template`, APIKey: "foo"},
+		},
+		"tpp connection without password is invalid": {
+			vc:          &VenafiConnection{URL: "https://tpp.example.com/vedsdk", Zone: "foo", Username: "foo"},
+			expectedErr: "missing credentials: expected either Venafi access token or username and password: got access-token , username: foo, password: ",
+		},
+		"secrets are not included in errors": {
+			vc:          &VenafiConnection{URL: "https://tpp.example.com/vedsdk", Zone: "foo", Password: "secret"},
+			expectedErr: "missing credentials: expected either Venafi access token or username and password: got access-token , username: , password: <redacted>",
+		},
+		"connection without zone or url is invalid": {
+			vc:          &VenafiConnection{AccessToken: "foo"},
+			expectedErr: "zone must be specified, url must be specified",
+		},
+		"url without a scheme is invalid": {
+			vc:          &VenafiConnection{URL: "tpp.example.com", Zone: "foo", AccessToken: "foo"},
+			expectedErr: `invalid url "tpp.example.com": scheme must be http or https`,
+		},
+		"api key with tpp credentials is invalid": {
+			vc:          &VenafiConnection{Zone: "foo", APIKey: "foo", AccessToken: "foo"},
+			expectedErr: "an api-key cannot be used with an access-token, username or password",
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := scenario.vc.Validate()
+			if scenario.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, scenario.expectedErr)
+			}
+		})
+	}
+}
+
+// fakeTPP serves the parts of the TPP API used by CheckConnection, accepting
+// the credentials foo:bar, the access token footoken and the zone \VED\Policy\foo
+func fakeTPP(t *testing.T) string {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vedauth/authorize/oauth", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req["username"] != "foo" || req["password"] != "bar" || req["client_id"] != tppClientID {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "footoken"})
+	})
+	mux.HandleFunc("/vedauth/authorize/verify", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer footoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"application":     tppClientID,
+			"expires_ISO8601": "2030-01-02T03:04:05Z",
+			"scope":           tppScope,
+		})
+	})
+	mux.HandleFunc("/vedsdk/config/isvalid", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer footoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		result := 400
+		if req["ObjectDN"] == `\VED\Policy\foo` {
+			result = 1
+		}
+		json.NewEncoder(w).Encode(map[string]int{"Result": result})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL + "/vedsdk"
+}
+
+func TestCheckConnection(t *testing.T) {
+	ctx := context.Background()
+	url := fakeTPP(t)
+
+	tests := map[string]struct {
+		vc          *VenafiConnection
+		expectedErr error
+	}{
+		"username and password should authenticate": {
+			vc: &VenafiConnection{URL: url, Zone: "foo", Username: "foo", Password: "bar"},
+		},
+		"access token should be verified": {
+			vc: &VenafiConnection{URL: url, Zone: `\VED\Policy\foo`, AccessToken: "footoken"},
+		},
+		"wrong password should fail": {
+			vc:          &VenafiConnection{URL: url, Zone: "foo", Username: "foo", Password: "baz"},
+			expectedErr: ErrAuthenticationFailed,
+		},
+		"wrong access token should fail": {
+			vc:          &VenafiConnection{URL: url, Zone: "foo", AccessToken: "bartoken"},
+			expectedErr: ErrAuthenticationFailed,
+		},
+		"missing zone should fail": {
+			vc:          &VenafiConnection{URL: url, Zone: "bar", AccessToken: "footoken"},
+			expectedErr: ErrZoneNotFound,
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckConnection(ctx, nil, scenario.vc)
+			if scenario.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, scenario.expectedErr)
+			}
+		})
+	}
+}
+
+func TestLoadConnections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("foo:\n  url: https://tpp.example.com/vedsdk\n  zone: foo\n  access-token: footoken\n"), 0600))
+
+	vcs, err := LoadConnections(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*VenafiConnection{
+		"foo": {URL: "https://tpp.example.com/vedsdk", Zone: "foo", AccessToken: "footoken"},
+	}, vcs)
+}
+
+func TestLoadConnections_unknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("foo:\n  url: https://tpp.example.com/vedsdk\n  zone: foo\n  acess-token: footoken\n"), 0600))
+
+	_, err := LoadConnections(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+package venafi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/jsctl/internal/kubernetes"
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+type (
+	// The CredentialSource type describes where a credential of a VenafiConnection is read from, so that it does not
+	// need to be stored in the connections file. Exactly one field must be set.
+	CredentialSource struct {
+		// Env is the name of an environment variable containing the credential
+		Env string `yaml:"env,omitempty"`
+		// File is the path of a file containing the credential
+		File string `yaml:"file,omitempty"`
+		// Secret is a key of an existing Kubernetes Secret containing the credential
+		Secret *SecretKeyReference `yaml:"secret,omitempty"`
+		// Exec is a credential plugin that writes the credential to stdout
+		Exec *ExecCredential `yaml:"exec,omitempty"`
+	}
+
+	// The SecretKeyReference type identifies a key of a Kubernetes Secret.
+	SecretKeyReference struct {
+		Namespace string `yaml:"namespace"`
+		Name      string `yaml:"name"`
+		Key       string `yaml:"key"`
+	}
+
+	// The ExecCredential type describes a command that writes a credential to stdout.
+	ExecCredential struct {
+		Command string   `yaml:"command"`
+		Args    []string `yaml:"args,omitempty"`
+	}
+
+	// A SecretGetter returns the data of a Kubernetes Secret.
+	SecretGetter func(ctx context.Context, namespace, name string) (map[string][]byte, error)
+)
+
+// ErrNoSecretGetter is the error given when a credential is read from a Kubernetes Secret by a command that does not
+// connect to a cluster.
+var ErrNoSecretGetter = errors.New("credentials cannot be read from Kubernetes Secrets by this command")
+
+// ResolveCredentials reads the credentials of each connection that are given as a CredentialSource, setting the
+// corresponding plain text field. Credentials read from Kubernetes Secrets use getSecret, which may be nil if no
+// connection uses them. Whitespace surrounding each credential is removed.
+func ResolveCredentials(ctx context.Context, vcs map[string]*VenafiConnection, getSecret SecretGetter) error {
+	for name, vc := range vcs {
+		if vc == nil {
+			continue
+		}
+
+		fields := []struct {
+			name   string
+			value  *string
+			source *CredentialSource
+		}{
+			{name: "access-token", value: &vc.AccessToken, source: vc.AccessTokenFrom},
+			{name: "username", value: &vc.Username, source: vc.UsernameFrom},
+			{name: "password", value: &vc.Password, source: vc.PasswordFrom},
+			{name: "api-key", value: &vc.APIKey, source: vc.APIKeyFrom},
+		}
+		for _, field := range fields {
+			if field.source == nil {
+				continue
+			}
+			if *field.value != "" {
+				return fmt.Errorf("Venafi connection %s: cannot specify both %s and %s-from", name, field.name, field.name)
+			}
+
+			value, err := field.source.resolve(ctx, getSecret)
+			if err != nil {
+				return fmt.Errorf("Venafi connection %s: failed to read %s: %w", name, field.name, err)
+			}
+			*field.value = value
+		}
+	}
+	return nil
+}
+
+// NewKubernetesSecretGetter returns a SecretGetter that reads Secrets from the cluster of the kubeconfig file. The
+// cluster is only contacted when a Secret is read.
+func NewKubernetesSecretGetter(kubeConfig string) SecretGetter {
+	return func(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+		config, err := kubernetes.NewConfig(kubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := clients.NewSecretClient(config)
+		if err != nil {
+			return nil, err
+		}
+
+		var secret corev1.Secret
+		if err := client.Get(ctx, &clients.GenericRequestOptions{Namespace: namespace, Name: name}, &secret); err != nil {
+			return nil, err
+		}
+		return secret.Data, nil
+	}
+}
+
+func (s *CredentialSource) resolve(ctx context.Context, getSecret SecretGetter) (string, error) {
+	var set int
+	for _, isSet := range []bool{s.Env != "", s.File != "", s.Secret != nil, s.Exec != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", errors.New("exactly one of env, file, secret or exec must be specified")
+	}
+
+	var value string
+	switch {
+	case s.Env != "":
+		var ok bool
+		value, ok = os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", s.Env)
+		}
+	case s.File != "":
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", err
+		}
+		value = string(data)
+	case s.Secret != nil:
+		if getSecret == nil {
+			return "", ErrNoSecretGetter
+		}
+		data, err := getSecret(ctx, s.Secret.Namespace, s.Secret.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to get Secret %s/%s: %w", s.Secret.Namespace, s.Secret.Name, err)
+		}
+		secretValue, ok := data[s.Secret.Key]
+		if !ok {
+			return "", fmt.Errorf("Secret %s/%s has no key %q", s.Secret.Namespace, s.Secret.Name, s.Secret.Key)
+		}
+		value = string(secretValue)
+	case s.Exec != nil:
+		var stdout bytes.Buffer
+		cmd := exec.CommandContext(ctx, s.Exec.Command, s.Exec.Args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("credential plugin %s failed: %w", s.Exec.Command, err)
+		}
+		value = stdout.String()
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("credential is empty")
+	}
+	return value, nil
+}
@@ -0,0 +1,105 @@
+package venafi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("filepassword\n"), 0600))
+	t.Setenv("JSCTL_TEST_USERNAME", "envuser")
+
+	getSecret := func(_ context.Context, namespace, name string) (map[string][]byte, error) {
+		if namespace != "venafi" || name != "tpp" {
+			return nil, errors.New("not found")
+		}
+		return map[string][]byte{"access-token": []byte("secrettoken")}, nil
+	}
+
+	tests := map[string]struct {
+		vc          *VenafiConnection
+		getSecret   SecretGetter
+		expected    *VenafiConnection
+		expectedErr string
+	}{
+		"read credentials from environment variables and files": {
+			vc: &VenafiConnection{
+				UsernameFrom: &CredentialSource{Env: "JSCTL_TEST_USERNAME"},
+				PasswordFrom: &CredentialSource{File: path},
+			},
+			expected: &VenafiConnection{Username: "envuser", Password: "filepassword"},
+		},
+		"read credentials from Kubernetes Secrets": {
+			vc: &VenafiConnection{
+				AccessTokenFrom: &CredentialSource{Secret: &SecretKeyReference{Namespace: "venafi", Name: "tpp", Key: "access-token"}},
+			},
+			getSecret: getSecret,
+			expected:  &VenafiConnection{AccessToken: "secrettoken"},
+		},
+		"read credentials from a credential plugin": {
+			vc: &VenafiConnection{
+				APIKeyFrom: &CredentialSource{Exec: &ExecCredential{Command: "echo", Args: []string{"pluginkey"}}},
+			},
+			expected: &VenafiConnection{APIKey: "pluginkey"},
+		},
+		"leave plain text credentials unchanged": {
+			vc:       &VenafiConnection{AccessToken: "token"},
+			expected: &VenafiConnection{AccessToken: "token"},
+		},
+		"error if a credential is given in plain text and from a source": {
+			vc: &VenafiConnection{
+				Password:     "password",
+				PasswordFrom: &CredentialSource{File: path},
+			},
+			expectedErr: "Venafi connection foo: cannot specify both password and password-from",
+		},
+		"error if a source sets more than one field": {
+			vc: &VenafiConnection{
+				PasswordFrom: &CredentialSource{File: path, Env: "JSCTL_TEST_USERNAME"},
+			},
+			expectedErr: "Venafi connection foo: failed to read password: exactly one of env, file, secret or exec must be specified",
+		},
+		"error if an environment variable is not set": {
+			vc: &VenafiConnection{
+				PasswordFrom: &CredentialSource{Env: "JSCTL_TEST_MISSING"},
+			},
+			expectedErr: "Venafi connection foo: failed to read password: environment variable JSCTL_TEST_MISSING is not set",
+		},
+		"error if a Secret key is missing": {
+			vc: &VenafiConnection{
+				PasswordFrom: &CredentialSource{Secret: &SecretKeyReference{Namespace: "venafi", Name: "tpp", Key: "password"}},
+			},
+			getSecret:   getSecret,
+			expectedErr: `Venafi connection foo: failed to read password: Secret venafi/tpp has no key "password"`,
+		},
+		"error if Secrets cannot be read": {
+			vc: &VenafiConnection{
+				PasswordFrom: &CredentialSource{Secret: &SecretKeyReference{Namespace: "venafi", Name: "tpp", Key: "password"}},
+			},
+			expectedErr: "Venafi connection foo: failed to read password: " + ErrNoSecretGetter.Error(),
+		},
+	}
+	for name, scenario := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ResolveCredentials(ctx, map[string]*VenafiConnection{"foo": scenario.vc}, scenario.getSecret)
+			if scenario.expectedErr != "" {
+				assert.EqualError(t, err, scenario.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, scenario.expected.AccessToken, scenario.vc.AccessToken)
+			assert.Equal(t, scenario.expected.Username, scenario.vc.Username)
+			assert.Equal(t, scenario.expected.Password, scenario.vc.Password)
+			assert.Equal(t, scenario.expected.APIKey, scenario.vc.APIKey)
+		})
+	}
+}
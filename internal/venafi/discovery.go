@@ -0,0 +1,87 @@
+package venafi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	alpha1operatorv1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+)
+
+const (
+	// cdvTokenSecretName is the Secret holding the access token of cert-discovery-venafi when the Installation does
+	// not reference one
+	cdvTokenSecretName = "access-token"
+
+	// discoveryPageSize is the number of certificates requested from TPP at a time
+	discoveryPageSize = 1000
+)
+
+// The DiscoveryUpload type describes the certificates cert-discovery-venafi has uploaded to its TPP policy folder.
+type DiscoveryUpload struct {
+	// Certificates is the number of certificates in the policy folder and its subfolders
+	Certificates int
+	// Last is the time the most recently uploaded certificate was created in TPP, it is zero if there are none
+	Last time.Time
+	// LastName is the name of the most recently uploaded certificate
+	LastName string
+}
+
+// DiscoveryTokenSecret returns the namespace, name and key of the Secret that cert-discovery-venafi reads its access
+// token from.
+func DiscoveryTokenSecret(cdv *alpha1operatorv1.CertDiscoveryVenafi) (string, string, string) {
+	if cdv.TPP != nil && cdv.TPP.TokenSecretRef != nil {
+		return clusterNamespace, cdv.TPP.TokenSecretRef.Name, cdv.TPP.TokenSecretRef.Key
+	}
+	return clusterNamespace, cdvTokenSecretName, accessTokenKey
+}
+
+// LastDiscoveryUpload searches the TPP policy folder for the zone, and its subfolders, for the certificates uploaded
+// by cert-discovery-venafi. TPP records when a certificate is first created, so uploads that only update existing
+// certificates are not reflected in DiscoveryUpload.Last.
+func LastDiscoveryUpload(ctx context.Context, client *http.Client, tppURL, zone, token string) (*DiscoveryUpload, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var upload DiscoveryUpload
+	for offset := 0; ; offset += discoveryPageSize {
+		query := url.Values{}
+		query.Set("ParentDnRecursive", zoneDN(zone))
+		query.Set("limit", strconv.Itoa(discoveryPageSize))
+		query.Set("offset", strconv.Itoa(offset))
+
+		var response struct {
+			Certificates []struct {
+				Name      string `json:"Name"`
+				CreatedOn string `json:"CreatedOn"`
+			} `json:"Certificates"`
+			TotalCount int `json:"TotalCount"`
+		}
+		endpoint := tppBaseURL(tppURL) + "/vedsdk/certificates/?" + query.Encode()
+		if err := tppRequest(ctx, client, http.MethodGet, endpoint, token, nil, &response); err != nil {
+			return nil, err
+		}
+
+		for _, certificate := range response.Certificates {
+			created, err := time.Parse(time.RFC3339, certificate.CreatedOn)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing creation time %q of certificate %s: %w", certificate.CreatedOn, certificate.Name, err)
+			}
+			if created.After(upload.Last) {
+				upload.Last = created
+				upload.LastName = certificate.Name
+			}
+		}
+
+		upload.Certificates = response.TotalCount
+		if len(response.Certificates) == 0 || offset+len(response.Certificates) >= response.TotalCount {
+			break
+		}
+	}
+
+	return &upload, nil
+}
@@ -0,0 +1,82 @@
+package venafi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	alpha1operatorv1 "github.com/jetstack/js-operator/pkg/apis/operator/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastDiscoveryUpload(t *testing.T) {
+	ctx := context.Background()
+
+	// the fake TPP server holds more certificates than fit in one page
+	total := discoveryPageSize + 1
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/vedsdk/certificates/" || r.Header.Get("Authorization") != "Bearer footoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("ParentDnRecursive") != `\VED\Policy\discovered` {
+			json.NewEncoder(w).Encode(map[string]interface{}{"Certificates": []interface{}{}, "TotalCount": 0})
+			return
+		}
+
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		require.NoError(t, err)
+
+		var certificates []map[string]string
+		for i := offset; i < total && i < offset+discoveryPageSize; i++ {
+			certificates = append(certificates, map[string]string{
+				"Name":      fmt.Sprintf("cert-%d", i),
+				"CreatedOn": time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Minute).Format(time.RFC3339Nano),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Certificates": certificates, "TotalCount": total})
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("It should find the most recent certificate across pages", func(t *testing.T) {
+		requests = 0
+		upload, err := LastDiscoveryUpload(ctx, nil, server.URL+"/vedsdk", "discovered", "footoken")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, &DiscoveryUpload{
+			Certificates: total,
+			Last:         time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(total-1) * time.Minute),
+			LastName:     fmt.Sprintf("cert-%d", total-1),
+		}, upload)
+	})
+
+	t.Run("It should report an empty policy folder", func(t *testing.T) {
+		upload, err := LastDiscoveryUpload(ctx, nil, server.URL+"/vedsdk", "empty", "footoken")
+		require.NoError(t, err)
+		assert.Equal(t, &DiscoveryUpload{}, upload)
+	})
+
+	t.Run("It should fail with an invalid token", func(t *testing.T) {
+		_, err := LastDiscoveryUpload(ctx, nil, server.URL+"/vedsdk", "discovered", "bartoken")
+		assert.ErrorIs(t, err, ErrAuthenticationFailed)
+	})
+}
+
+func TestDiscoveryTokenSecret(t *testing.T) {
+	namespace, name, key := DiscoveryTokenSecret(&alpha1operatorv1.CertDiscoveryVenafi{TPP: &alpha1operatorv1.TPP{}})
+	assert.Equal(t, []string{clusterNamespace, "access-token", "access-token"}, []string{namespace, name, key})
+
+	namespace, name, key = DiscoveryTokenSecret(&alpha1operatorv1.CertDiscoveryVenafi{TPP: &alpha1operatorv1.TPP{
+		TokenSecretRef: &alpha1operatorv1.SecretRef{Name: "foo", Key: "token"},
+	}})
+	assert.Equal(t, []string{clusterNamespace, "foo", "token"}, []string{namespace, name, key})
+}
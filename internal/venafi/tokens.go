@@ -0,0 +1,252 @@
+package venafi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+// vohBootstrapSuffix is appended to the name of the Secret referenced by a TPP issuer to give the name of the Secret
+// holding the credentials that venafi-oauth-helper uses to request its access token
+const vohBootstrapSuffix = "-voh-bootstrap"
+
+type (
+	// The ManagedToken type describes a TPP access token that venafi-oauth-helper maintains from the credentials of a
+	// bootstrap Secret.
+	ManagedToken struct {
+		// Namespace is the namespace of the bootstrap and access token Secrets
+		Namespace string
+		// Name is the name of the Secret holding the access token, as referenced by the issuer
+		Name string
+		// Issuer is the kind and name of the issuer using the token, it is empty if no issuer was found
+		Issuer string
+		// URL is the TPP URL of the issuer
+		URL string
+		// AccessToken is the current access token, it is empty if venafi-oauth-helper has not yet created it
+		AccessToken string
+		// Refreshed is the time the access token Secret was last written
+		Refreshed time.Time
+	}
+
+	// The TokenInfo type contains the details TPP gives for a valid access token.
+	TokenInfo struct {
+		Expires     time.Time
+		Application string
+		Scope       string
+	}
+)
+
+// BootstrapSecretName returns the name of the Secret venafi-oauth-helper reads credentials from to create the token.
+func (t *ManagedToken) BootstrapSecretName() string {
+	return t.Name + vohBootstrapSuffix
+}
+
+// FindManagedTokens returns the tokens managed by venafi-oauth-helper in the namespace, which are found by their
+// bootstrap Secrets, sorted by name. Issuers in the namespace, and ClusterIssuers if it is the jetstack-secure
+// namespace, are matched to the tokens so that they can be verified with TPP.
+func FindManagedTokens(ctx context.Context, config *rest.Config, namespace string) ([]*ManagedToken, error) {
+	secretClient, err := clients.NewSecretClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets corev1.SecretList
+	if err := secretClient.List(ctx, &clients.GenericRequestOptions{Namespace: namespace}, &secrets); err != nil {
+		return nil, err
+	}
+
+	issuerClient, err := clients.NewCertManagerIssuerClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// issuers are optional, the cert-manager CRDs may not be installed yet
+	var issuers cmapi.IssuerList
+	err = issuerClient.List(ctx, &clients.GenericRequestOptions{Namespace: namespace}, &issuers)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var clusterIssuers cmapi.ClusterIssuerList
+	if namespace == clusterNamespace {
+		clusterIssuerClient, err := clients.NewCertManagerClusterIssuerClient(config)
+		if err != nil {
+			return nil, err
+		}
+
+		err = clusterIssuerClient.List(ctx, &clients.GenericRequestOptions{}, &clusterIssuers)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return managedTokens(secrets.Items, issuers.Items, clusterIssuers.Items), nil
+}
+
+// managedTokens finds the tokens for each bootstrap Secret in secrets, matching them to the issuers that use them
+func managedTokens(secrets []corev1.Secret, issuers []cmapi.Issuer, clusterIssuers []cmapi.ClusterIssuer) []*ManagedToken {
+	secretsByName := make(map[string]corev1.Secret)
+	for _, secret := range secrets {
+		secretsByName[secret.Namespace+"/"+secret.Name] = secret
+	}
+
+	var tokens []*ManagedToken
+	for _, secret := range secrets {
+		if !strings.HasSuffix(secret.Name, vohBootstrapSuffix) {
+			continue
+		}
+
+		token := &ManagedToken{
+			Namespace: secret.Namespace,
+			Name:      strings.TrimSuffix(secret.Name, vohBootstrapSuffix),
+		}
+
+		if tokenSecret, ok := secretsByName[token.Namespace+"/"+token.Name]; ok {
+			token.AccessToken = string(tokenSecret.Data[accessTokenKey])
+			token.Refreshed = lastWritten(tokenSecret)
+		}
+
+		for _, issuer := range issuers {
+			if issuer.Namespace == token.Namespace && usesTokenSecret(issuer.Spec, token.Name) {
+				token.Issuer = "Issuer/" + issuer.Name
+				token.URL = issuer.Spec.Venafi.TPP.URL
+			}
+		}
+		if token.Namespace == clusterNamespace {
+			for _, issuer := range clusterIssuers {
+				if usesTokenSecret(issuer.Spec, token.Name) {
+					token.Issuer = "ClusterIssuer/" + issuer.Name
+					token.URL = issuer.Spec.Venafi.TPP.URL
+				}
+			}
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Namespace != tokens[j].Namespace {
+			return tokens[i].Namespace < tokens[j].Namespace
+		}
+		return tokens[i].Name < tokens[j].Name
+	})
+
+	return tokens
+}
+
+func usesTokenSecret(spec cmapi.IssuerSpec, name string) bool {
+	return spec.Venafi != nil && spec.Venafi.TPP != nil && spec.Venafi.TPP.CredentialsRef.Name == name
+}
+
+// lastWritten returns the most recent time a Secret was written, as recorded in its managed fields, falling back to
+// its creation time
+func lastWritten(secret corev1.Secret) time.Time {
+	written := secret.CreationTimestamp.Time
+	for _, entry := range secret.ManagedFields {
+		if entry.Time != nil && entry.Time.After(written) {
+			written = entry.Time.Time
+		}
+	}
+	return written
+}
+
+// VerifyToken asks the TPP server at url, the vedsdk URL of an issuer, for the details of an access token. The error
+// wraps ErrAuthenticationFailed if TPP rejects the token, which includes tokens that have expired.
+func VerifyToken(ctx context.Context, client *http.Client, url, token string) (*TokenInfo, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var response struct {
+		Expires     string `json:"expires_ISO8601"`
+		Application string `json:"application"`
+		Scope       string `json:"scope"`
+	}
+	if err := tppRequest(ctx, client, http.MethodGet, tppBaseURL(url)+"/vedauth/authorize/verify", token, nil, &response); err != nil {
+		return nil, err
+	}
+
+	expires, err := time.Parse(time.RFC3339, response.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token expiry %q: %w", response.Expires, err)
+	}
+
+	return &TokenInfo{
+		Expires:     expires,
+		Application: response.Application,
+		Scope:       response.Scope,
+	}, nil
+}
+
+// RotateToken deletes the access token Secret of a managed token so that venafi-oauth-helper requests a new token
+// using the credentials of the bootstrap Secret. Unless the timeout is zero, it then polls until a new token has been
+// written, the timeout expires or the context is cancelled.
+func RotateToken(
+	ctx context.Context,
+	client clients.Generic[*corev1.Secret, *corev1.SecretList],
+	token *ManagedToken,
+	interval, timeout time.Duration,
+	out io.Writer,
+) error {
+	options := &clients.GenericRequestOptions{Namespace: token.Namespace, Name: token.Name}
+
+	// the bootstrap Secret must exist for the token to be recreated
+	var bootstrap corev1.Secret
+	err := client.Get(ctx, &clients.GenericRequestOptions{Namespace: token.Namespace, Name: token.BootstrapSecretName()}, &bootstrap)
+	if err != nil {
+		return fmt.Errorf("error getting bootstrap secret %s/%s: %w", token.Namespace, token.BootstrapSecretName(), err)
+	}
+
+	err = client.Delete(ctx, options)
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Fprintf(out, "token %s/%s: not found, waiting for it to be created\n", token.Namespace, token.Name)
+	case err != nil:
+		return fmt.Errorf("error deleting token secret %s/%s: %w", token.Namespace, token.Name, err)
+	default:
+		fmt.Fprintf(out, "token %s/%s: deleted, waiting for a new token\n", token.Namespace, token.Name)
+	}
+
+	if timeout == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err = wait.PollImmediateUntilWithContext(ctx, interval, func(ctx context.Context) (bool, error) {
+		var secret corev1.Secret
+		err := client.Get(ctx, options, &secret)
+		switch {
+		case apierrors.IsNotFound(err):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+
+		newToken := string(secret.Data[accessTokenKey])
+		return newToken != "" && newToken != token.AccessToken, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s waiting for venafi-oauth-helper to create token %s/%s", timeout, token.Namespace, token.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("error waiting for token %s/%s: %w", token.Namespace, token.Name, err)
+	}
+
+	fmt.Fprintf(out, "token %s/%s: rotated\n", token.Namespace, token.Name)
+	return nil
+}
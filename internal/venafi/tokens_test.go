@@ -0,0 +1,131 @@
+package venafi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/jetstack/jsctl/internal/kubernetes/clients"
+)
+
+func TestManagedTokens(t *testing.T) {
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	refreshed := metav1.NewTime(created.Add(time.Hour))
+
+	tppSpec := func(url, secretName string) cmapi.IssuerSpec {
+		return cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{Venafi: &cmapi.VenafiIssuer{
+			TPP: &cmapi.VenafiTPP{URL: url, CredentialsRef: certmanagermetav1.LocalObjectReference{Name: secretName}},
+		}}}
+	}
+
+	secrets := []corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Name: "foo-jsctl-voh-bootstrap", Namespace: clusterNamespace}},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "foo-jsctl",
+				Namespace:         clusterNamespace,
+				CreationTimestamp: metav1.NewTime(created),
+				ManagedFields:     []metav1.ManagedFieldsEntry{{Manager: "venafi-oauth-helper", Time: &refreshed}},
+			},
+			Data: map[string][]byte{accessTokenKey: []byte("footoken")},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "bar-jsctl-voh-bootstrap", Namespace: clusterNamespace}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "baz-jsctl", Namespace: clusterNamespace}},
+	}
+	clusterIssuers := []cmapi.ClusterIssuer{
+		{ObjectMeta: metav1.ObjectMeta{Name: "foo"}, Spec: tppSpec("https://tpp.example.com/vedsdk", "foo-jsctl")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "selfsigned"}, Spec: cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{SelfSigned: &cmapi.SelfSignedIssuer{}}}},
+	}
+	issuers := []cmapi.Issuer{
+		// issuers in other namespaces cannot use the token
+		{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"}, Spec: tppSpec("https://tpp.example.com/vedsdk", "bar-jsctl")},
+	}
+
+	tokens := managedTokens(secrets, issuers, clusterIssuers)
+
+	assert.Equal(t, []*ManagedToken{
+		{Namespace: clusterNamespace, Name: "bar-jsctl"},
+		{
+			Namespace:   clusterNamespace,
+			Name:        "foo-jsctl",
+			Issuer:      "ClusterIssuer/foo",
+			URL:         "https://tpp.example.com/vedsdk",
+			AccessToken: "footoken",
+			Refreshed:   refreshed.Time,
+		},
+	}, tokens)
+}
+
+func TestVerifyToken(t *testing.T) {
+	ctx := context.Background()
+	url := fakeTPP(t)
+
+	info, err := VerifyToken(ctx, nil, url, "footoken")
+	require.NoError(t, err)
+	assert.Equal(t, &TokenInfo{
+		Expires:     time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		Application: tppClientID,
+		Scope:       tppScope,
+	}, info)
+
+	_, err = VerifyToken(ctx, nil, url, "bartoken")
+	assert.ErrorIs(t, err, ErrAuthenticationFailed)
+}
+
+func TestRotateToken(t *testing.T) {
+	ctx := context.Background()
+
+	// the fake API server recreates the token secret with a new token on the
+	// first read after it has been deleted, as venafi-oauth-helper would
+	var deleted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/namespaces/jetstack-secure/secrets/foo-jsctl-voh-bootstrap" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "foo-jsctl-voh-bootstrap"}})
+		case r.URL.Path == "/api/v1/namespaces/jetstack-secure/secrets/foo-jsctl" && r.Method == http.MethodDelete:
+			deleted = true
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusSuccess})
+		case r.URL.Path == "/api/v1/namespaces/jetstack-secure/secrets/foo-jsctl" && r.Method == http.MethodGet && deleted:
+			json.NewEncoder(w).Encode(corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-jsctl"},
+				Data:       map[string][]byte{accessTokenKey: []byte("newtoken")},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := clients.NewSecretClient(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	t.Run("It should delete the token and wait for a new one", func(t *testing.T) {
+		var out bytes.Buffer
+		token := &ManagedToken{Namespace: clusterNamespace, Name: "foo-jsctl", AccessToken: "footoken"}
+		err := RotateToken(ctx, client, token, time.Millisecond, time.Second, &out)
+		require.NoError(t, err)
+
+		assert.True(t, deleted)
+		assert.Contains(t, out.String(), "token jetstack-secure/foo-jsctl: rotated")
+	})
+
+	t.Run("It should fail without a bootstrap secret", func(t *testing.T) {
+		token := &ManagedToken{Namespace: clusterNamespace, Name: "bar-jsctl"}
+		err := RotateToken(ctx, client, token, time.Millisecond, time.Second, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "error getting bootstrap secret jetstack-secure/bar-jsctl-voh-bootstrap")
+	})
+}
@@ -14,6 +14,7 @@ import (
 
 const (
 	tppType                  = "tpp"
+	vaasType                 = "vaas"
 	issuerSecretNameTemplate = "%s-jsctl"
 
 	clusterNamespace = "jetstack-secure"
@@ -21,21 +22,34 @@ const (
 	usernameKey    = "username"
 	passwordKey    = "password"
 	accessTokenKey = "access-token"
+	apiKeyKey      = "api-key"
 
 	errMsgInvalidIssuerTemplate    = "invalid isuer template expected 'type:connection:name:[namespace] got %s"
-	errMsgInvalidIssuerType        = "invalid issuer type: %s, valid types are: [tpp]"
+	errMsgInvalidIssuerType        = "invalid issuer type: %s, valid types are: [tpp vaas]"
 	errMsgMissingVenafiConnection  = "VenafiConnection %s not found. Make sure that it is included in config passed to --experimental-venafi-connections-config"
 	errMsgIncompleteIssuerTemplate = "internal error (please report this): issuer template is empty or missing venafi connection details: %+#v"
 	errMsgMissingConnectionCreds   = "missing credentials: expected either Venafi access token or username and password: got access-token %s, username: %s, password: %s"
+	errMsgMissingAPIKey            = "missing credentials: expected a Venafi as a Service API key for issuer %s"
 )
 
-// VenafiConnection holds connection details for a Venafi server
+// VenafiConnection holds connection details for a Venafi server. TPP
+// connections use either an access token or a username and password, while
+// Venafi as a Service connections use an API key. The URL of a Venafi as a
+// Service connection may be left blank to use the default. Each credential can
+// instead be read from a CredentialSource by ResolveCredentials, so that it does
+// not need to be stored in plain text.
 type VenafiConnection struct {
 	URL         string `yaml:"url,omitempty"`
 	Zone        string `yaml:"zone,omitempty"`
 	AccessToken string `yaml:"access-token,omitempty"`
 	Username    string `yaml:"username,omitempty"`
 	Password    string `yaml:"password,omitempty"`
+	APIKey      string `yaml:"api-key,omitempty"`
+
+	AccessTokenFrom *CredentialSource `yaml:"access-token-from,omitempty"`
+	UsernameFrom    *CredentialSource `yaml:"username-from,omitempty"`
+	PasswordFrom    *CredentialSource `yaml:"password-from,omitempty"`
+	APIKeyFrom      *CredentialSource `yaml:"api-key-from,omitempty"`
 }
 
 type VenafiIssuer struct {
@@ -74,6 +88,8 @@ func ParseIssuerConfig(issuers []string, vcs map[string]*VenafiConnection, vohEn
 		switch {
 		case parts[0] == tppType:
 			iss.IssuerType = tppType
+		case parts[0] == vaasType:
+			iss.IssuerType = vaasType
 		default:
 			return nil, fmt.Errorf(errMsgInvalidIssuerType, parts[0])
 		}
@@ -84,8 +100,9 @@ func ParseIssuerConfig(issuers []string, vcs map[string]*VenafiConnection, vohEn
 			return nil, fmt.Errorf(errMsgMissingVenafiConnection, parts[1])
 		}
 		conn := &Conn{
-			VC:           vc,
-			ManagedByVOH: vohEnabled,
+			VC: vc,
+			// venafi-oauth-helper only manages TPP access tokens
+			ManagedByVOH: vohEnabled && iss.IssuerType == tppType,
 		}
 		iss.Conn = conn
 		vi[i] = iss
@@ -93,8 +110,9 @@ func ParseIssuerConfig(issuers []string, vcs map[string]*VenafiConnection, vohEn
 	return vi, nil
 }
 
-// ParseCertDiscoveryVenafiConfig parses provided Venafi connection details and validates that they are suitable.
-func ParseCertDiscoveryVenafiConfig(vcName string, vcs map[string]*VenafiConnection, cdvEnabled bool) (*VenafiConnection, error) {
+// ParseCertDiscoveryVenafiConfig parses provided Venafi connection details and validates that they are suitable. If
+// policyFolder is set, it replaces the zone of the connection as the TPP policy folder certificates are uploaded to.
+func ParseCertDiscoveryVenafiConfig(vcName, policyFolder string, vcs map[string]*VenafiConnection, cdvEnabled bool) (*VenafiConnection, error) {
 	if !cdvEnabled {
 		return nil, nil
 	}
@@ -105,7 +123,13 @@ func ParseCertDiscoveryVenafiConfig(vcName string, vcs map[string]*VenafiConnect
 	}
 
 	if len(vc.AccessToken) > 0 {
-		return vc, nil
+		if policyFolder == "" {
+			return vc, nil
+		}
+		// the connection may also be used by issuers, so it is copied rather than modified
+		cdvConn := *vc
+		cdvConn.Zone = policyFolder
+		return &cdvConn, nil
 	} else if len(vc.Username) > 0 && len(vc.Password) > 0 {
 		return nil, errors.New("incorrect connection credentials for cert-discovery-venafi, expected access token got username and password")
 	} else {
@@ -118,6 +142,10 @@ func GenerateOperatorManifestsForIssuer(issuer *VenafiIssuer) (*alpha1operatorv1
 	if issuer == nil || issuer.Conn == nil || issuer.Conn.VC == nil {
 		return nil, nil, fmt.Errorf(errMsgIncompleteIssuerTemplate, issuer)
 	}
+	if issuer.IssuerType == vaasType {
+		return generateOperatorManifestsForVaasIssuer(issuer)
+	}
+
 	vc := issuer.Conn.VC
 	iss := &alpha1operatorv1.Issuer{
 		Venafi: &cmapi.VenafiIssuer{
@@ -149,7 +177,7 @@ func GenerateOperatorManifestsForIssuer(issuer *VenafiIssuer) (*alpha1operatorv1
 	secret.Namespace = namespace
 	name := iss.Venafi.TPP.CredentialsRef.Name
 	if issuer.Conn.ManagedByVOH {
-		name = fmt.Sprintf("%s-voh-bootstrap", iss.Venafi.TPP.CredentialsRef.Name)
+		name = iss.Venafi.TPP.CredentialsRef.Name + vohBootstrapSuffix
 	}
 
 	secret.Name = name
@@ -168,6 +196,50 @@ func GenerateOperatorManifestsForIssuer(issuer *VenafiIssuer) (*alpha1operatorv1
 
 }
 
+// generateOperatorManifestsForVaasIssuer generates a Venafi as a Service
+// issuer and the Secret containing its API key
+func generateOperatorManifestsForVaasIssuer(issuer *VenafiIssuer) (*alpha1operatorv1.Issuer, *corev1.Secret, error) {
+	vc := issuer.Conn.VC
+	if len(vc.APIKey) == 0 {
+		return nil, nil, fmt.Errorf(errMsgMissingAPIKey, issuer.Name)
+	}
+
+	secretName := fmt.Sprintf(issuerSecretNameTemplate, issuer.Name)
+	iss := &alpha1operatorv1.Issuer{
+		Name:         issuer.Name,
+		Namespace:    issuer.Namespace,
+		ClusterScope: issuer.ClusterScope,
+		Venafi: &cmapi.VenafiIssuer{
+			Zone: vc.Zone,
+			Cloud: &cmapi.VenafiCloud{
+				URL: vc.URL,
+				APITokenSecretRef: certmanagermetav1.SecretKeySelector{
+					LocalObjectReference: certmanagermetav1.LocalObjectReference{Name: secretName},
+					Key:                  apiKeyKey,
+				},
+			},
+		},
+	}
+
+	namespace := issuer.Namespace
+	if issuer.ClusterScope {
+		namespace = clusterNamespace
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{apiKeyKey: []byte(vc.APIKey)},
+	}
+
+	return iss, secret, nil
+}
+
 func GenerateManifestsForCertDiscoveryVenafi(vc *VenafiConnection) (*alpha1operatorv1.CertDiscoveryVenafi, *corev1.Secret) {
 	cdv := &alpha1operatorv1.CertDiscoveryVenafi{
 		TPP: &alpha1operatorv1.TPP{
@@ -94,6 +94,32 @@ func TestParseIssuerConfig(t *testing.T) {
 				},
 			}},
 		},
+		"create a Venafi as a Service issuer": {
+			issuers: []string{"vaas:cloud:foo:foo"},
+			vcs:     map[string]*VenafiConnection{"cloud": {Zone: "app\\template", APIKey: "foo"}},
+			expectedIssuers: []*VenafiIssuer{{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{Zone: "app\\template", APIKey: "foo"},
+				},
+			}},
+		},
+		"do not manage Venafi as a Service credentials with voh": {
+			issuers:    []string{"vaas:cloud:foo"},
+			vcs:        map[string]*VenafiConnection{"cloud": {APIKey: "foo"}},
+			vohEnabled: true,
+			expectedIssuers: []*VenafiIssuer{{
+				IssuerType:   "vaas",
+				Name:         "foo",
+				ClusterScope: true,
+				Conn: &Conn{
+					VC:           &VenafiConnection{APIKey: "foo"},
+					ManagedByVOH: false,
+				},
+			}},
+		},
 		"record more than one issuer": {
 			issuers: []string{"tpp:default:foo:foo", "tpp:bar:bar"},
 			vcs: map[string]*VenafiConnection{"default": baseConnection,
@@ -320,6 +346,51 @@ func TestGenerateOperatorManifestsForIssuer(t *testing.T) {
 				Data: map[string][]byte{accessTokenKey: []byte("foo")},
 			},
 		},
+		"create a cluster scoped Venafi as a Service issuer and Secret": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType:   "vaas",
+				Name:         "foo",
+				ClusterScope: true,
+				Conn: &Conn{
+					VC: &VenafiConnection{Zone: "app\\template", APIKey: "foo"},
+				},
+			},
+			expectedIssuer: &operatorv1alpha1.Issuer{
+				Name:         "foo",
+				ClusterScope: true,
+				Venafi: &cmapi.VenafiIssuer{
+					Zone: "app\\template",
+					Cloud: &cmapi.VenafiCloud{
+						APITokenSecretRef: certmanagermetav1.SecretKeySelector{
+							LocalObjectReference: certmanagermetav1.LocalObjectReference{Name: "foo-jsctl"},
+							Key:                  "api-key",
+						},
+					},
+				},
+			},
+			expectedSecret: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-jsctl",
+					Namespace: "jetstack-secure",
+				},
+				Data: map[string][]byte{apiKeyKey: []byte("foo")},
+			},
+		},
+		"error out if a Venafi as a Service issuer has no API key": {
+			issuerTemplate: &VenafiIssuer{
+				IssuerType: "vaas",
+				Name:       "foo",
+				Namespace:  "foo",
+				Conn: &Conn{
+					VC: &VenafiConnection{Zone: "foo", AccessToken: "foo"},
+				},
+			},
+			expectedErr: fmt.Sprintf(errMsgMissingAPIKey, "foo"),
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -344,6 +415,7 @@ func TestParseCertDiscoveryVenafiConfig(t *testing.T) {
 	}
 	tests := map[string]struct {
 		connName     string
+		policyFolder string
 		conns        map[string]*VenafiConnection
 		expectedConn *VenafiConnection
 		cdvEnabled   bool
@@ -404,10 +476,21 @@ func TestParseCertDiscoveryVenafiConfig(t *testing.T) {
 			cdvEnabled:   true,
 			expectedConn: baseConn,
 		},
+		"override the zone with the policy folder": {
+			connName:     "fooConn",
+			policyFolder: `\VED\Policy\discovered`,
+			conns:        map[string]*VenafiConnection{"fooConn": baseConn},
+			cdvEnabled:   true,
+			expectedConn: &VenafiConnection{
+				URL:         "foo",
+				Zone:        `\VED\Policy\discovered`,
+				AccessToken: "footoken",
+			},
+		},
 	}
 	for name, scenario := range tests {
 		t.Run(name, func(t *testing.T) {
-			gotConn, err := ParseCertDiscoveryVenafiConfig(scenario.connName, scenario.conns, scenario.cdvEnabled)
+			gotConn, err := ParseCertDiscoveryVenafiConfig(scenario.connName, scenario.policyFolder, scenario.conns, scenario.cdvEnabled)
 
 			if scenario.expectedErr == "" {
 				assert.NoError(t, err)
@@ -416,6 +499,8 @@ func TestParseCertDiscoveryVenafiConfig(t *testing.T) {
 			}
 
 			assert.Equal(t, gotConn, scenario.expectedConn)
+			// the connection should not be modified for other uses
+			assert.Equal(t, "foozone", baseConn.Zone)
 		})
 	}
 }